@@ -0,0 +1,125 @@
+package goquirc
+
+import "sort"
+
+// StructuredAppend describes the place of a qrcode inside a message split
+// across several symbols
+type StructuredAppend struct {
+	Index  int
+	Total  int
+	Parity byte
+}
+
+// Message is a payload reassembled from structured append qrcodes
+type Message struct {
+	Total    int
+	Parity   byte
+	Parts    []QRcode
+	Payload  []byte
+	Complete bool
+}
+
+// Text returns the reassembled payload as an UTF-8 string, transcoded
+// according to its first part
+func (m Message) Text() string {
+	if len(m.Parts) == 0 {
+		return string(m.Payload)
+	}
+	dataType := DataTypeNumeric
+	for _, part := range m.Parts {
+		if part.DataType > dataType {
+			dataType = part.DataType
+		}
+	}
+	return transcode(m.Payload, m.Parts[0].ECI, dataType)
+}
+
+// messageKey identifies the symbols belonging to the same message
+type messageKey struct {
+	total  int
+	parity byte
+}
+
+// Assembler gathers structured append qrcodes across several frames until
+// their messages are complete
+type Assembler struct {
+	messages map[messageKey]*Message
+}
+
+// NewAssembler creates an empty assembler
+func NewAssembler() *Assembler {
+	return &Assembler{messages: make(map[messageKey]*Message)}
+}
+
+// Add records a qrcode and returns its message once all its parts were seen,
+// qrcodes without structured append header are ignored
+func (a *Assembler) Add(code QRcode) (Message, bool) {
+	if code.Append == nil {
+		return Message{}, false
+	}
+
+	key := messageKey{code.Append.Total, code.Append.Parity}
+	m, ok := a.messages[key]
+	if !ok {
+		m = &Message{Total: key.total, Parity: key.parity}
+		a.messages[key] = m
+	}
+	for _, part := range m.Parts {
+		if part.Append.Index == code.Append.Index {
+			return *m, m.Complete
+		}
+	}
+
+	m.Parts = append(m.Parts, code)
+	sort.Slice(m.Parts, func(i, j int) bool {
+		return m.Parts[i].Append.Index < m.Parts[j].Append.Index
+	})
+	m.assemble()
+
+	if m.Complete {
+		delete(a.messages, key)
+	}
+	return *m, m.Complete
+}
+
+// Pending returns the messages still missing some parts
+func (a *Assembler) Pending() []Message {
+	var messages []Message
+	for _, m := range a.messages {
+		messages = append(messages, *m)
+	}
+	sort.Slice(messages, func(i, j int) bool {
+		if messages[i].Total != messages[j].Total {
+			return messages[i].Total < messages[j].Total
+		}
+		return messages[i].Parity < messages[j].Parity
+	})
+	return messages
+}
+
+// Assemble groups structured append qrcodes of a single image into their
+// messages, complete or not
+func Assemble(codes []QRcode) []Message {
+	a := NewAssembler()
+	var messages []Message
+	for _, code := range codes {
+		if m, complete := a.Add(code); complete {
+			messages = append(messages, m)
+		}
+	}
+	return append(messages, a.Pending()...)
+}
+
+// assemble concatenates parts and checks the message against its parity
+func (m *Message) assemble() {
+	m.Payload = nil
+	for _, part := range m.Parts {
+		m.Payload = append(m.Payload, part.Payload...)
+	}
+
+	var parity byte
+	for _, b := range m.Payload {
+		parity ^= b
+	}
+	m.Complete = len(m.Parts) == m.Total && parity == m.Parity
+}
@@ -0,0 +1,79 @@
+package goquirc
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// Frame is a grayscale source image submitted for asynchronous decoding,
+// Sequence is left untouched and helps to match results with their frame
+type Frame struct {
+	Sequence int
+	Image    []byte
+	Width    int
+	Height   int
+}
+
+// FrameResult is the outcome of the reveal process of a frame
+type FrameResult struct {
+	Frame  Frame
+	Result Result
+	Err    error
+}
+
+// DecodeAsync decodes the frames received on in with workers goroutines, each
+// one owning its decoder, and emits their results on the returned channel.
+// Results may come out of order, the channel is closed once in is closed and
+// drained or ctx is done. A workers count lower than one falls back to
+// GOMAXPROCS
+func DecodeAsync(ctx context.Context, in <-chan Frame, workers int, opts ...Option) (<-chan FrameResult, error) {
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	decoders := make([]*Decoder, 0, workers)
+	for i := 0; i < workers; i++ {
+		d, err := NewDecoder()
+		if err != nil {
+			for _, d := range decoders {
+				d.Close()
+			}
+			return nil, err
+		}
+		decoders = append(decoders, d)
+	}
+
+	out := make(chan FrameResult, workers)
+	var wg sync.WaitGroup
+	for _, d := range decoders {
+		wg.Add(1)
+		go func(d *Decoder) {
+			defer wg.Done()
+			defer d.Close()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case frame, ok := <-in:
+					if !ok {
+						return
+					}
+					result, err := d.RevealContext(ctx, &frame.Image, frame.Width, frame.Height, opts...)
+					select {
+					case out <- FrameResult{Frame: frame, Result: result, Err: err}:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}(d)
+	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+
+	return out, nil
+}
@@ -0,0 +1,267 @@
+// Package awslambda provides an AWS Lambda handler revealing the qrcodes of
+// images received through API Gateway, or of the objects named by S3 event
+// notifications, and answering with their JSON form
+//
+// The handler is given to lambda.Start, S3 objects being read through a
+// Fetcher such as one over the client of aws-sdk-go-v2:
+//
+//	func main() {
+//		cfg, err := config.LoadDefaultConfig(context.Background())
+//		if err != nil {
+//			log.Fatal(err)
+//		}
+//		client := s3.NewFromConfig(cfg)
+//		lambda.Start(&awslambda.Handler{
+//			Fetch: func(ctx context.Context, bucket string, key string) (io.ReadCloser, error) {
+//				out, err := client.GetObject(ctx, &s3.GetObjectInput{Bucket: &bucket, Key: &key})
+//				if err != nil {
+//					return nil, err
+//				}
+//				return out.Body, nil
+//			},
+//		})
+//	}
+//
+// # Building
+//
+// The provided.al2023 and provided.al2 runtimes run a binary named bootstrap.
+// Linking it statically against musl, along with a libquirc built by the same
+// compiler, frees it from the glibc of the runtime. From the main package of
+// the function, with goquirc checked out next to it and a replace directive
+// pointing go.mod at the checkout:
+//
+//	make -C ../goquirc/quirc CC=musl-gcc libquirc.a
+//	CC=musl-gcc GOOS=linux go build -tags lambda.norpc,goquirc_static -ldflags '-s -w' -o bootstrap .
+//	zip function.zip bootstrap
+//
+// The architecture of the function, x86_64 or arm64, must match the one of
+// the compiler, such as aarch64-linux-musl-gcc with GOARCH=arm64.
+package awslambda
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"github.com/quaresc/goquirc"
+	"github.com/quaresc/goquirc/internal/imagedecode"
+	"github.com/quaresc/goquirc/internal/jsonresult"
+)
+
+// DefaultMaxBytes bounds the size of S3 objects when Handler leaves MaxBytes
+// unset, API Gateway payloads being bounded by Lambda itself
+const DefaultMaxBytes = 32 << 20
+
+// DefaultMaxPixels bounds the dimensions of images when Handler leaves
+// MaxPixels unset
+const DefaultMaxPixels = imagedecode.DefaultMaxPixels
+
+// Fetcher opens the object key of an S3 bucket
+type Fetcher func(ctx context.Context, bucket string, key string) (io.ReadCloser, error)
+
+// Handler reveals the qrcodes of the images of Lambda invocations.
+//
+// API Gateway requests, both REST proxy and HTTP API ones, carry an encoded
+// image as body. They are answered with the JSON form of its result, or with
+// {"error": "..."} and a 4xx or 5xx status.
+//
+// S3 event notifications are answered with {"objects": [...]}, holding in
+// order the result of every object with its bucket and key, and an error for
+// the ones which could not be read or decoded
+type Handler struct {
+	// Pool runs the reveal processes, typically a *goquirc.Pool, it must be
+	// safe for concurrent use. When nil each image gets its own quirc
+	// context
+	Pool goquirc.Revealer
+
+	// Options tune every reveal process
+	Options []goquirc.Option
+
+	// Fetch opens S3 objects, it is required by S3 events only
+	Fetch Fetcher
+
+	// MaxBytes bounds the size of S3 objects, zero falls back to
+	// DefaultMaxBytes
+	MaxBytes int64
+
+	// MaxPixels bounds the dimensions of images, larger ones being rejected
+	// before they are decoded, zero falls back to DefaultMaxPixels
+	MaxPixels int
+}
+
+// Object is the JSON form of the result of an S3 object
+type Object struct {
+	Bucket string `json:"bucket"`
+	Key    string `json:"key"`
+	Error  string `json:"error,omitempty"`
+	jsonresult.Result
+}
+
+// S3Response is the JSON form of the results of the objects of an S3 event
+type S3Response struct {
+	Objects []Object `json:"objects"`
+}
+
+// invocation holds the fields telling apart the payloads of invocations
+type invocation struct {
+	Records []struct {
+		EventSource string `json:"eventSource"`
+	} `json:"Records"`
+	Version        string          `json:"version"`
+	HTTPMethod     string          `json:"httpMethod"`
+	RequestContext json.RawMessage `json:"requestContext"`
+}
+
+// Invoke handles the payload of an invocation, as lambda.Start expects
+func (h *Handler) Invoke(ctx context.Context, payload []byte) ([]byte, error) {
+	var probe invocation
+	if err := json.Unmarshal(payload, &probe); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case len(probe.Records) > 0 && probe.Records[0].EventSource == "aws:s3":
+		var event events.S3Event
+		if err := json.Unmarshal(payload, &event); err != nil {
+			return nil, err
+		}
+		return json.Marshal(h.HandleS3(ctx, event))
+	case probe.RequestContext != nil && probe.Version == "2.0":
+		var request events.APIGatewayV2HTTPRequest
+		if err := json.Unmarshal(payload, &request); err != nil {
+			return nil, err
+		}
+		return json.Marshal(h.HandleHTTP(ctx, request))
+	case probe.RequestContext != nil && probe.HTTPMethod != "":
+		var request events.APIGatewayProxyRequest
+		if err := json.Unmarshal(payload, &request); err != nil {
+			return nil, err
+		}
+		return json.Marshal(h.HandleProxy(ctx, request))
+	}
+	return nil, errors.New("Unsupported invocation payload, expected an API Gateway request or an S3 event")
+}
+
+// HandleProxy answers an API Gateway REST proxy request
+func (h *Handler) HandleProxy(ctx context.Context, request events.APIGatewayProxyRequest) events.APIGatewayProxyResponse {
+	code, body := h.handleBody(ctx, request.Body, request.IsBase64Encoded)
+	return events.APIGatewayProxyResponse{
+		StatusCode: code,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       body,
+	}
+}
+
+// HandleHTTP answers an API Gateway HTTP API request
+func (h *Handler) HandleHTTP(ctx context.Context, request events.APIGatewayV2HTTPRequest) events.APIGatewayV2HTTPResponse {
+	code, body := h.handleBody(ctx, request.Body, request.IsBase64Encoded)
+	return events.APIGatewayV2HTTPResponse{
+		StatusCode: code,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       body,
+	}
+}
+
+// handleBody reveals the image of a request body and returns the status and
+// the JSON body of the response
+func (h *Handler) handleBody(ctx context.Context, body string, encoded bool) (int, string) {
+	data := []byte(body)
+	if encoded {
+		var err error
+		if data, err = base64.StdEncoding.DecodeString(body); err != nil {
+			return errorResponse(http.StatusBadRequest, fmt.Errorf("Invalid base64 body: %w", err))
+		}
+	}
+
+	result, err := h.decode(ctx, bytes.NewReader(data))
+	var bad badImage
+	switch {
+	case errors.As(err, &bad):
+		return errorResponse(http.StatusBadRequest, err)
+	case err != nil:
+		return errorResponse(http.StatusInternalServerError, err)
+	}
+	out, err := json.Marshal(jsonresult.New(result))
+	if err != nil {
+		return errorResponse(http.StatusInternalServerError, err)
+	}
+	return http.StatusOK, string(out)
+}
+
+// HandleS3 reveals the qrcodes of the objects of an S3 event
+func (h *Handler) HandleS3(ctx context.Context, event events.S3Event) S3Response {
+	objects := make([]Object, 0, len(event.Records))
+	for _, record := range event.Records {
+		object := Object{
+			Bucket: record.S3.Bucket.Name,
+			Key:    record.S3.Object.URLDecodedKey,
+			Result: jsonresult.Empty(),
+		}
+		if result, err := h.fetch(ctx, object.Bucket, object.Key); err != nil {
+			object.Error = err.Error()
+		} else {
+			object.Result = jsonresult.New(result)
+		}
+		objects = append(objects, object)
+	}
+	return S3Response{Objects: objects}
+}
+
+// fetch reveals the qrcodes of an S3 object
+func (h *Handler) fetch(ctx context.Context, bucket string, key string) (goquirc.Result, error) {
+	if h.Fetch == nil {
+		return goquirc.Result{}, errors.New("Handler has no Fetch to read S3 objects")
+	}
+	body, err := h.Fetch(ctx, bucket, key)
+	if err != nil {
+		return goquirc.Result{}, err
+	}
+	defer body.Close()
+
+	limit := h.MaxBytes
+	if limit <= 0 {
+		limit = DefaultMaxBytes
+	}
+	data, err := io.ReadAll(io.LimitReader(body, limit+1))
+	if err != nil {
+		return goquirc.Result{}, err
+	}
+	if int64(len(data)) > limit {
+		return goquirc.Result{}, fmt.Errorf("Object exceeds %d bytes", limit)
+	}
+	return h.decode(ctx, bytes.NewReader(data))
+}
+
+// decode reveals the qrcodes of an encoded image
+func (h *Handler) decode(ctx context.Context, r io.Reader) (goquirc.Result, error) {
+	gray, width, height, err := imagedecode.Luminance(r, h.MaxPixels)
+	if err != nil {
+		return goquirc.Result{}, badImage{err}
+	}
+	return imagedecode.Reveal(ctx, h.Pool, h.Options, gray, width, height)
+}
+
+// badImage marks the errors caused by the content of an image
+type badImage struct {
+	error
+}
+
+// Unwrap returns the underlying error
+func (e badImage) Unwrap() error {
+	return e.error
+}
+
+// errorResponse returns the status and the JSON body answering an error
+func errorResponse(code int, err error) (int, string) {
+	out, _ := json.Marshal(struct {
+		Error string `json:"error"`
+	}{err.Error()})
+	return code, string(out)
+}
@@ -0,0 +1,59 @@
+package goquirc
+
+import (
+	"context"
+	"image"
+	"sync"
+)
+
+// ImageInput is a source image of a batch, given either as a grayscale
+// buffer with its dimensions or, when Source is set, as any image.Image
+type ImageInput struct {
+	Image  []byte
+	Width  int
+	Height int
+	Source image.Image
+}
+
+// BatchResult is the outcome of the reveal process of a batch input
+type BatchResult struct {
+	Result Result
+	Err    error
+}
+
+// DecodeBatch reveals the qrcodes of all inputs with a pool of workers
+// decoders and returns their results in input order. The inputs left when ctx
+// is done report its error. A workers count lower than one falls back to
+// GOMAXPROCS
+func DecodeBatch(ctx context.Context, inputs []ImageInput, workers int, opts ...Option) ([]BatchResult, error) {
+	pool, err := NewPool(workers)
+	if err != nil {
+		return nil, err
+	}
+	defer pool.Close()
+
+	results := make([]BatchResult, len(inputs))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for n := 0; n < pool.Size(); n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				input := inputs[i]
+				gray, w, h := input.Image, input.Width, input.Height
+				if input.Source != nil {
+					gray, w, h = Luminance(input.Source)
+				}
+				results[i].Result, results[i].Err = pool.RevealContext(ctx, &gray, w, h, opts...)
+			}
+		}()
+	}
+	for i := range inputs {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	return results, nil
+}
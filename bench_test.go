@@ -0,0 +1,128 @@
+package goquirc_test
+
+import (
+	"image"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/quaresc/goquirc"
+)
+
+// corpus lists the testdata images: a single qrcode in small and 4K frames,
+// a sheet of 48 qrcodes, an image without any and a qrcode which fails to
+// decode
+var corpus = []string{"small", "large", "sheet", "noise", "damaged"}
+
+// load decodes a testdata image
+func load(b *testing.B, name string) image.Image {
+	b.Helper()
+	f, err := os.Open(filepath.Join("testdata", name+".png"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer f.Close()
+
+	img, err := png.Decode(f)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return img
+}
+
+func BenchmarkReveal(b *testing.B) {
+	for _, name := range corpus {
+		b.Run(name, func(b *testing.B) {
+			gray, w, h := goquirc.Luminance(load(b, name))
+			b.SetBytes(int64(len(gray)))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				var qr goquirc.Processing
+				if _, err := qr.Reveal(&gray, w, h); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkDecoderReveal(b *testing.B) {
+	d, err := goquirc.NewDecoder()
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer d.Close()
+
+	for _, name := range corpus {
+		b.Run(name, func(b *testing.B) {
+			gray, w, h := goquirc.Luminance(load(b, name))
+			b.SetBytes(int64(len(gray)))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := d.Reveal(&gray, w, h); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkLoad(b *testing.B) {
+	gray, w, h := goquirc.Luminance(load(b, "large"))
+
+	var qr goquirc.Processing
+	if err := qr.Create(); err != nil {
+		b.Fatal(err)
+	}
+	defer qr.Destroy()
+	if err := qr.Resize(w, h); err != nil {
+		b.Fatal(err)
+	}
+
+	b.SetBytes(int64(len(gray)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		qr.Load(&gray)
+	}
+}
+
+func BenchmarkLuminance(b *testing.B) {
+	src := load(b, "large")
+	rgba := image.NewRGBA(src.Bounds())
+	draw.Draw(rgba, rgba.Bounds(), src, image.Point{}, draw.Src)
+	ycbcr := image.NewYCbCr(src.Bounds(), image.YCbCrSubsampleRatio420)
+
+	for _, img := range []struct {
+		name string
+		img  image.Image
+	}{{"gray", src}, {"rgba", rgba}, {"ycbcr", ycbcr}} {
+		b.Run(img.name, func(b *testing.B) {
+			b.SetBytes(int64(img.img.Bounds().Dx() * img.img.Bounds().Dy()))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				goquirc.Luminance(img.img)
+			}
+		})
+	}
+}
+
+func BenchmarkDecodeReader(b *testing.B) {
+	path := filepath.Join("testdata", "small.png")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		f, err := os.Open(path)
+		if err != nil {
+			b.Fatal(err)
+		}
+		_, err = goquirc.DecodeReader(f)
+		f.Close()
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
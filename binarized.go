@@ -0,0 +1,10 @@
+package goquirc
+
+// #include "regions.h"
+import "C"
+
+// EndBinarized works like End on a source image already binarized by the
+// caller, pixels below 128 being dark, and only groups the finder patterns
+func (qr *Processing) EndBinarized() {
+	C.goquirc_end_binarized(qr.qrStruct)
+}
@@ -0,0 +1,89 @@
+package goquirc
+
+import "math"
+
+// Gaussian blurs images with a gaussian kernel of standard deviation sigma
+// pixels, which smooths the sensor noise fragmenting finder patterns of high
+// ISO frames (usually 0.5 to 1.5)
+func Gaussian(sigma float64) Filter {
+	return func(pix []byte, w int, h int) []byte {
+		if sigma <= 0 {
+			return append([]byte(nil), pix...)
+		}
+		blurred := gaussian(pix, w, h, sigma)
+		out := make([]byte, len(pix))
+		for i, v := range blurred {
+			out[i] = byte(v + 0.5)
+		}
+		return out
+	}
+}
+
+// gaussian convolves an image with a separable gaussian kernel, the edges
+// being extended
+func gaussian(pix []byte, w int, h int, sigma float64) []float32 {
+	radius := int(math.Ceil(3 * sigma))
+	kernel := make([]float32, 2*radius+1)
+	var total float32
+	for i := range kernel {
+		d := float64(i - radius)
+		kernel[i] = float32(math.Exp(-d * d / (2 * sigma * sigma)))
+		total += kernel[i]
+	}
+	for i := range kernel {
+		kernel[i] /= total
+	}
+
+	horizontal := make([]float32, len(pix))
+	for y := 0; y < h; y++ {
+		row := pix[y*w : (y+1)*w]
+		for x := range row {
+			var v float32
+			for i, k := range kernel {
+				v += k * float32(row[clamp(x+i-radius, w)])
+			}
+			horizontal[y*w+x] = v
+		}
+	}
+
+	out := make([]float32, len(pix))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var v float32
+			for i, k := range kernel {
+				v += k * horizontal[clamp(y+i-radius, h)*w+x]
+			}
+			out[y*w+x] = v
+		}
+	}
+	return out
+}
+
+// clamp keeps a coordinate within an axis of length pixels
+func clamp(c int, length int) int {
+	if c < 0 {
+		return 0
+	}
+	if c >= length {
+		return length - 1
+	}
+	return c
+}
+
+// Unsharp sharpens images by adding amount times their difference with a
+// gaussian blur of standard deviation radius pixels, which restores the
+// module edges of slightly defocused captures (usually amount 0.5 to 1.5)
+func Unsharp(radius float64, amount float64) Filter {
+	return func(pix []byte, w int, h int) []byte {
+		if radius <= 0 {
+			return append([]byte(nil), pix...)
+		}
+		blurred := gaussian(pix, w, h, radius)
+		out := make([]byte, len(pix))
+		for i, p := range pix {
+			v := float64(p) + amount*(float64(p)-float64(blurred[i]))
+			out[i] = byte(math.Max(0, math.Min(255, v+0.5)))
+		}
+		return out
+	}
+}
@@ -0,0 +1,153 @@
+// Package capture reads frames from a V4L2 camera and reveals their qrcodes
+// with a Decoder, a complete camera to payload pipeline on Linux without
+// ffmpeg or any other process in between
+//
+// Open negotiates the format whose luminance is the cheapest to read, a
+// grayscale or YUV one when the camera has it and Motion JPEG otherwise, and
+// Run streams memory mapped buffers straight into the quirc image buffer:
+//
+//	c, err := capture.Open(capture.Config{Device: "/dev/video0", Width: 640, Height: 480})
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	defer c.Close()
+//
+//	d, err := goquirc.NewDecoder()
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	defer d.Close()
+//
+//	err = c.Run(ctx, d, func(frame capture.Frame) bool {
+//		for _, code := range frame.Result.Code {
+//			fmt.Println(code.Text())
+//		}
+//		return true
+//	})
+package capture
+
+import (
+	"bytes"
+	"errors"
+	"image/jpeg"
+	"time"
+
+	"github.com/quaresc/goquirc"
+)
+
+// DefaultDevice is the camera opened when Config.Device is empty
+const DefaultDevice = "/dev/video0"
+
+// defaultBuffers is the count of buffers requested when Config.Buffers is 0
+const defaultBuffers = 4
+
+// Config describes the camera to open and the format to ask it for, zero
+// fields letting the driver choose
+type Config struct {
+	// Device is the path of the V4L2 device, DefaultDevice when empty
+	Device string
+
+	// Width and Height are the frame dimensions asked for, which the driver
+	// may adjust to the closest it supports, zero ones keeping the current
+	// dimensions of the device
+	Width  int
+	Height int
+
+	// FPS is the frame rate asked for
+	FPS int
+
+	// Buffers is the count of memory mapped buffers, 4 when 0
+	Buffers int
+
+	// PixelFormat forces a fourcc such as "YUYV" instead of the preferred
+	// format the camera supports
+	PixelFormat string
+
+	// Options tune every reveal
+	Options []goquirc.Option
+}
+
+// Format is the format the driver settled on
+type Format struct {
+	// PixelFormat is the fourcc of the frames, such as "GREY" or "YUYV"
+	PixelFormat string
+	Width       int
+	Height      int
+
+	// Stride is the count of bytes between rows of the first plane
+	Stride int
+}
+
+// Frame is the outcome of revealing a captured frame
+type Frame struct {
+	// Sequence is the frame counter of the driver
+	Sequence int
+
+	// Time is when the frame was dequeued
+	Time time.Time
+
+	Result goquirc.Result
+	Err    error
+}
+
+// preferred lists the formats Open picks from, cheapest luminance first
+var preferred = []string{"GREY", "NV12", "NV21", "YU12", "YV12", "YUYV", "UYVY", "MJPG"}
+
+// supported tells whether frames of a fourcc can be loaded
+func supported(fourcc string) bool {
+	for _, f := range preferred {
+		if f == fourcc {
+			return true
+		}
+	}
+	return false
+}
+
+// load writes the luminance of a frame into the image buffer of d, resizing
+// it to the dimensions of the frame first. MJPG frames lacking Huffman tables
+// are decoded with the standard ones
+func load(d *goquirc.Decoder, format Format, frame []byte) error {
+	w, h, stride := format.Width, format.Height, format.Stride
+	if format.PixelFormat == "MJPG" {
+		img, err := jpeg.Decode(bytes.NewReader(withHuffmanTables(frame)))
+		if err != nil {
+			return err
+		}
+		var gray []byte
+		gray, w, h = goquirc.Luminance(img)
+		if err := d.Resize(w, h); err != nil {
+			return err
+		}
+		copy(d.Buffer(), gray)
+		return nil
+	}
+
+	// Planar and semi-planar formats start with a Y plane of one byte per
+	// pixel, packed 4:2:2 ones interleave it with chroma
+	step, offset := 1, 0
+	switch format.PixelFormat {
+	case "YUYV":
+		step = 2
+	case "UYVY":
+		step, offset = 2, 1
+	}
+	if stride < w*step || len(frame) < (h-1)*stride+w*step {
+		return errors.New("Frame is too short")
+	}
+	if err := d.Resize(w, h); err != nil {
+		return err
+	}
+
+	buffer := d.Buffer()
+	for y := 0; y < h; y++ {
+		row, out := frame[y*stride+offset:], buffer[y*w:(y+1)*w]
+		if step == 1 {
+			copy(out, row)
+			continue
+		}
+		for x := range out {
+			out[x] = row[x*step]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,376 @@
+package capture
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/quaresc/goquirc"
+)
+
+// Capture streams frames from an open V4L2 device
+type Capture struct {
+	fd      int
+	format  Format
+	buffers [][]byte
+	opts    []goquirc.Option
+}
+
+// V4L2 constants of linux/videodev2.h
+const (
+	capVideoCapture = 0x00000001
+	capStreaming    = 0x04000000
+	capDeviceCaps   = 0x80000000
+
+	bufTypeVideoCapture = 1
+	memoryMmap          = 1
+	fieldNone           = 1
+	bufFlagError        = 0x40
+)
+
+// capability is struct v4l2_capability
+type capability struct {
+	driver       [16]byte
+	card         [32]byte
+	busInfo      [32]byte
+	version      uint32
+	capabilities uint32
+	deviceCaps   uint32
+	reserved     [3]uint32
+}
+
+// fmtdesc is struct v4l2_fmtdesc
+type fmtdesc struct {
+	index       uint32
+	typ         uint32
+	flags       uint32
+	description [32]byte
+	pixelformat uint32
+	mbusCode    uint32
+	reserved    [3]uint32
+}
+
+// pixFormat is struct v4l2_pix_format
+type pixFormat struct {
+	width        uint32
+	height       uint32
+	pixelformat  uint32
+	field        uint32
+	bytesperline uint32
+	sizeimage    uint32
+	colorspace   uint32
+	priv         uint32
+	flags        uint32
+	ycbcrEnc     uint32
+	quantization uint32
+	xferFunc     uint32
+}
+
+// format is struct v4l2_format, whose 200 bytes union holds pointers in some
+// of its members and is aligned like them
+type format struct {
+	typ uint32
+	fmt [200 / unsafe.Sizeof(uintptr(0))]uintptr
+}
+
+// pix returns the pixel format member of the union
+func (f *format) pix() *pixFormat {
+	return (*pixFormat)(unsafe.Pointer(&f.fmt))
+}
+
+// requestBuffers is struct v4l2_requestbuffers
+type requestBuffers struct {
+	count        uint32
+	typ          uint32
+	memory       uint32
+	capabilities uint32
+	reserved     uint32
+}
+
+// buffer is struct v4l2_buffer, with the m union read as an offset
+type buffer struct {
+	index     uint32
+	typ       uint32
+	bytesused uint32
+	flags     uint32
+	field     uint32
+	timestamp unix.Timeval
+	timecode  [4]uint32
+	sequence  uint32
+	memory    uint32
+	offset    uintptr
+	length    uint32
+	reserved2 uint32
+	requestFd int32
+}
+
+// streamParm is struct v4l2_streamparm holding a struct v4l2_captureparm
+type streamParm struct {
+	typ          uint32
+	capability   uint32
+	capturemode  uint32
+	numerator    uint32
+	denominator  uint32
+	extendedmode uint32
+	readbuffers  uint32
+	reserved     [200 - 24]byte
+}
+
+// ioctl requests, encoded like the _IOR, _IOW and _IOWR macros
+var (
+	vidiocQuerycap  = ioc(2, 0, unsafe.Sizeof(capability{}))
+	vidiocEnumFmt   = ioc(3, 2, unsafe.Sizeof(fmtdesc{}))
+	vidiocGFmt      = ioc(3, 4, unsafe.Sizeof(format{}))
+	vidiocSFmt      = ioc(3, 5, unsafe.Sizeof(format{}))
+	vidiocReqbufs   = ioc(3, 8, unsafe.Sizeof(requestBuffers{}))
+	vidiocQuerybuf  = ioc(3, 9, unsafe.Sizeof(buffer{}))
+	vidiocQbuf      = ioc(3, 15, unsafe.Sizeof(buffer{}))
+	vidiocDqbuf     = ioc(3, 17, unsafe.Sizeof(buffer{}))
+	vidiocStreamon  = ioc(1, 18, unsafe.Sizeof(int32(0)))
+	vidiocStreamoff = ioc(1, 19, unsafe.Sizeof(int32(0)))
+	vidiocSParm     = ioc(3, 22, unsafe.Sizeof(streamParm{}))
+)
+
+// ioc encodes an ioctl request of the V4L2 type, dir being 1 for write, 2
+// for read and 3 for both
+func ioc(dir uintptr, nr uintptr, size uintptr) uintptr {
+	return dir<<30 | size<<16 | 'V'<<8 | nr
+}
+
+// ioctl issues a request on fd, retrying when interrupted by a signal
+func ioctl(fd int, req uintptr, arg unsafe.Pointer) error {
+	for {
+		_, _, errno := unix.Syscall(unix.SYS_IOCTL, uintptr(fd), req, uintptr(arg))
+		if errno != unix.EINTR {
+			if errno != 0 {
+				return errno
+			}
+			return nil
+		}
+	}
+}
+
+// fourcc converts a pixel format code to its four characters
+func fourcc(code uint32) string {
+	return string([]byte{byte(code), byte(code >> 8), byte(code >> 16), byte(code >> 24)})
+}
+
+// code converts four characters to a pixel format code
+func code(fourcc string) uint32 {
+	return uint32(fourcc[0]) | uint32(fourcc[1])<<8 | uint32(fourcc[2])<<16 | uint32(fourcc[3])<<24
+}
+
+// Open opens a V4L2 device, negotiates its format and maps its buffers,
+// frames being captured once Run is called
+func Open(cfg Config) (*Capture, error) {
+	device := cfg.Device
+	if device == "" {
+		device = DefaultDevice
+	}
+	fd, err := unix.Open(device, unix.O_RDWR|unix.O_NONBLOCK|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot open %s: %w", device, err)
+	}
+
+	c := &Capture{fd: fd, opts: cfg.Options}
+	if err := c.setup(cfg); err != nil {
+		c.Close()
+		return nil, fmt.Errorf("%s: %w", device, err)
+	}
+	return c, nil
+}
+
+// setup checks the device captures video by streaming, then sets its format,
+// its frame rate and maps its buffers
+func (c *Capture) setup(cfg Config) error {
+	var caps capability
+	if err := ioctl(c.fd, vidiocQuerycap, unsafe.Pointer(&caps)); err != nil {
+		return fmt.Errorf("Not a V4L2 device: %w", err)
+	}
+	flags := caps.capabilities
+	if flags&capDeviceCaps != 0 {
+		flags = caps.deviceCaps
+	}
+	if flags&capVideoCapture == 0 || flags&capStreaming == 0 {
+		return errors.New("Device does not stream video capture")
+	}
+
+	pixelFormat := cfg.PixelFormat
+	if pixelFormat == "" {
+		var err error
+		if pixelFormat, err = c.choose(); err != nil {
+			return err
+		}
+	} else if !supported(pixelFormat) {
+		return fmt.Errorf("Unsupported pixel format %q", pixelFormat)
+	}
+
+	// Unset dimensions keep the current ones, as drivers clamp zero ones to
+	// their smallest frame size
+	f := format{typ: bufTypeVideoCapture}
+	if err := ioctl(c.fd, vidiocGFmt, unsafe.Pointer(&f)); err != nil {
+		return fmt.Errorf("Cannot get format: %w", err)
+	}
+	pix := f.pix()
+	if cfg.Width > 0 {
+		pix.width = uint32(cfg.Width)
+	}
+	if cfg.Height > 0 {
+		pix.height = uint32(cfg.Height)
+	}
+	pix.pixelformat = code(pixelFormat)
+	pix.field = fieldNone
+	pix.bytesperline, pix.sizeimage = 0, 0
+	if err := ioctl(c.fd, vidiocSFmt, unsafe.Pointer(&f)); err != nil {
+		return fmt.Errorf("Cannot set format %s: %w", pixelFormat, err)
+	}
+	if fourcc(pix.pixelformat) != pixelFormat {
+		return fmt.Errorf("Driver replaced format %s by %s", pixelFormat, fourcc(pix.pixelformat))
+	}
+	c.format = Format{
+		PixelFormat: pixelFormat,
+		Width:       int(pix.width),
+		Height:      int(pix.height),
+		Stride:      int(pix.bytesperline),
+	}
+	if c.format.Stride == 0 {
+		c.format.Stride = c.format.Width
+		if pixelFormat == "YUYV" || pixelFormat == "UYVY" {
+			c.format.Stride *= 2
+		}
+	}
+
+	if cfg.FPS > 0 {
+		// Drivers without frame rate control reject the request, which
+		// leaves their own rate
+		parm := streamParm{typ: bufTypeVideoCapture, numerator: 1, denominator: uint32(cfg.FPS)}
+		_ = ioctl(c.fd, vidiocSParm, unsafe.Pointer(&parm))
+	}
+	return c.mmap(cfg.Buffers)
+}
+
+// choose returns the preferred format among the ones the device enumerates
+func (c *Capture) choose() (string, error) {
+	formats := map[string]bool{}
+	for i := uint32(0); ; i++ {
+		desc := fmtdesc{index: i, typ: bufTypeVideoCapture}
+		if err := ioctl(c.fd, vidiocEnumFmt, unsafe.Pointer(&desc)); err != nil {
+			break
+		}
+		formats[fourcc(desc.pixelformat)] = true
+	}
+	for _, f := range preferred {
+		if formats[f] {
+			return f, nil
+		}
+	}
+	return "", errors.New("Device has no grayscale, YUV or MJPG format")
+}
+
+// mmap requests count buffers and maps them
+func (c *Capture) mmap(count int) error {
+	if count <= 0 {
+		count = defaultBuffers
+	}
+	req := requestBuffers{count: uint32(count), typ: bufTypeVideoCapture, memory: memoryMmap}
+	if err := ioctl(c.fd, vidiocReqbufs, unsafe.Pointer(&req)); err != nil {
+		return fmt.Errorf("Cannot request buffers: %w", err)
+	}
+	if req.count == 0 {
+		return errors.New("Device granted no buffer")
+	}
+
+	for i := uint32(0); i < req.count; i++ {
+		buf := buffer{index: i, typ: bufTypeVideoCapture, memory: memoryMmap}
+		if err := ioctl(c.fd, vidiocQuerybuf, unsafe.Pointer(&buf)); err != nil {
+			return fmt.Errorf("Cannot query buffer: %w", err)
+		}
+		data, err := unix.Mmap(c.fd, int64(buf.offset), int(buf.length), unix.PROT_READ|unix.PROT_WRITE, unix.MAP_SHARED)
+		if err != nil {
+			return fmt.Errorf("Cannot map buffer: %w", err)
+		}
+		c.buffers = append(c.buffers, data)
+	}
+	return nil
+}
+
+// Format returns the format the driver settled on
+func (c *Capture) Format() Format {
+	return c.format
+}
+
+// Run streams frames into d and calls fn with the outcome of each of them,
+// until fn returns false, which gives a nil error, or ctx is done, which
+// gives its error. Frames the driver flags as corrupted are skipped
+func (c *Capture) Run(ctx context.Context, d *goquirc.Decoder, fn func(Frame) bool) error {
+	if c.fd < 0 {
+		return errors.New("Capture is closed")
+	}
+	for i := range c.buffers {
+		buf := buffer{index: uint32(i), typ: bufTypeVideoCapture, memory: memoryMmap}
+		if err := ioctl(c.fd, vidiocQbuf, unsafe.Pointer(&buf)); err != nil {
+			return fmt.Errorf("Cannot queue buffer: %w", err)
+		}
+	}
+	typ := int32(bufTypeVideoCapture)
+	if err := ioctl(c.fd, vidiocStreamon, unsafe.Pointer(&typ)); err != nil {
+		return fmt.Errorf("Cannot start streaming: %w", err)
+	}
+	defer ioctl(c.fd, vidiocStreamoff, unsafe.Pointer(&typ))
+
+	fds := []unix.PollFd{{Fd: int32(c.fd), Events: unix.POLLIN}}
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		// Polling in short slices lets a cancelled context stop a camera
+		// that stopped delivering frames
+		n, err := unix.Poll(fds, 100)
+		if err == unix.EINTR || n == 0 {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		buf := buffer{typ: bufTypeVideoCapture, memory: memoryMmap}
+		if err := ioctl(c.fd, vidiocDqbuf, unsafe.Pointer(&buf)); err != nil {
+			if err == unix.EAGAIN {
+				continue
+			}
+			return fmt.Errorf("Cannot dequeue buffer: %w", err)
+		}
+		frame := Frame{Sequence: int(buf.sequence), Time: time.Now()}
+		corrupted := buf.flags&bufFlagError != 0
+		if !corrupted {
+			data := c.buffers[buf.index][:buf.bytesused]
+			if frame.Err = load(d, c.format, data); frame.Err == nil {
+				frame.Result, frame.Err = d.Scan(c.opts...)
+			}
+		}
+		if err := ioctl(c.fd, vidiocQbuf, unsafe.Pointer(&buf)); err != nil {
+			return fmt.Errorf("Cannot queue buffer: %w", err)
+		}
+		if !corrupted && !fn(frame) {
+			return nil
+		}
+	}
+}
+
+// Close unmaps the buffers and closes the device
+func (c *Capture) Close() error {
+	if c.fd < 0 {
+		return nil
+	}
+	for _, data := range c.buffers {
+		unix.Munmap(data)
+	}
+	c.buffers = nil
+	err := unix.Close(c.fd)
+	c.fd = -1
+	return err
+}
@@ -0,0 +1,36 @@
+//go:build !linux
+
+package capture
+
+import (
+	"context"
+	"errors"
+
+	"github.com/quaresc/goquirc"
+)
+
+// errUnsupported is returned on systems without V4L2
+var errUnsupported = errors.New("V4L2 capture is only supported on Linux")
+
+// Capture streams frames from an open V4L2 device
+type Capture struct{}
+
+// Open opens a V4L2 device, which only exists on Linux
+func Open(cfg Config) (*Capture, error) {
+	return nil, errUnsupported
+}
+
+// Format returns the format the driver settled on
+func (c *Capture) Format() Format {
+	return Format{}
+}
+
+// Run streams frames into d and calls fn with the outcome of each of them
+func (c *Capture) Run(ctx context.Context, d *goquirc.Decoder, fn func(Frame) bool) error {
+	return errUnsupported
+}
+
+// Close unmaps the buffers and closes the device
+func (c *Capture) Close() error {
+	return nil
+}
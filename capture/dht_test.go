@@ -0,0 +1,44 @@
+package capture
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"reflect"
+	"testing"
+)
+
+func TestWithHuffmanTables(t *testing.T) {
+	src := image.NewGray(image.Rect(0, 0, 40, 24))
+	for i := range src.Pix {
+		src.Pix[i] = byte(i * 7)
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, src, nil); err != nil {
+		t.Fatal(err)
+	}
+	full := buf.Bytes()
+	want, err := jpeg.Decode(bytes.NewReader(full))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// image/jpeg writes the tables of annexK, which frames of USB cameras
+	// leave out
+	i := bytes.Index(full, []byte{0xff, 0xc4})
+	stripped := append(append([]byte{}, full[:i]...), full[i+2+(int(full[i+2])<<8|int(full[i+3])):]...)
+	if _, err := jpeg.Decode(bytes.NewReader(stripped)); err == nil {
+		t.Fatal("Frame without Huffman tables decoded")
+	}
+
+	got, err := jpeg.Decode(bytes.NewReader(withHuffmanTables(stripped)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Error("Frame with default tables differs from the original")
+	}
+	if framed := withHuffmanTables(full); &framed[0] != &full[0] {
+		t.Error("Frame with its own tables was copied")
+	}
+}
@@ -0,0 +1,73 @@
+package goquirc
+
+import (
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// charsets maps ECI assignment numbers to their character encoding. ECI 0,
+// which also stands for CP437, is left out since quirc reports payloads
+// without ECI header as ECI 0
+var charsets = map[int]encoding.Encoding{
+	1:  charmap.ISO8859_1,
+	2:  charmap.CodePage437,
+	3:  charmap.ISO8859_1,
+	4:  charmap.ISO8859_2,
+	5:  charmap.ISO8859_3,
+	6:  charmap.ISO8859_4,
+	7:  charmap.ISO8859_5,
+	8:  charmap.ISO8859_6,
+	9:  charmap.ISO8859_7,
+	10: charmap.ISO8859_8,
+	11: charmap.ISO8859_9,
+	12: charmap.ISO8859_10,
+	13: charmap.Windows874,
+	15: charmap.ISO8859_13,
+	16: charmap.ISO8859_14,
+	17: charmap.ISO8859_15,
+	18: charmap.ISO8859_16,
+	20: japanese.ShiftJIS,
+	21: charmap.Windows1250,
+	22: charmap.Windows1251,
+	23: charmap.Windows1252,
+	24: charmap.Windows1256,
+	25: unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM),
+	26: unicode.UTF8,
+	27: encoding.Nop,
+	28: traditionalchinese.Big5,
+	29: simplifiedchinese.GB18030,
+	30: korean.EUCKR,
+}
+
+// transcode converts a payload to UTF-8 according to its ECI assignment
+// number. Payloads without ECI header are read as Shift-JIS in Kanji mode,
+// kept as is when they are valid UTF-8 and read as ISO-8859-1, the default
+// of the standard, otherwise. An explicit ECI 0 cannot be told apart from a
+// missing header and is treated as absent
+func transcode(payload []byte, eci int, dataType DataType) string {
+	var charset encoding.Encoding
+
+	if eci == 0 && dataType == DataTypeKanji {
+		charset = japanese.ShiftJIS
+	} else if eci == 0 {
+		if utf8.Valid(payload) {
+			return string(payload)
+		}
+		charset = charmap.ISO8859_1
+	} else if charset = charsets[eci]; charset == nil {
+		return string(payload)
+	}
+
+	text, err := charset.NewDecoder().Bytes(payload)
+	if err != nil {
+		return string(payload)
+	}
+	return string(text)
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+
+	"github.com/quaresc/goquirc"
+	"github.com/quaresc/goquirc/internal/jsonresult"
+)
+
+// Colors of the annotations
+var (
+	decodedColor = color.RGBA{0x00, 0xc8, 0x00, 0xff}
+	failureColor = color.RGBA{0xe0, 0x00, 0x00, 0xff}
+	labelColor   = color.RGBA{0xff, 0xff, 0xff, 0xff}
+)
+
+// maxLabel is the number of characters of a payload kept in its label
+const maxLabel = 32
+
+// annotation tells where annotated copies of the images are written: to
+// path itself for a single image, or into the directory path for several
+type annotation struct {
+	path string
+	dir  bool
+}
+
+// newAnnotation checks the destination of annotated images
+func newAnnotation(path string, images int, raw bool) (annotation, error) {
+	if path == "" {
+		return annotation{}, nil
+	}
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return annotation{path: path, dir: true}, nil
+	}
+	if images > 1 || raw {
+		return annotation{}, errors.New("--annotate must name an existing directory when decoding several images")
+	}
+	return annotation{path: path}, nil
+}
+
+// enabled reports whether annotated images are written
+func (a annotation) enabled() bool {
+	return a.path != ""
+}
+
+// destination returns the path of the annotated copy of an image
+func (a annotation) destination(name string) string {
+	if !a.dir {
+		return a.path
+	}
+	// Directories are flattened into the name so that images of the same
+	// name found in different directories do not overwrite each other
+	name = filepath.ToSlash(filepath.Clean(name))
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	name = strings.TrimLeft(name, "./")
+	name = strings.NewReplacer("/", "_", ":", "-").Replace(name)
+	return filepath.Join(a.path, name+".png")
+}
+
+// write draws the qrcodes and failures of a result onto a copy of img and
+// saves it as PNG
+func (a annotation) write(name string, img image.Image, result goquirc.Result) error {
+	f, err := os.Create(a.destination(name))
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, annotate(img, result)); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// annotate returns a copy of img outlining the decoded qrcodes along with
+// their payloads, and marking the failures with a cross
+func annotate(img image.Image, result goquirc.Result) *image.RGBA {
+	bounds := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, bounds.Min, draw.Src)
+
+	// Lines thicken with the image so that they remain visible once scaled
+	// down on screen
+	short := bounds.Dx()
+	if bounds.Dy() < short {
+		short = bounds.Dy()
+	}
+	width := 1 + short/400
+	for _, failure := range result.Failures {
+		c := failure.Corners
+		drawQuad(dst, c, width, failureColor)
+		drawLine(dst, c[0], c[2], width, failureColor)
+		drawLine(dst, c[1], c[3], width, failureColor)
+		drawLabel(dst, c, failure.Error(), failureColor)
+	}
+	for _, code := range result.Code {
+		drawQuad(dst, code.Corners, width, decodedColor)
+		drawLabel(dst, code.Corners, label(code), decodedColor)
+	}
+	return dst
+}
+
+// label shortens the payload of a qrcode to a printable line
+func label(code goquirc.QRcode) string {
+	text := code.Text()
+	if !jsonresult.Printable(text) {
+		return fmt.Sprintf("%d bytes", len(code.Payload))
+	}
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) > maxLabel {
+		text = string([]rune(text)[:maxLabel-3]) + "..."
+	}
+	return text
+}
+
+// drawQuad outlines a quadrilateral
+func drawQuad(dst *image.RGBA, c [4]goquirc.Position, width int, col color.Color) {
+	for i := range c {
+		drawLine(dst, c[i], c[(i+1)%4], width, col)
+	}
+}
+
+// drawLine draws a segment with square pens of width pixels, following
+// Bresenham's algorithm
+func drawLine(dst *image.RGBA, a goquirc.Position, b goquirc.Position, width int, col color.Color) {
+	dx, dy := abs(b.X-a.X), -abs(b.Y-a.Y)
+	sx, sy := 1, 1
+	if a.X > b.X {
+		sx = -1
+	}
+	if a.Y > b.Y {
+		sy = -1
+	}
+
+	pen := image.Rect(-width/2, -width/2, width-width/2, width-width/2)
+	x, y, e := a.X, a.Y, dx+dy
+	for {
+		draw.Draw(dst, pen.Add(image.Pt(x, y)), image.NewUniform(col), image.Point{}, draw.Src)
+		if x == b.X && y == b.Y {
+			return
+		}
+		if 2*e >= dy {
+			e += dy
+			x += sx
+		}
+		if 2*e <= dx {
+			e += dx
+			y += sy
+		}
+	}
+}
+
+// drawLabel writes a text over a colored box above the topmost corner of a
+// quadrilateral, or below it when there is no room above
+func drawLabel(dst *image.RGBA, c [4]goquirc.Position, text string, background color.Color) {
+	face := basicfont.Face7x13
+	top := c[0]
+	for _, p := range c[1:] {
+		if p.Y < top.Y || (p.Y == top.Y && p.X < top.X) {
+			top = p
+		}
+	}
+
+	const padding = 2
+	box := image.Rect(0, 0, font.MeasureString(face, text).Ceil()+2*padding, face.Height+2*padding)
+	origin := image.Pt(top.X, top.Y-box.Dy()-padding)
+	if origin.Y < 0 {
+		origin.Y = top.Y + padding
+	}
+	if origin.X+box.Dx() > dst.Rect.Dx() {
+		origin.X = dst.Rect.Dx() - box.Dx()
+	}
+	if origin.X < 0 {
+		origin.X = 0
+	}
+	box = box.Add(origin)
+	draw.Draw(dst, box, image.NewUniform(background), image.Point{}, draw.Src)
+
+	drawer := font.Drawer{
+		Dst:  dst,
+		Src:  image.NewUniform(labelColor),
+		Face: face,
+		Dot:  fixed.P(box.Min.X+padding, box.Min.Y+padding+face.Ascent),
+	}
+	drawer.DrawString(text)
+}
+
+// abs returns the absolute value of an integer
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
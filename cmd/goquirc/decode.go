@@ -0,0 +1,269 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"runtime"
+	"sync"
+
+	"github.com/quaresc/goquirc"
+)
+
+// decode runs the decode command and returns the exit status
+func decode(args []string) int {
+	flags := flag.NewFlagSet("decode", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: goquirc decode [flags] image|directory|pattern|-...")
+		flags.PrintDefaults()
+	}
+	var tuning decodeFlags
+	tuning.register(flags)
+	recursive := flags.Bool("recursive", false, "walk directories for images")
+	workers := flags.Int("workers", runtime.GOMAXPROCS(0), "number of images decoded at once")
+	var raw rawFormat
+	flags.BoolVar(&raw.raw, "raw", false, "read inputs as successive raw 8 bits grayscale frames of --width by --height pixels")
+	flags.IntVar(&raw.width, "width", 0, "width of raw frames")
+	flags.IntVar(&raw.height, "height", 0, "height of raw frames")
+	annotate := flags.String("annotate", "", "write a copy of the image outlining qrcodes and failures to this PNG `file`, or into this directory for several images")
+	format := flags.String("format", formatText, "output format: text, json or jsonl")
+	if err := flags.Parse(args); err != nil {
+		return exitInput
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return exitInput
+	}
+	if err := raw.check(); err != nil {
+		fmt.Fprintf(os.Stderr, "goquirc: %v\n", err)
+		return exitInput
+	}
+	if *format != formatText && *format != formatJSON && *format != formatJSONL {
+		fmt.Fprintf(os.Stderr, "goquirc: unknown format %q\n", *format)
+		return exitInput
+	}
+
+	opts, err := tuning.options()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "goquirc: %v\n", err)
+		return exitInput
+	}
+
+	paths, err := expandPaths(flags.Args(), *recursive)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "goquirc: %v\n", err)
+		return exitInput
+	}
+
+	var summary summary
+	var files []jsonFile
+	lines := json.NewEncoder(os.Stdout)
+	annotated, err := newAnnotation(*annotate, len(paths), raw.raw)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "goquirc: %v\n", err)
+		return exitInput
+	}
+
+	for file := range decodeFiles(paths, raw, *workers, opts, annotated) {
+		summary.add(file)
+		switch {
+		case *format == formatJSON:
+			files = append(files, newJSONFile(file))
+		case *format == formatJSONL:
+			if err := lines.Encode(newJSONFile(file)); err != nil {
+				fmt.Fprintf(os.Stderr, "goquirc: %v\n", err)
+				return exitInput
+			}
+		case file.err != nil:
+			fmt.Fprintf(os.Stderr, "goquirc: %s: %v\n", file.path, file.err)
+		default:
+			printResult(os.Stdout, file.path, file.result)
+		}
+	}
+	if *format == formatJSON {
+		if files == nil {
+			files = []jsonFile{}
+		}
+		out, err := json.MarshalIndent(files, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "goquirc: %v\n", err)
+			return exitInput
+		}
+		fmt.Fprintf(os.Stdout, "%s\n", out)
+	}
+	if summary.images > 1 {
+		fmt.Fprintln(os.Stderr, summary)
+	}
+	return summary.status()
+}
+
+// decodedFile is the outcome of the reveal process of an image file or frame
+type decodedFile struct {
+	path   string
+	result goquirc.Result
+	err    error
+}
+
+// decodeJob is an image waiting for a worker, its outcome is sent on done
+type decodeJob struct {
+	path string
+	load func() (image.Image, error)
+	done chan decodedFile
+}
+
+// decodeFiles decodes the images of inputs with a pool of workers and emits
+// their outcomes in input order, the channel is closed after the last one
+func decodeFiles(paths []string, raw rawFormat, workers int, opts []goquirc.Option, annotated annotation) <-chan decodedFile {
+	if workers < 1 {
+		workers = 1
+	}
+
+	// Jobs are queued in order as well so that outcomes can be emitted as
+	// soon as all the previous ones are known
+	order := make(chan chan decodedFile, workers)
+	jobs := make(chan decodeJob)
+	go func() {
+		submit := func(path string, load func() (image.Image, error)) {
+			done := make(chan decodedFile, 1)
+			order <- done
+			jobs <- decodeJob{path: path, load: load, done: done}
+		}
+		fail := func(path string, err error) {
+			done := make(chan decodedFile, 1)
+			done <- decodedFile{path: path, err: err}
+			order <- done
+		}
+		for _, path := range paths {
+			if raw.raw {
+				readFrames(path, raw, submit, fail)
+				continue
+			}
+			path := path
+			submit(inputName(path), func() (image.Image, error) {
+				return loadFile(path)
+			})
+		}
+		close(jobs)
+		close(order)
+	}()
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				result, err := decodeImage(job, opts, annotated)
+				job.done <- decodedFile{path: job.path, result: result, err: err}
+			}
+		}()
+	}
+
+	out := make(chan decodedFile)
+	go func() {
+		for done := range order {
+			out <- <-done
+		}
+		close(out)
+		wg.Wait()
+	}()
+	return out
+}
+
+// summary totals the outcomes of a batch
+type summary struct {
+	images     int
+	unreadable int
+	codes      int
+	failures   int
+}
+
+// add accounts for the outcome of an image
+func (s *summary) add(file decodedFile) {
+	s.images++
+	if file.err != nil {
+		s.unreadable++
+		return
+	}
+	s.codes += len(file.result.Code)
+	s.failures += len(file.result.Failures)
+}
+
+// status returns the exit status of a batch: decoded codes win over
+// unreadable images, which win over failures
+func (s summary) status() int {
+	switch {
+	case s.codes > 0:
+		return exitDecoded
+	case s.unreadable > 0:
+		return exitInput
+	case s.failures > 0:
+		return exitDecodeErrors
+	}
+	return exitNone
+}
+
+// String reports the totals of a batch
+func (s summary) String() string {
+	return fmt.Sprintf("%d images scanned, %d codes found, %d failures, %d unreadable images", s.images, s.codes, s.failures, s.unreadable)
+}
+
+// decodeImage loads the image of a job, reveals its qrcodes and writes its
+// annotated copy when requested
+func decodeImage(job decodeJob, opts []goquirc.Option, annotated annotation) (goquirc.Result, error) {
+	img, err := job.load()
+	if err != nil {
+		return goquirc.Result{}, err
+	}
+
+	var qr goquirc.Processing
+	result, err := qr.RevealImage(img, opts...)
+	if err != nil {
+		return result, err
+	}
+	if annotated.enabled() {
+		if err := annotated.write(job.path, img, result); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// loadFile decodes an image file, or the standard input for "-"
+func loadFile(path string) (image.Image, error) {
+	f, err := openInput(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, err
+	}
+	if img.Bounds().Empty() {
+		return nil, errors.New("Empty source image")
+	}
+	return img, nil
+}
+
+// printResult writes the qrcodes and decode failures of an image as text
+func printResult(w io.Writer, path string, result goquirc.Result) {
+	fmt.Fprintf(w, "%s: %d found, %d decoded\n", path, result.Found, result.Usable)
+	for i, code := range result.Code {
+		fmt.Fprintf(w, "  code %d: version %d, ECC level %s, corners %s\n", i+1, code.Version, code.ECCLevel, corners(code.Corners))
+		fmt.Fprintf(w, "    %s\n", code.Text())
+	}
+	for _, failure := range result.Failures {
+		fmt.Fprintf(w, "  failure %d: %v, corners %s\n", failure.Index+1, failure.Err, corners(failure.Corners))
+	}
+}
+
+// corners formats the corners of a qrcode
+func corners(c [4]goquirc.Position) string {
+	return fmt.Sprintf("(%d,%d) (%d,%d) (%d,%d) (%d,%d)", c[0].X, c[0].Y, c[1].X, c[1].Y, c[2].X, c[2].Y, c[3].X, c[3].Y)
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// imageExtensions lists the file extensions picked when walking directories
+var imageExtensions = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".webp": true,
+	".tif": true, ".tiff": true, ".bmp": true, ".pgm": true, ".ppm": true,
+	".pnm": true,
+}
+
+// expandPaths turns the arguments of the decode command into a list of
+// files. Patterns are expanded with filepath.Match rules, where a "**"
+// component matches any number of directories, so that quoted patterns work
+// whatever the shell. Directories are walked for images when recursive is set
+func expandPaths(args []string, recursive bool) ([]string, error) {
+	var paths []string
+	seen := map[string]bool{}
+	add := func(path string) {
+		if !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+
+	for _, arg := range args {
+		if !hasMeta(arg) {
+			info, err := os.Stat(arg)
+			switch {
+			case err != nil:
+				// Left for the decoder to report along with the other files
+				add(arg)
+			case info.IsDir() && !recursive:
+				return nil, fmt.Errorf("%s is a directory, use --recursive to walk it", arg)
+			case info.IsDir():
+				files, err := walkImages(arg)
+				if err != nil {
+					return nil, err
+				}
+				for _, file := range files {
+					add(file)
+				}
+			default:
+				add(arg)
+			}
+			continue
+		}
+
+		matches, err := glob(arg)
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("%s matches no file", arg)
+		}
+		for _, match := range matches {
+			add(match)
+		}
+	}
+	return paths, nil
+}
+
+// hasMeta reports whether a path holds pattern characters
+func hasMeta(path string) bool {
+	return strings.ContainsAny(path, `*?[`)
+}
+
+// walkImages lists the image files below a directory
+func walkImages(root string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() && imageExtensions[strings.ToLower(filepath.Ext(path))] {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// glob expands a pattern into the regular files it matches, in lexical order
+func glob(pattern string) ([]string, error) {
+	if !strings.Contains(pattern, "**") {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		return regularFiles(matches), nil
+	}
+
+	// Walk from the deepest directory free of pattern characters
+	parts := strings.Split(filepath.ToSlash(pattern), "/")
+	fixed := 0
+	for fixed < len(parts)-1 && !hasMeta(parts[fixed]) {
+		fixed++
+	}
+	root := strings.Join(parts[:fixed], "/")
+	if root == "" {
+		root = "."
+		if strings.HasPrefix(pattern, "/") {
+			root = "/"
+		}
+	}
+
+	var matches []string
+	err := filepath.WalkDir(filepath.FromSlash(root), func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(filepath.FromSlash(root), path)
+		if err != nil {
+			return err
+		}
+		ok, err := matchParts(parts[fixed:], strings.Split(filepath.ToSlash(rel), "/"))
+		if err != nil {
+			return err
+		}
+		if ok {
+			matches = append(matches, path)
+		}
+		return nil
+	})
+	sort.Strings(matches)
+	return matches, err
+}
+
+// matchParts matches path components against pattern components, "**"
+// standing for zero or more of them
+func matchParts(pattern []string, path []string) (bool, error) {
+	if len(pattern) == 0 {
+		return len(path) == 0, nil
+	}
+	if pattern[0] == "**" {
+		for skip := 0; skip <= len(path); skip++ {
+			ok, err := matchParts(pattern[1:], path[skip:])
+			if ok || err != nil {
+				return ok, err
+			}
+		}
+		return false, nil
+	}
+	if len(path) == 0 {
+		return false, nil
+	}
+	ok, err := filepath.Match(pattern[0], path[0])
+	if !ok || err != nil {
+		return false, err
+	}
+	return matchParts(pattern[1:], path[1:])
+}
+
+// regularFiles drops the directories of a list of matches
+func regularFiles(matches []string) []string {
+	files := matches[:0]
+	for _, match := range matches {
+		if info, err := os.Stat(match); err == nil && !info.IsDir() {
+			files = append(files, match)
+		}
+	}
+	return files
+}
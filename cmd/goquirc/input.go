@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"os"
+)
+
+// stdinPath stands for the standard input among the inputs
+const stdinPath = "-"
+
+// rawFormat describes the frames of raw 8 bits grayscale inputs
+type rawFormat struct {
+	raw    bool
+	width  int
+	height int
+}
+
+// check validates the dimensions of raw frames
+func (f rawFormat) check() error {
+	if !f.raw {
+		if f.width != 0 || f.height != 0 {
+			return errors.New("--width and --height require --raw")
+		}
+		return nil
+	}
+	if f.width <= 0 || f.height <= 0 {
+		return errors.New("--raw requires a positive --width and --height")
+	}
+	return nil
+}
+
+// openInput opens an input file, or the standard input for "-"
+func openInput(path string) (io.ReadCloser, error) {
+	if path == stdinPath {
+		return io.NopCloser(os.Stdin), nil
+	}
+	return os.Open(path)
+}
+
+// inputName names an input in the output
+func inputName(path string) string {
+	if path == stdinPath {
+		return "stdin"
+	}
+	return path
+}
+
+// readFrames splits a raw input into frames, handing each one to submit
+// under the name of the input followed by its number. Read errors, including
+// a truncated last frame, are handed to fail
+func readFrames(path string, raw rawFormat, submit func(string, func() (image.Image, error)), fail func(string, error)) {
+	f, err := openInput(path)
+	if err != nil {
+		fail(inputName(path), err)
+		return
+	}
+	defer f.Close()
+
+	for n := 1; ; n++ {
+		frame := make([]byte, raw.width*raw.height)
+		name := fmt.Sprintf("%s:%d", inputName(path), n)
+		if _, err := io.ReadFull(f, frame); err != nil {
+			switch err {
+			case io.EOF:
+				if n == 1 {
+					fail(inputName(path), errors.New("No frame"))
+				}
+			case io.ErrUnexpectedEOF:
+				fail(name, errors.New("Truncated frame"))
+			default:
+				fail(name, err)
+			}
+			return
+		}
+		submit(name, func() (image.Image, error) {
+			return &image.Gray{Pix: frame, Stride: raw.width, Rect: image.Rect(0, 0, raw.width, raw.height)}, nil
+		})
+	}
+}
@@ -0,0 +1,55 @@
+// Command goquirc reveals the qrcodes of images from the command line
+//
+// Usage:
+//
+//	goquirc decode [flags] image...
+//	goquirc scan [flags]
+//
+// The exit status is 0 when at least one qrcode was decoded, 1 when none was
+// found, 2 on usage or input errors such as unreadable images and 3 when
+// qrcodes were found but none could be decoded
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// Exit statuses
+const (
+	exitDecoded      = 0
+	exitNone         = 1
+	exitInput        = 2
+	exitDecodeErrors = 3
+)
+
+// usage describes the commands
+const usage = `Usage: goquirc <command> [flags] [arguments]
+
+Commands:
+  decode    reveal the qrcodes of images
+  scan      print the payloads seen by a camera, captured through ffmpeg
+
+Run "goquirc <command> -h" for the flags of a command.
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(exitInput)
+	}
+
+	code := exitDecoded
+	switch command, args := os.Args[1], os.Args[2:]; command {
+	case "decode":
+		code = decode(args)
+	case "scan":
+		code = scan(args)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(os.Stdout, usage)
+	default:
+		fmt.Fprintf(os.Stderr, "goquirc: unknown command %q\n\n%s", command, usage)
+		code = exitInput
+	}
+	os.Exit(code)
+}
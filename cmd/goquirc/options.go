@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"strconv"
+	"strings"
+
+	"github.com/quaresc/goquirc"
+)
+
+// decodeFlags holds the flags tuning the reveal process, shared by the
+// commands
+type decodeFlags struct {
+	gamma    float64
+	inverted bool
+	mirrored bool
+	crop     cropFlag
+	maxDim   int
+}
+
+// register declares the flags on a flag set
+func (f *decodeFlags) register(flags *flag.FlagSet) {
+	flags.Float64Var(&f.gamma, "gamma", 0, "apply a gamma correction before decoding, such as 2 to brighten underexposed images")
+	flags.BoolVar(&f.inverted, "inverted", false, "also look for light on dark qrcodes")
+	flags.BoolVar(&f.mirrored, "mirrored", false, "also look for mirrored qrcodes")
+	flags.Var(&f.crop, "crop", "restrict scanning to a `region` of images, given as x,y,w,h")
+	flags.IntVar(&f.maxDim, "max-dim", 0, "downscale images whose long edge exceeds this many pixels before decoding")
+}
+
+// options converts the flags to reveal options
+func (f *decodeFlags) options() ([]goquirc.Option, error) {
+	if f.maxDim < 0 {
+		return nil, errors.New("--max-dim must not be negative")
+	}
+
+	var opts []goquirc.Option
+	if f.gamma > 0 {
+		opts = append(opts, goquirc.WithGamma(f.gamma))
+	}
+	if f.inverted {
+		opts = append(opts, goquirc.WithInvertedSearch())
+	}
+	if f.mirrored {
+		opts = append(opts, goquirc.WithMirroredSearch())
+	}
+	if !f.crop.Empty() {
+		opts = append(opts, goquirc.WithROI(image.Rectangle(f.crop)))
+	}
+	if f.maxDim > 0 {
+		opts = append(opts, goquirc.WithMaxDimension(f.maxDim))
+	}
+	return opts, nil
+}
+
+// cropFlag is a region written as x,y,w,h
+type cropFlag image.Rectangle
+
+// Empty reports whether no region was given
+func (c cropFlag) Empty() bool {
+	return image.Rectangle(c).Empty()
+}
+
+// String formats the region as x,y,w,h
+func (c *cropFlag) String() string {
+	if c == nil || c.Empty() {
+		return ""
+	}
+	r := image.Rectangle(*c)
+	return fmt.Sprintf("%d,%d,%d,%d", r.Min.X, r.Min.Y, r.Dx(), r.Dy())
+}
+
+// Set parses a region written as x,y,w,h
+func (c *cropFlag) Set(value string) error {
+	fields := strings.Split(value, ",")
+	if len(fields) != 4 {
+		return errors.New("expected x,y,w,h")
+	}
+	var v [4]int
+	for i, field := range fields {
+		n, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			return errors.New("expected x,y,w,h")
+		}
+		v[i] = n
+	}
+	if v[0] < 0 || v[1] < 0 || v[2] <= 0 || v[3] <= 0 {
+		return errors.New("x and y must not be negative, w and h must be positive")
+	}
+	*c = cropFlag(image.Rect(v[0], v[1], v[0]+v[2], v[1]+v[3]))
+	return nil
+}
@@ -0,0 +1,28 @@
+package main
+
+import "github.com/quaresc/goquirc/internal/jsonresult"
+
+// Output formats of the decode command
+const (
+	formatText  = "text"
+	formatJSON  = "json"
+	formatJSONL = "jsonl"
+)
+
+// jsonFile is the JSON form of the outcome of an image file
+type jsonFile struct {
+	File  string `json:"file"`
+	Error string `json:"error,omitempty"`
+	jsonresult.Result
+}
+
+// newJSONFile converts the outcome of an image file to its JSON form
+func newJSONFile(file decodedFile) jsonFile {
+	out := jsonFile{File: file.path, Result: jsonresult.Empty()}
+	if file.err != nil {
+		out.Error = file.err.Error()
+		return out
+	}
+	out.Result = jsonresult.New(file.result)
+	return out
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/quaresc/goquirc"
+	"github.com/quaresc/goquirc/internal/jsonresult"
+)
+
+// cameraFormats maps operating systems to the ffmpeg input format of their
+// cameras, along with the name of the first camera
+var cameraFormats = map[string][2]string{
+	"linux":   {"v4l2", "/dev/video0"},
+	"darwin":  {"avfoundation", "0"},
+	"windows": {"dshow", ""},
+}
+
+// scan runs the scan command and returns the exit status
+func scan(args []string) int {
+	camera := cameraFormats[runtime.GOOS]
+
+	flags := flag.NewFlagSet("scan", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: goquirc scan [flags]")
+		flags.PrintDefaults()
+	}
+	device := flags.String("device", camera[1], "camera to capture, such as /dev/video0 or video=\"Integrated Camera\" on Windows")
+	input := flags.String("input-format", camera[0], "ffmpeg input format of the camera")
+	size := flags.String("size", "640x480", "dimensions frames are scaled to before decoding")
+	fps := flags.Int("fps", 10, "frames decoded per second")
+	ffmpeg := flags.String("ffmpeg", "ffmpeg", "path of the ffmpeg binary capturing frames")
+	var tuning decodeFlags
+	tuning.register(flags)
+	format := flags.String("format", formatText, "output format: text or jsonl")
+	if err := flags.Parse(args); err != nil {
+		return exitInput
+	}
+	if flags.NArg() != 0 {
+		flags.Usage()
+		return exitInput
+	}
+
+	w, h, err := parseSize(*size)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "goquirc: %v\n", err)
+		return exitInput
+	}
+	switch {
+	case *device == "" || *input == "":
+		fmt.Fprintln(os.Stderr, "goquirc: --device and --input-format are required on this system")
+		return exitInput
+	case *fps <= 0:
+		fmt.Fprintln(os.Stderr, "goquirc: --fps must be positive")
+		return exitInput
+	case *format != formatText && *format != formatJSONL:
+		fmt.Fprintf(os.Stderr, "goquirc: unknown format %q\n", *format)
+		return exitInput
+	}
+
+	opts, err := tuning.options()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "goquirc: %v\n", err)
+		return exitInput
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	cmd := exec.CommandContext(ctx, *ffmpeg,
+		"-hide_banner", "-loglevel", "error",
+		"-f", *input, "-i", *device,
+		"-vf", fmt.Sprintf("fps=%d,scale=%d:%d", *fps, w, h),
+		"-pix_fmt", "gray", "-f", "rawvideo", "-")
+	cmd.Stderr = os.Stderr
+	frames, err := cmd.StdoutPipe()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "goquirc: %v\n", err)
+		return exitInput
+	}
+	if err := cmd.Start(); err != nil {
+		fmt.Fprintf(os.Stderr, "goquirc: %v\n", err)
+		return exitInput
+	}
+
+	printer := newPayloadPrinter(os.Stdout, *format)
+	err = scanFrames(frames, w, h, opts, printer.print)
+	if werr := cmd.Wait(); err == nil && ctx.Err() == nil {
+		err = werr
+	}
+	if err != nil && !errors.Is(err, io.EOF) && ctx.Err() == nil {
+		fmt.Fprintf(os.Stderr, "goquirc: %v\n", err)
+		return exitInput
+	}
+	if len(printer.seen) == 0 {
+		return exitNone
+	}
+	return exitDecoded
+}
+
+// parseSize parses dimensions written as WxH
+func parseSize(size string) (int, int, error) {
+	w, h, ok := strings.Cut(size, "x")
+	width, werr := strconv.Atoi(w)
+	height, herr := strconv.Atoi(h)
+	if !ok || werr != nil || herr != nil || width <= 0 || height <= 0 {
+		return 0, 0, fmt.Errorf("invalid size %q, expected WxH", size)
+	}
+	return width, height, nil
+}
+
+// scanFrames reads raw grayscale frames straight into the buffer of a single
+// decoder and hands every decoded qrcode to found, until the stream ends
+func scanFrames(frames io.Reader, w int, h int, opts []goquirc.Option, found func(goquirc.QRcode) error) error {
+	d, err := goquirc.NewDecoder()
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	if err := d.Resize(w, h); err != nil {
+		return err
+	}
+
+	for {
+		if _, err := io.ReadFull(frames, d.Buffer()); err != nil {
+			return err
+		}
+		result, err := d.Scan(opts...)
+		if err != nil {
+			return err
+		}
+		for _, code := range result.Code {
+			if err := found(code); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// payloadPrinter prints each payload the first time it is seen
+type payloadPrinter struct {
+	w      io.Writer
+	format string
+	lines  *json.Encoder
+	seen   map[string]bool
+}
+
+// newPayloadPrinter returns a printer writing payloads in a format
+func newPayloadPrinter(w io.Writer, format string) *payloadPrinter {
+	return &payloadPrinter{w: w, format: format, lines: json.NewEncoder(w), seen: map[string]bool{}}
+}
+
+// print prints a qrcode unless its payload was already seen
+func (p *payloadPrinter) print(code goquirc.QRcode) error {
+	if p.seen[string(code.Payload)] {
+		return nil
+	}
+	p.seen[string(code.Payload)] = true
+
+	if p.format == formatJSONL {
+		return p.lines.Encode(jsonresult.NewCode(code))
+	}
+	_, err := fmt.Fprintln(p.w, code.Text())
+	return err
+}
@@ -0,0 +1,40 @@
+//go:build go1.23
+
+package goquirc
+
+import (
+	"context"
+	"errors"
+	"image"
+	"iter"
+)
+
+// Codes returns an iterator over the qrcodes of a source image, yielding each
+// one as soon as it is decoded. Processings which could not be decoded are
+// yielded with their DecodeFailure as error, and a process failure is yielded
+// last with an empty QRcode
+func (d *Decoder) Codes(img image.Image, opts ...Option) iter.Seq2[QRcode, error] {
+	return func(yield func(QRcode, error) bool) {
+		if !d.qr.allocated() {
+			yield(QRcode{}, errors.New("Decoder is closed"))
+			return
+		}
+
+		stopped := false
+		cfg := newConfig(opts)
+		// A later retry pass must not yield once the loop was broken
+		cfg.visit = func(code QRcode) bool {
+			stopped = stopped || !yield(code, nil)
+			return !stopped
+		}
+		cfg.visitFailure = func(failure DecodeFailure) bool {
+			stopped = stopped || !yield(QRcode{}, failure)
+			return !stopped
+		}
+
+		gray, w, h := Luminance(img)
+		if _, err := d.qr.reveal(context.Background(), &gray, w, h, cfg); err != nil && !stopped {
+			yield(QRcode{}, err)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package goquirc
+
+// #include <stdint.h>
+// #include <stddef.h>
+//
+// // Conversions are plain loops vectorized by the compiler, gcc also builds
+// // an AVX2 variant on x86-64 Linux which is picked at load time when the
+// // CPU supports it. NEON is part of the arm64 baseline and used as is
+// #if defined(__x86_64__) && defined(__linux__) && defined(__GNUC__) && !defined(__clang__)
+// #define GOQUIRC_CLONES __attribute__((target_clones("avx2", "default")))
+// #else
+// #define GOQUIRC_CLONES
+// #endif
+//
+// GOQUIRC_CLONES
+// static void goquirc_rgba_to_gray(uint8_t *restrict dst, const uint8_t *restrict pix,
+// 				 int w, int h, ptrdiff_t stride) {
+// 	for (int y = 0; y < h; y++) {
+// 		const uint8_t *row = pix + y * stride;
+// 		uint8_t *out = dst + (ptrdiff_t)y * w;
+// 		for (int x = 0; x < w; x++) {
+// 			uint32_t r = row[x * 4], g = row[x * 4 + 1], b = row[x * 4 + 2];
+// 			out[x] = (uint8_t)((19595 * r + 38470 * g + 7471 * b + (1 << 15)) >> 16);
+// 		}
+// 	}
+// }
+//
+// // Alpha composites over white, premultiplied samples adding the light
+// // let through and straight ones being blended, rounded as v / 255
+// GOQUIRC_CLONES
+// static void goquirc_rgba_over_white(uint8_t *restrict dst, const uint8_t *restrict pix,
+// 				    int w, int h, ptrdiff_t stride, int premultiplied) {
+// 	for (int y = 0; y < h; y++) {
+// 		const uint8_t *row = pix + y * stride;
+// 		uint8_t *out = dst + (ptrdiff_t)y * w;
+// 		for (int x = 0; x < w; x++) {
+// 			uint32_t r = row[x * 4], g = row[x * 4 + 1], b = row[x * 4 + 2], a = row[x * 4 + 3];
+// 			uint32_t luma = (19595 * r + 38470 * g + 7471 * b + (1 << 15)) >> 16;
+// 			if (premultiplied) {
+// 				out[x] = (uint8_t)(luma + 255 - a);
+// 			} else {
+// 				uint32_t v = luma * a + 255 * (255 - a) + 128;
+// 				out[x] = (uint8_t)((v + (v >> 8)) >> 8);
+// 			}
+// 		}
+// 	}
+// }
+//
+// GOQUIRC_CLONES
+// static void goquirc_packed_luma(uint8_t *restrict dst, const uint8_t *restrict pix,
+// 				int w, int h, ptrdiff_t stride) {
+// 	for (int y = 0; y < h; y++) {
+// 		const uint8_t *row = pix + y * stride;
+// 		uint8_t *out = dst + (ptrdiff_t)y * w;
+// 		for (int x = 0; x < w; x++)
+// 			out[x] = row[x * 2];
+// 	}
+// }
+import "C"
+import "unsafe"
+
+// rgbaToGray converts 4 bytes per pixel RGBA rows into a tightly packed
+// luminance plane, with the same fixed point weights as color.GrayModel
+func rgbaToGray(dst []byte, pix []byte, w int, h int, stride int) {
+	if w*h == 0 {
+		return
+	}
+	C.goquirc_rgba_to_gray((*C.uint8_t)(unsafe.Pointer(&dst[0])), (*C.uint8_t)(unsafe.Pointer(&pix[0])),
+		C.int(w), C.int(h), C.ptrdiff_t(stride))
+}
+
+// rgbaOverWhite converts 4 bytes per pixel RGBA or NRGBA rows into a
+// tightly packed luminance plane, composited over white so that transparent
+// pixels come out light
+func rgbaOverWhite(dst []byte, pix []byte, w int, h int, stride int, premultiplied bool) {
+	if w*h == 0 {
+		return
+	}
+	flag := C.int(0)
+	if premultiplied {
+		flag = 1
+	}
+	C.goquirc_rgba_over_white((*C.uint8_t)(unsafe.Pointer(&dst[0])), (*C.uint8_t)(unsafe.Pointer(&pix[0])),
+		C.int(w), C.int(h), C.ptrdiff_t(stride), flag)
+}
+
+// packedLuma extracts every other byte of rows of 2 bytes per pixel, which is
+// the luminance of packed 4:2:2 frames once offset to their first Y sample
+func packedLuma(dst []byte, pix []byte, w int, h int, stride int) {
+	if w*h == 0 {
+		return
+	}
+	C.goquirc_packed_luma((*C.uint8_t)(unsafe.Pointer(&dst[0])), (*C.uint8_t)(unsafe.Pointer(&pix[0])),
+		C.int(w), C.int(h), C.ptrdiff_t(stride))
+}
@@ -0,0 +1,46 @@
+//go:build !cgo
+
+package goquirc
+
+// rgbaToGray converts 4 bytes per pixel RGBA rows into a tightly packed
+// luminance plane, with the same fixed point weights as color.GrayModel
+func rgbaToGray(dst []byte, pix []byte, w int, h int, stride int) {
+	for y := 0; y < h; y++ {
+		row, out := pix[y*stride:], dst[y*w:(y+1)*w]
+		for x := range out {
+			r, g, b := uint32(row[x*4]), uint32(row[x*4+1]), uint32(row[x*4+2])
+			out[x] = byte((19595*r + 38470*g + 7471*b + 1<<15) >> 16)
+		}
+	}
+}
+
+// rgbaOverWhite converts 4 bytes per pixel RGBA or NRGBA rows into a
+// tightly packed luminance plane, composited over white so that transparent
+// pixels come out light
+func rgbaOverWhite(dst []byte, pix []byte, w int, h int, stride int, premultiplied bool) {
+	for y := 0; y < h; y++ {
+		row, out := pix[y*stride:], dst[y*w:(y+1)*w]
+		for x := range out {
+			r, g, b, a := uint32(row[x*4]), uint32(row[x*4+1]), uint32(row[x*4+2]), uint32(row[x*4+3])
+			luma := (19595*r + 38470*g + 7471*b + 1<<15) >> 16
+			if premultiplied {
+				out[x] = byte(luma + 255 - a)
+				continue
+			}
+			// Rounded v / 255
+			v := luma*a + 255*(255-a) + 128
+			out[x] = byte((v + v>>8) >> 8)
+		}
+	}
+}
+
+// packedLuma extracts every other byte of rows of 2 bytes per pixel, which is
+// the luminance of packed 4:2:2 frames once offset to their first Y sample
+func packedLuma(dst []byte, pix []byte, w int, h int, stride int) {
+	for y := 0; y < h; y++ {
+		row, out := pix[y*stride:], dst[y*w:(y+1)*w]
+		for x := range out {
+			out[x] = row[x*2]
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package goquirc
+
+import (
+	"github.com/quaresc/goquirc/internal/qrspec"
+	"github.com/quaresc/goquirc/internal/reedsolomon"
+)
+
+// stream holds the content parsed from the data codewords of a qrcode, for
+// the parts quirc does not report such as structured append headers
+type stream struct {
+	payload  []byte
+	dataType DataType
+	eci      int
+	append   *StructuredAppend
+
+	// version, level and mask are read from the grid size and format
+	version int
+	level   ECCLevel
+	mask    int
+}
+
+// readStream samples the data codewords from the cells of an extracted
+// qrcode, corrects them and parses their segments
+func readStream(size int, cells []byte) (stream, error) {
+	version := qrspec.Version(size)
+	if version == 0 {
+		return stream{}, ErrInvalidGridSize
+	}
+
+	grid := qrspec.NewGrid(version)
+	for i := range grid.Dark {
+		grid.Dark[i] = cells[i>>3]>>uint(i&7)&1 != 0
+	}
+
+	first, second := grid.ReadFormat()
+	level, mask, ok := qrspec.DecodeFormat(first)
+	if !ok {
+		if level, mask, ok = qrspec.DecodeFormat(second); !ok {
+			return stream{}, ErrFormatECC
+		}
+	}
+
+	codewords := make([]byte, qrspec.RawCodewords(version))
+	bit := 0
+	grid.Walk(func(x int, y int) {
+		if bit >= len(codewords)*8 {
+			return
+		}
+		if grid.At(x, y) != qrspec.Mask(mask, x, y) {
+			codewords[bit>>3] |= 0x80 >> uint(bit&7)
+		}
+		bit++
+	})
+
+	ecc := qrspec.ECCodewordsPerBlock(version, level)
+	data := make([]byte, 0, qrspec.DataCodewords(version, level))
+	for _, block := range qrspec.Deinterleave(codewords, version, level) {
+		if _, err := reedsolomon.Correct(block, ecc); err != nil {
+			return stream{}, ErrDataECC
+		}
+		data = append(data, block[:len(block)-ecc]...)
+	}
+
+	s, err := parseStream(data, version)
+	s.version, s.level, s.mask = version, ECCLevel(level.FormatBits()), mask
+	return s, err
+}
+
+// parseStream parses the segments of corrected data codewords
+func parseStream(data []byte, version int) (stream, error) {
+	var s stream
+	r := bitReader{data: data}
+
+	for r.remaining() >= 4 {
+		mode := r.read(4)
+		switch mode {
+		case 0:
+			return s, nil
+		case 1:
+			count := r.read(qrspec.CountBits(mode, version))
+			for ; count >= 3; count -= 3 {
+				if r.remaining() < 10 {
+					return s, ErrDataUnderflow
+				}
+				s.payload = appendDigits(s.payload, r.read(10), 3)
+			}
+			if count > 0 {
+				bits := 4 + 3*(count-1)
+				if r.remaining() < bits {
+					return s, ErrDataUnderflow
+				}
+				s.payload = appendDigits(s.payload, r.read(bits), count)
+			}
+		case 2:
+			count := r.read(qrspec.CountBits(mode, version))
+			for ; count >= 2; count -= 2 {
+				if r.remaining() < 11 {
+					return s, ErrDataUnderflow
+				}
+				pair := r.read(11)
+				if pair >= 45*45 {
+					return s, ErrDataUnderflow
+				}
+				s.payload = append(s.payload, qrspec.Alphanumeric[pair/45], qrspec.Alphanumeric[pair%45])
+			}
+			if count > 0 {
+				if r.remaining() < 6 {
+					return s, ErrDataUnderflow
+				}
+				if c := r.read(6); c < 45 {
+					s.payload = append(s.payload, qrspec.Alphanumeric[c])
+				}
+			}
+		case 3:
+			if r.remaining() < 16 {
+				return s, ErrDataUnderflow
+			}
+			s.append = &StructuredAppend{
+				Index:  r.read(4),
+				Total:  r.read(4) + 1,
+				Parity: byte(r.read(8)),
+			}
+		case 4:
+			count := r.read(qrspec.CountBits(mode, version))
+			if r.remaining() < count*8 {
+				return s, ErrDataUnderflow
+			}
+			for ; count > 0; count-- {
+				s.payload = append(s.payload, byte(r.read(8)))
+			}
+		case 5:
+			// FNC1 in first position carries no data
+		case 7:
+			if r.remaining() < 8 {
+				return s, ErrDataUnderflow
+			}
+			eci := r.read(8)
+			switch {
+			case eci&0x80 == 0:
+			case eci&0xc0 == 0x80 && r.remaining() >= 8:
+				eci = (eci&0x3f)<<8 | r.read(8)
+			case eci&0xe0 == 0xc0 && r.remaining() >= 16:
+				eci = (eci&0x1f)<<16 | r.read(16)
+			default:
+				return s, ErrDataUnderflow
+			}
+			s.eci = eci
+		case 8:
+			count := r.read(qrspec.CountBits(mode, version))
+			if r.remaining() < count*13 {
+				return s, ErrDataUnderflow
+			}
+			for ; count > 0; count-- {
+				v := r.read(13)
+				sjis := (v/0xc0)<<8 | v%0xc0
+				if sjis+0x8140 <= 0x9ffc {
+					sjis += 0x8140
+				} else {
+					sjis += 0xc140
+				}
+				s.payload = append(s.payload, byte(sjis>>8), byte(sjis))
+			}
+		case 9:
+			if r.remaining() < 8 {
+				return s, ErrDataUnderflow
+			}
+			r.read(8)
+		default:
+			return s, ErrUnknownDataType
+		}
+
+		if mode&(mode-1) == 0 && DataType(mode) > s.dataType {
+			s.dataType = DataType(mode)
+		}
+	}
+
+	return s, nil
+}
+
+// appendDigits appends the count decimal digits of value
+func appendDigits(payload []byte, value int, count int) []byte {
+	digits := make([]byte, count)
+	for i := count - 1; i >= 0; i-- {
+		digits[i] = byte('0' + value%10)
+		value /= 10
+	}
+	return append(payload, digits...)
+}
+
+// bitReader reads big endian bit fields from a byte slice
+type bitReader struct {
+	data []byte
+	pos  int
+}
+
+// remaining returns the number of bits left
+func (r *bitReader) remaining() int {
+	return len(r.data)*8 - r.pos
+}
+
+// read returns the next n bits, missing bits being read as zeros
+func (r *bitReader) read(n int) int {
+	value := 0
+	for i := 0; i < n; i++ {
+		value <<= 1
+		if r.pos < len(r.data)*8 && r.data[r.pos>>3]&(0x80>>uint(r.pos&7)) != 0 {
+			value |= 1
+		}
+		r.pos++
+	}
+	return value
+}
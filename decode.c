@@ -0,0 +1,75 @@
+#include <string.h>
+#include "decode.h"
+
+// goquirc_decode_range extracts and decodes n processings from index from
+void goquirc_decode_range(struct goquirc *g, int from, int n,
+			  struct goquirc_result *results)
+{
+	for (int i = 0; i < n; i++) {
+		goquirc_extract(g, from + i, &results[i].code);
+		results[i].err = quirc_decode(&results[i].code, &results[i].data);
+	}
+}
+
+// quirc_decode_all loads an image, negated when invert is set, identifies
+// its processings and decodes the first capacity ones, returning their count
+int quirc_decode_all(struct goquirc *g, const uint8_t *image, size_t size,
+		     int invert, struct goquirc_result *results, int capacity)
+{
+	int w, h;
+	uint8_t *buffer = goquirc_begin(g, &w, &h);
+	size_t length = (size_t)w * h;
+
+	if (size < length)
+		length = size;
+	if (length > 0)
+		memcpy(buffer, image, length);
+	if (invert)
+		for (size_t i = 0; i < (size_t)w * h; i++)
+			buffer[i] = 255 - buffer[i];
+	goquirc_end(g);
+
+	int count = goquirc_count(g);
+	goquirc_decode_range(g, 0, count < capacity ? count : capacity, results);
+	return count;
+}
+
+// goquirc_decode_frames decodes frames in turn, up to limit processings
+// each when limit is positive, while results has room for all of their
+// processings. It returns the number of frames processed, the last one
+// possibly holding more processings than decoded
+int goquirc_decode_frames(struct goquirc *g, struct goquirc_frame *frames, int n,
+			  int limit, struct goquirc_result *results, int capacity)
+{
+	int used = 0;
+	int w, h;
+
+	goquirc_begin(g, &w, &h);
+	for (int i = 0; i < n; i++) {
+		struct goquirc_frame *f = &frames[i];
+
+		if ((f->w != w || f->h != h) && goquirc_resize(g, f->w, f->h) < 0) {
+			f->err = 1;
+			return i + 1;
+		}
+		w = f->w;
+		h = f->h;
+
+		int room = capacity - used;
+		if (limit > 0 && limit < room)
+			room = limit;
+
+		f->first = used;
+		f->found = quirc_decode_all(g, f->image, f->size, 0,
+					    results + used, room);
+		f->decoded = f->found;
+		if (limit > 0 && f->decoded > limit)
+			f->decoded = limit;
+		if (f->decoded > room) {
+			f->decoded = room;
+			return i + 1;
+		}
+		used += f->decoded;
+	}
+	return n;
+}
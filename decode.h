@@ -0,0 +1,37 @@
+#ifndef GOQUIRC_DECODE_H_
+#define GOQUIRC_DECODE_H_
+
+#include <stddef.h>
+#include <stdint.h>
+#include <quirc.h>
+#include "regions.h"
+
+// goquirc_result holds a processing extracted and decoded on the C side
+struct goquirc_result {
+	struct quirc_code	code;
+	struct quirc_data	data;
+	quirc_decode_error_t	err;
+};
+
+// goquirc_frame describes a source image of a batch, found, first and
+// decoded being filled with the count of processings identified, the index
+// of the first result and the number of results decoded
+struct goquirc_frame {
+	const uint8_t	*image;
+	size_t		size;
+	int		w;
+	int		h;
+	int		err;
+	int		found;
+	int		first;
+	int		decoded;
+};
+
+void goquirc_decode_range(struct goquirc *g, int from, int n,
+			  struct goquirc_result *results);
+int quirc_decode_all(struct goquirc *g, const uint8_t *image, size_t size,
+		     int invert, struct goquirc_result *results, int capacity);
+int goquirc_decode_frames(struct goquirc *g, struct goquirc_frame *frames, int n,
+			  int limit, struct goquirc_result *results, int capacity);
+
+#endif
@@ -0,0 +1,63 @@
+package goquirc
+
+// #include "decode.h"
+import "C"
+import "unsafe"
+
+// decodeCapacity is the number of processings decoded by the first cgo call
+// of a frame, the others taking a second one
+const decodeCapacity = 8
+
+// decodeResults holds the processings decoded on the C side, reused from one
+// frame to the next
+type decodeResults []C.struct_goquirc_result
+
+// decodeAll works like Load, End and the extraction and decoding of all
+// processings, crossing the cgo boundary once for most frames. It returns the
+// count of processings found and the outcomes of those within the limit
+func (qr *Processing) decodeAll(image []byte, cfg *config, inverted bool) (int, []outcome) {
+	if len(qr.results) < decodeCapacity {
+		qr.results = make(decodeResults, decodeCapacity)
+	}
+	capacity := len(qr.results)
+	if cfg.maxCodes > 0 && cfg.maxCodes < capacity {
+		capacity = cfg.maxCodes
+	}
+
+	var pix *C.uint8_t
+	if len(image) > 0 {
+		pix = (*C.uint8_t)(unsafe.Pointer(&image[0]))
+	}
+	invert := C.int(0)
+	if inverted {
+		invert = 1
+	}
+	found := int(C.quirc_decode_all(qr.qrStruct, pix, C.size_t(len(image)), invert, &qr.results[0], C.int(capacity)))
+
+	limit := found
+	if cfg.maxCodes > 0 && cfg.maxCodes < limit {
+		limit = cfg.maxCodes
+	}
+	if limit > capacity {
+		grown := make(decodeResults, limit)
+		copy(grown, qr.results[:capacity])
+		qr.results = grown
+		C.goquirc_decode_range(qr.qrStruct, C.int(capacity), C.int(limit-capacity), &qr.results[capacity])
+	}
+
+	return found, qr.outcomes(qr.results[:limit], cfg, inverted)
+}
+
+// outcomes converts processings decoded on the C side
+func (qr *Processing) outcomes(results decodeResults, cfg *config, inverted bool) []outcome {
+	outcomes := make([]outcome, len(results))
+	for i := range results {
+		qr.code, qr.data = results[i].code, results[i].data
+		if qr.skipped(cfg) {
+			outcomes[i] = outcome{done: true, skipped: true}
+			continue
+		}
+		outcomes[i] = qr.finish(decodeError(results[i].err), cfg, inverted)
+	}
+	return outcomes
+}
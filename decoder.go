@@ -0,0 +1,129 @@
+package goquirc
+
+import (
+	"context"
+	"errors"
+	"image"
+	"io"
+	"time"
+)
+
+// Decoder keeps a single quirc context alive across many reveals, which
+// avoids allocating and freeing it for every frame of a video stream or
+// every request of a server
+type Decoder struct {
+	qr Processing
+
+	// frames counts the frames revealed, for Stats
+	frames uint64
+}
+
+var _ io.Closer = (*Decoder)(nil)
+
+// NewDecoder allocates a quirc context ready for successive reveals
+func NewDecoder() (*Decoder, error) {
+	return NewDecoderRegions(RegionsLarge)
+}
+
+// Close frees the quirc context, the decoder must not be used afterwards
+func (d *Decoder) Close() error {
+	if d.qr.allocated() {
+		d.qr.Destroy()
+	}
+	return nil
+}
+
+// Version provides current version of quirc
+func (d *Decoder) Version() string {
+	return d.qr.Version()
+}
+
+// Reveal counts and decodes all qrcodes found in a source image with its
+// dimensions, reusing the decoder context
+func (d *Decoder) Reveal(image *[]byte, w int, h int, opts ...Option) (Result, error) {
+	return d.RevealContext(context.Background(), image, w, h, opts...)
+}
+
+// RevealContext works like Reveal but stops as soon as ctx is done
+func (d *Decoder) RevealContext(ctx context.Context, image *[]byte, w int, h int, opts ...Option) (Result, error) {
+	if !d.qr.allocated() {
+		return Result{}, errors.New("Decoder is closed")
+	}
+	d.frames++
+	return d.qr.reveal(ctx, image, w, h, newConfig(opts))
+}
+
+// RevealFunc works like Reveal but hands each qrcode to fn as soon as it is
+// decoded, it stops when fn returns false
+func (d *Decoder) RevealFunc(image *[]byte, w int, h int, fn func(QRcode) bool, opts ...Option) error {
+	if !d.qr.allocated() {
+		return errors.New("Decoder is closed")
+	}
+	d.frames++
+	cfg := newConfig(opts)
+	cfg.visit = fn
+	_, err := d.qr.reveal(context.Background(), image, w, h, cfg)
+	return err
+}
+
+// RevealFirst works like Reveal but stops at the first qrcode successfully
+// decoded and returns it, or ErrNotFound when none could be decoded
+func (d *Decoder) RevealFirst(image *[]byte, w int, h int, opts ...Option) (QRcode, error) {
+	if !d.qr.allocated() {
+		return QRcode{}, errors.New("Decoder is closed")
+	}
+	d.frames++
+	return d.qr.revealFirst(context.Background(), image, w, h, newConfig(opts))
+}
+
+// Resize allocates the quirc image buffer for frames of w by h pixels, a
+// decoder fed with frames of constant dimensions allocates it only once
+func (d *Decoder) Resize(w int, h int) error {
+	if !d.qr.allocated() {
+		return errors.New("Decoder is closed")
+	}
+	return d.qr.Resize(w, h)
+}
+
+// Buffer returns a view over the quirc image buffer, sized by the last
+// Resize, so that a frame can be written there directly before calling Scan.
+// The view is only valid until dimensions change or the decoder is closed
+func (d *Decoder) Buffer() []byte {
+	if !d.qr.allocated() {
+		return nil
+	}
+	buffer, _, _ := d.qr.buffer()
+	return buffer
+}
+
+// Scan reveals the qrcodes of the frame written through Buffer
+func (d *Decoder) Scan(opts ...Option) (Result, error) {
+	if !d.qr.allocated() {
+		return Result{}, errors.New("Decoder is closed")
+	}
+	d.frames++
+
+	ctx := context.Background()
+	cfg := newConfig(opts)
+	buffer, w, h := d.qr.buffer()
+	if cfg.reloads() {
+		// Options needing the frame again work on a copy, as quirc may
+		// overwrite its buffer while identifying
+		image := append([]byte(nil), buffer...)
+		return d.qr.reveal(ctx, &image, w, h, cfg)
+	}
+
+	cfg.start()
+	start := time.Now()
+	d.qr.end(cfg)
+	if cfg.observe != nil {
+		d.qr.observeIdentify(cfg, start)
+	}
+	return d.qr.collect(ctx, cfg, false)
+}
+
+// RevealImage works like Reveal but accepts any image.Image as source
+func (d *Decoder) RevealImage(img image.Image, opts ...Option) (Result, error) {
+	gray, w, h := Luminance(img)
+	return d.Reveal(&gray, w, h, opts...)
+}
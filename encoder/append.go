@@ -0,0 +1,71 @@
+package encoder
+
+import "errors"
+
+// maxAppendSymbols is the largest count of symbols of a structured append
+// sequence
+const maxAppendSymbols = 16
+
+// MakeStructuredAppend returns the header placing a symbol at index in a
+// sequence of total symbols, parity being the XOR of all the bytes of the
+// whole payload. It must come first in the segments of a symbol
+func MakeStructuredAppend(index int, total int, parity byte) (Segment, error) {
+	if total < 1 || total > maxAppendSymbols || index < 0 || index >= total {
+		return Segment{}, errors.New("Structured append sequences hold 1 to 16 symbols")
+	}
+	return Segment{Mode: StructuredAppend, Data: []byte{byte(index<<4 | (total - 1)), parity}}, nil
+}
+
+// EncodeAppend splits payload into the smallest structured append sequence
+// whose symbols fit in the allowed versions, each one holding a part in the
+// most compact mode of the whole payload. A payload fitting in a single
+// symbol still gets a sequence of one, so that readers reassembling
+// messages handle it alike
+func EncodeAppend(payload []byte, opts ...Option) ([]*Symbol, error) {
+	cfg, err := newConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.micro {
+		return nil, errors.New("Structured append is not available on Micro QR")
+	}
+
+	var parity byte
+	for _, b := range payload {
+		parity ^= b
+	}
+	whole := MakeSegments(payload)[0]
+
+	for total := 1; total <= maxAppendSymbols; total++ {
+		parts := make([][]Segment, total)
+		fits := true
+		for i := range parts {
+			header, _ := MakeStructuredAppend(i, total, parity)
+			parts[i] = []Segment{header, chunk(whole, i, total)}
+			if fitting(parts[i], cfg.minVersion, cfg.maxVersion, cfg.level) == 0 {
+				fits = false
+				break
+			}
+		}
+		if !fits {
+			continue
+		}
+
+		symbols := make([]*Symbol, total)
+		for i, part := range parts {
+			if symbols[i], err = EncodeSegments(part, opts...); err != nil {
+				return nil, err
+			}
+		}
+		return symbols, nil
+	}
+	return nil, ErrTooLong
+}
+
+// chunk returns part index of a segment split into total parts of nearly
+// equal lengths
+func chunk(s Segment, index int, total int) Segment {
+	size := (len(s.Data) + total - 1) / total
+	from, to := minInt(index*size, len(s.Data)), minInt((index+1)*size, len(s.Data))
+	return Segment{Mode: s.Mode, Data: s.Data[from:to]}
+}
@@ -0,0 +1,257 @@
+// Package encoder generates qrcodes, from segment encoding and version
+// selection to error correction and masking, as a counterpart of the quirc
+// decoder
+package encoder
+
+import (
+	"errors"
+	"image"
+
+	"github.com/quaresc/goquirc/internal/qrspec"
+	"github.com/quaresc/goquirc/internal/reedsolomon"
+)
+
+// Level is an error correction level, ordered from the weakest to the
+// strongest
+type Level int
+
+// Error correction levels, restoring about 7%, 15%, 25% and 30% of the
+// codewords
+const (
+	L Level = Level(qrspec.L)
+	M Level = Level(qrspec.M)
+	Q Level = Level(qrspec.Q)
+	H Level = Level(qrspec.H)
+)
+
+// ErrTooLong is returned when segments do not fit in the allowed versions
+var ErrTooLong = errors.New("Payload does not fit in a qrcode")
+
+// Symbol is a generated qrcode, a square matrix of Size modules per side.
+// Micro QR symbols number their versions from 1 to 4 for M1 to M4
+type Symbol struct {
+	Version int
+	Level   Level
+	Mask    int
+	Size    int
+	Micro   bool
+
+	// Logo is drawn over the modules of LogoArea, which read as light
+	Logo image.Image
+
+	dark []bool
+	logo image.Rectangle
+}
+
+// Dark tells whether the module at column x and row y is dark, modules
+// outside the symbol belong to the quiet zone and are light
+func (s *Symbol) Dark(x int, y int) bool {
+	if x < 0 || x >= s.Size || y < 0 || y >= s.Size {
+		return false
+	}
+	if image.Pt(x, y).In(s.logo) {
+		return false
+	}
+	return s.dark[y*s.Size+x]
+}
+
+// QuietZone returns the width in modules of the light margin required
+// around the symbol
+func (s *Symbol) QuietZone() int {
+	if s.Micro {
+		return 2
+	}
+	return 4
+}
+
+// config holds the encoding parameters
+type config struct {
+	level      Level
+	minVersion int
+	maxVersion int
+	mask       int
+	boost      bool
+	micro      bool
+	logo       image.Image
+	logoRatio  float64
+}
+
+// Option tunes the encoding
+type Option func(*config)
+
+// WithLevel sets the error correction level, M by default
+func WithLevel(level Level) Option {
+	return func(cfg *config) {
+		cfg.level = level
+	}
+}
+
+// WithVersions restricts the versions, hence the sizes, a symbol may take,
+// the smallest one fitting the segments being selected. All of them are
+// allowed by default
+func WithVersions(min int, max int) Option {
+	return func(cfg *config) {
+		cfg.minVersion, cfg.maxVersion = min, max
+	}
+}
+
+// WithMask forces a mask pattern from 0 to 7, or 0 to 3 for Micro QR,
+// instead of the one with the lowest penalty
+func WithMask(mask int) Option {
+	return func(cfg *config) {
+		cfg.mask = mask
+	}
+}
+
+// WithMicro generates Micro QR symbols, from M1 to M4, which hold short
+// payloads with a single finder pattern. Level H and ECI segments are not
+// available, M1 only detects errors and is reported as level L
+func WithMicro() Option {
+	return func(cfg *config) {
+		cfg.micro = true
+	}
+}
+
+// WithLevelBoost raises the error correction level as long as the segments
+// still fit in the selected version
+func WithLevelBoost() Option {
+	return func(cfg *config) {
+		cfg.boost = true
+	}
+}
+
+// newConfig applies options over the defaults
+func newConfig(opts []Option) (*config, error) {
+	cfg := &config{level: M, mask: -1}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	first, last, masks := qrspec.MinVersion, qrspec.MaxVersion, 8
+	if cfg.micro {
+		first, last, masks = qrspec.MinMicroVersion, qrspec.MaxMicroVersion, 4
+	}
+	if cfg.minVersion == 0 && cfg.maxVersion == 0 {
+		cfg.minVersion, cfg.maxVersion = first, last
+	}
+
+	if cfg.level < L || cfg.level > H {
+		return nil, errors.New("Invalid error correction level")
+	}
+	if cfg.minVersion < first || cfg.maxVersion > last || cfg.minVersion > cfg.maxVersion {
+		return nil, errors.New("Invalid version range")
+	}
+	if cfg.mask < -1 || cfg.mask >= masks {
+		return nil, errors.New("Invalid mask")
+	}
+	return cfg, nil
+}
+
+// Encode generates a qrcode holding payload in its most compact mode
+func Encode(payload []byte, opts ...Option) (*Symbol, error) {
+	return EncodeSegments(MakeSegments(payload), opts...)
+}
+
+// EncodeSegments generates a qrcode holding segments in the smallest allowed
+// version
+func EncodeSegments(segments []Segment, opts ...Option) (*Symbol, error) {
+	cfg, err := newConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.micro && cfg.logo != nil {
+		return nil, errors.New("Logos are not available on Micro QR")
+	} else if cfg.micro {
+		return encodeMicro(segments, cfg)
+	}
+	if cfg.logo != nil {
+		return encodeLogo(segments, cfg)
+	}
+
+	version := fitting(segments, cfg.minVersion, cfg.maxVersion, cfg.level)
+	if version == 0 {
+		return nil, ErrTooLong
+	}
+
+	level := cfg.level
+	bits := streamBits(segments, version)
+	for cfg.boost && level < H && bits <= qrspec.DataCodewords(version, qrspec.Level(level+1))*8 {
+		level++
+	}
+
+	return build(segments, version, level, cfg.mask), nil
+}
+
+// streamBits returns the length of segments in a symbol of a version, or -1
+// when one of them does not fit
+func streamBits(segments []Segment, version int) int {
+	total := 0
+	for _, s := range segments {
+		n := s.bits(version)
+		if n < 0 {
+			return -1
+		}
+		total += n
+	}
+	return total
+}
+
+// build draws the symbol of segments known to fit
+func build(segments []Segment, version int, level Level, mask int) *Symbol {
+	capacity := qrspec.DataCodewords(version, qrspec.Level(level)) * 8
+	w := &bitWriter{}
+	for _, s := range segments {
+		s.write(w, version)
+	}
+	w.write(0, minInt(4, capacity-w.bits))
+	w.write(0, (8-w.bits%8)%8)
+	for pad := 0xec; w.bits < capacity; pad ^= 0xec ^ 0x11 {
+		w.write(pad, 8)
+	}
+
+	codewords := qrspec.Interleave(w.data, version, qrspec.Level(level), reedsolomon.Encode)
+	grid := qrspec.NewGrid(version)
+	bit := 0
+	grid.Walk(func(x int, y int) {
+		if bit < len(codewords)*8 {
+			grid.Dark[y*grid.Size+x] = codewords[bit>>3]&(0x80>>uint(bit&7)) != 0
+		}
+		bit++
+	})
+
+	if mask < 0 {
+		best := -1
+		for m := 0; m < 8; m++ {
+			if p := penalty(masked(grid, version, level, m)); best < 0 || p < best {
+				mask, best = m, p
+			}
+		}
+	}
+
+	return &Symbol{
+		Version: version,
+		Level:   level,
+		Mask:    mask,
+		Size:    grid.Size,
+		dark:    masked(grid, version, level, mask).Dark,
+	}
+}
+
+// masked returns a copy of the grid with its data modules flipped by a mask
+// and its format and version information drawn
+func masked(grid *qrspec.Grid, version int, level Level, mask int) *qrspec.Grid {
+	g := grid.Clone()
+	for y := 0; y < g.Size; y++ {
+		for x := 0; x < g.Size; x++ {
+			if !g.IsFunction(x, y) && qrspec.Mask(mask, x, y) {
+				g.Dark[y*g.Size+x] = !g.Dark[y*g.Size+x]
+			}
+		}
+	}
+	g.DrawFormat(qrspec.FormatInfo(qrspec.Level(level), mask))
+	if version >= 7 {
+		g.DrawVersion(qrspec.VersionInfo(version))
+	}
+	return g
+}
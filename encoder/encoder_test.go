@@ -0,0 +1,119 @@
+package encoder
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/quaresc/goquirc"
+)
+
+func TestEncodeRoundTrip(t *testing.T) {
+	levels := map[Level]goquirc.ECCLevel{L: goquirc.ECCLevelL, M: goquirc.ECCLevelM, Q: goquirc.ECCLevelQ, H: goquirc.ECCLevelH}
+	for _, version := range []int{1, 3, 6, 9, 13, 18, 24, 31, 40} {
+		for level, decoded := range levels {
+			payload := fmt.Sprintf("%d-%d", version, level)
+			s, err := Encode([]byte(payload), WithVersions(version, version), WithLevel(level))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var qr goquirc.Processing
+			result, err := qr.RevealImage(s.Image(WithModuleSize(3)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(result.Code) != 1 {
+				t.Errorf("version %d level %d: decoded %d qrcodes, want 1", version, level, len(result.Code))
+				continue
+			}
+			code := result.Code[0]
+			if code.Text() != payload || code.Version != version || code.ECCLevel != decoded || code.Mask != s.Mask {
+				t.Errorf("version %d level %d: decoded %q version %d level %v mask %d, want %q mask %d",
+					version, level, code.Text(), code.Version, code.ECCLevel, code.Mask, payload, s.Mask)
+			}
+		}
+	}
+}
+
+func TestFormatInformation(t *testing.T) {
+	// Masked format information of ISO/IEC 18004 table C.1
+	tests := []struct {
+		level Level
+		mask  int
+		want  int
+	}{
+		{L, 0, 0x77c4},
+		{L, 4, 0x662f},
+		{M, 0, 0x5412},
+		{M, 5, 0x40ce},
+		{Q, 0, 0x355f},
+		{H, 0, 0x1689},
+	}
+	for _, tt := range tests {
+		s, err := Encode([]byte("FORMAT"), WithVersions(1, 1), WithLevel(tt.level), WithMask(tt.mask))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Bit 0 is the least significant, both copies of the format
+		// information are read
+		first, second := 0, 0
+		for i := 0; i < 15; i++ {
+			var x, y int
+			switch {
+			case i < 6:
+				x, y = 8, i
+			case i < 8:
+				x, y = 8, i+1
+			case i == 8:
+				x, y = 7, 8
+			default:
+				x, y = 14-i, 8
+			}
+			if s.Dark(x, y) {
+				first |= 1 << uint(i)
+			}
+
+			if i < 8 {
+				x, y = s.Size-1-i, 8
+			} else {
+				x, y = 8, s.Size-15+i
+			}
+			if s.Dark(x, y) {
+				second |= 1 << uint(i)
+			}
+		}
+		if first != tt.want || second != tt.want {
+			t.Errorf("level %d mask %d: format information %#04x and %#04x, want %#04x", tt.level, tt.mask, first, second, tt.want)
+		}
+		if !s.Dark(8, s.Size-8) {
+			t.Errorf("level %d mask %d: missing dark module", tt.level, tt.mask)
+		}
+	}
+}
+
+func TestVersionInformation(t *testing.T) {
+	// Version information of ISO/IEC 18004 table D.1
+	for version, want := range map[int]int{7: 0x07c94, 21: 0x15683, 40: 0x28c69} {
+		s, err := Encode([]byte("VERSION"), WithVersions(version, version))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Bit i lies at column i/3 of the three rows above the bottom
+		// left finder, and transposed right of the top left one
+		below, right := 0, 0
+		for i := 0; i < 18; i++ {
+			a, b := s.Size-11+i%3, i/3
+			if s.Dark(b, a) {
+				below |= 1 << uint(i)
+			}
+			if s.Dark(a, b) {
+				right |= 1 << uint(i)
+			}
+		}
+		if below != want || right != want {
+			t.Errorf("version %d: version information %#05x and %#05x, want %#05x", version, below, right, want)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package encoder
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+)
+
+// Image draws the symbol with its quiet zone, one module being a square of
+// the module size in pixels. Symbols without logo are drawn on a two colors
+// palette, which keeps their PNG encoding small
+func (s *Symbol) Image(opts ...RenderOption) image.Image {
+	st := newStyle(s, opts)
+	side := (s.Size + 2*st.quietZone) * st.moduleSize
+	rect := image.Rect(0, 0, side, side)
+	module := func(px int, py int) bool {
+		return s.Dark(px/st.moduleSize-st.quietZone, py/st.moduleSize-st.quietZone)
+	}
+
+	if s.Logo == nil || s.logo.Empty() {
+		img := image.NewPaletted(rect, color.Palette{st.light, st.dark})
+		for py := 0; py < side; py++ {
+			for px := 0; px < side; px++ {
+				if module(px, py) {
+					img.Pix[py*img.Stride+px] = 1
+				}
+			}
+		}
+		return img
+	}
+
+	img := image.NewRGBA(rect)
+	dark, light := color.RGBAModel.Convert(st.dark).(color.RGBA), color.RGBAModel.Convert(st.light).(color.RGBA)
+	for py := 0; py < side; py++ {
+		for px := 0; px < side; px++ {
+			c := light
+			if module(px, py) {
+				c = dark
+			}
+			img.SetRGBA(px, py, c)
+		}
+	}
+
+	area := s.logo.Add(image.Pt(st.quietZone, st.quietZone))
+	area = image.Rectangle{area.Min.Mul(st.moduleSize), area.Max.Mul(st.moduleSize)}
+	drawLogo(s.Logo, area, func(x int, y int, c color.Color) {
+		r, g, b, a := c.RGBA()
+		bg := img.RGBAAt(x, y)
+		over := func(v uint32, under uint8) uint8 {
+			return uint8((v + uint32(under)*0x101*(0xffff-a)/0xffff) >> 8)
+		}
+		img.SetRGBA(x, y, color.RGBA{over(r, bg.R), over(g, bg.G), over(b, bg.B), over(a, bg.A)})
+	})
+	return img
+}
+
+// PNG writes the symbol drawn by Image as a PNG image
+func (s *Symbol) PNG(w io.Writer, opts ...RenderOption) error {
+	return png.Encode(w, s.Image(opts...))
+}
@@ -0,0 +1,170 @@
+package encoder
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/quaresc/goquirc"
+	"github.com/quaresc/goquirc/internal/qrspec"
+)
+
+// ErrLogoTooLarge is returned when no error correction level can restore
+// the modules covered by a logo
+var ErrLogoTooLarge = errors.New("Logo covers too many modules")
+
+// logoScale is the count of pixels per module of the images decoded to
+// validate symbols with a logo
+const logoScale = 4
+
+// WithLogo draws logo over the center of the symbol, in a square covering
+// ratio of its side, such as 0.2. The error correction level is raised from
+// the one requested until the covered modules can be restored, and the
+// symbol is checked to decode with quirc
+func WithLogo(logo image.Image, ratio float64) Option {
+	return func(cfg *config) {
+		cfg.logo, cfg.logoRatio = logo, ratio
+	}
+}
+
+// LogoArea returns the square of modules covered by the logo, empty when
+// the symbol has none
+func (s *Symbol) LogoArea() image.Rectangle {
+	return s.logo
+}
+
+// encodeLogo generates the symbol of segments with the lowest error
+// correction level restoring the modules covered by the logo
+func encodeLogo(segments []Segment, cfg *config) (*Symbol, error) {
+	if cfg.logoRatio <= 0 || cfg.logoRatio >= 1 {
+		return nil, errors.New("Logo ratio must be between 0 and 1")
+	}
+
+	var payload []byte
+	for _, s := range segments {
+		if s.Mode != StructuredAppend {
+			payload = append(payload, s.Data...)
+		}
+	}
+
+	for level := cfg.level; level <= H; level++ {
+		version := fitting(segments, cfg.minVersion, cfg.maxVersion, level)
+		if version == 0 && level == cfg.level {
+			return nil, ErrTooLong
+		} else if version == 0 {
+			break
+		}
+
+		// Small versions leave too little room between their patterns
+		area := logoArea(qrspec.Size(version), cfg.logoRatio)
+		for version != 0 && !uncovered(area, qrspec.Size(version)) {
+			if version = fitting(segments, version+1, cfg.maxVersion, level); version != 0 {
+				area = logoArea(qrspec.Size(version), cfg.logoRatio)
+			}
+		}
+		if version == 0 {
+			return nil, ErrLogoTooLarge
+		}
+		if !restorable(version, level, area) {
+			continue
+		}
+
+		s := build(segments, version, level, cfg.mask)
+		s.Logo, s.logo = cfg.logo, area
+		if decodes(s, payload) {
+			return s, nil
+		}
+	}
+	return nil, ErrLogoTooLarge
+}
+
+// fitting returns the smallest version from first to last where segments
+// fit at a level, or zero when there is none
+func fitting(segments []Segment, first int, last int, level Level) int {
+	for v := first; v <= last; v++ {
+		if n := streamBits(segments, v); n >= 0 && n <= qrspec.DataCodewords(v, qrspec.Level(level))*8 {
+			return v
+		}
+	}
+	return 0
+}
+
+// logoArea returns the centered square of modules covering ratio of the side
+func logoArea(size int, ratio float64) image.Rectangle {
+	side := int(math.Round(float64(size) * ratio))
+	origin := (size - side) / 2
+	return image.Rect(origin, origin, origin+side, origin+side)
+}
+
+// uncovered tells whether an area leaves finder, timing, format and version
+// patterns uncovered
+func uncovered(area image.Rectangle, size int) bool {
+	inner := image.Rect(9, 9, size-9, size-9)
+	return area.In(inner)
+}
+
+// restorable tells whether every block keeps enough error correction
+// codewords to fix the ones the area covers, assuming they are all wrong
+func restorable(version int, level Level, area image.Rectangle) bool {
+	grid := qrspec.NewGrid(version)
+	blocks := qrspec.CodewordBlocks(version, qrspec.Level(level))
+	covered := make([]bool, len(blocks))
+	bit := 0
+	grid.Walk(func(x int, y int) {
+		if bit < len(blocks)*8 && image.Pt(x, y).In(area) {
+			covered[bit>>3] = true
+		}
+		bit++
+	})
+
+	damaged := make([]int, qrspec.Blocks(version, qrspec.Level(level)))
+	for i, c := range covered {
+		if c {
+			damaged[blocks[i]]++
+		}
+	}
+	budget := qrspec.ECCodewordsPerBlock(version, qrspec.Level(level)) / 2
+	for _, n := range damaged {
+		if n > budget {
+			return false
+		}
+	}
+	return true
+}
+
+// decodes tells whether quirc reads payload back from the symbol drawn with
+// its logo
+func decodes(s *Symbol, payload []byte) bool {
+	gray, w, h := goquirc.Luminance(s.Image(WithModuleSize(logoScale)))
+	var qr goquirc.Processing
+	result, err := qr.Reveal(&gray, w, h)
+	if err != nil {
+		return false
+	}
+	for _, code := range result.Code {
+		if bytes.Equal(code.Payload, payload) {
+			return true
+		}
+	}
+	return false
+}
+
+// drawLogo scales logo to fit a rectangle of pixels, keeping its aspect
+// ratio, and hands set the color of each pixel it covers
+func drawLogo(logo image.Image, rect image.Rectangle, set func(x int, y int, c color.Color)) {
+	bounds := logo.Bounds()
+	if bounds.Empty() || rect.Empty() {
+		return
+	}
+
+	scale := math.Min(float64(rect.Dx())/float64(bounds.Dx()), float64(rect.Dy())/float64(bounds.Dy()))
+	w, h := int(float64(bounds.Dx())*scale), int(float64(bounds.Dy())*scale)
+	x0, y0 := rect.Min.X+(rect.Dx()-w)/2, rect.Min.Y+(rect.Dy()-h)/2
+	for y := y0; y < y0+h; y++ {
+		for x := x0; x < x0+w; x++ {
+			set(x, y, logo.At(bounds.Min.X+int(float64(x-x0)/scale), bounds.Min.Y+int(float64(y-y0)/scale)))
+		}
+	}
+}
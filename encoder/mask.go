@@ -0,0 +1,79 @@
+package encoder
+
+import "github.com/quaresc/goquirc/internal/qrspec"
+
+// Penalty weights of the mask evaluation
+const (
+	penaltyRun    = 3
+	penaltyBlock  = 3
+	penaltyFinder = 40
+	penaltyRatio  = 10
+)
+
+// finderLike is a 1:1:3:1:1 pattern preceded by four light modules, checked
+// in both directions
+var finderLike = [11]bool{false, false, false, false, true, false, true, true, true, false, true}
+
+// penalty scores how hard a masked symbol is to read, from its runs of
+// modules of the same color, 2x2 blocks, patterns looking like finders
+// and the balance of dark and light modules
+func penalty(g *qrspec.Grid) int {
+	score := 0
+	for i := 0; i < g.Size; i++ {
+		score += lineScore(g, func(j int) bool { return g.At(j, i) })
+		score += lineScore(g, func(j int) bool { return g.At(i, j) })
+	}
+
+	dark := 0
+	for y := 0; y < g.Size; y++ {
+		for x := 0; x < g.Size; x++ {
+			c := g.At(x, y)
+			if c {
+				dark++
+			}
+			if x+1 < g.Size && y+1 < g.Size && c == g.At(x+1, y) && c == g.At(x, y+1) && c == g.At(x+1, y+1) {
+				score += penaltyBlock
+			}
+		}
+	}
+
+	total := g.Size * g.Size
+	deviation := dark*100/total - 50
+	if deviation < 0 {
+		deviation = -deviation
+	}
+	return score + deviation/5*penaltyRatio
+}
+
+// lineScore scores the runs and finder like patterns of a row or column
+func lineScore(g *qrspec.Grid, at func(int) bool) int {
+	score := 0
+	run := 0
+	for j := 0; j < g.Size; j++ {
+		if j > 0 && at(j) == at(j-1) {
+			run++
+		} else {
+			run = 1
+		}
+		if run == 5 {
+			score += penaltyRun
+		} else if run > 5 {
+			score++
+		}
+	}
+
+	for j := 0; j+len(finderLike) <= g.Size; j++ {
+		forward, backward := true, true
+		for k, dark := range finderLike {
+			forward = forward && at(j+k) == dark
+			backward = backward && at(j+len(finderLike)-1-k) == dark
+		}
+		if forward {
+			score += penaltyFinder
+		}
+		if backward {
+			score += penaltyFinder
+		}
+	}
+	return score
+}
@@ -0,0 +1,140 @@
+package encoder
+
+import (
+	"errors"
+
+	"github.com/quaresc/goquirc/internal/qrspec"
+	"github.com/quaresc/goquirc/internal/reedsolomon"
+)
+
+// encodeMicro generates the Micro QR symbol of segments in the smallest
+// allowed version
+func encodeMicro(segments []Segment, cfg *config) (*Symbol, error) {
+	if cfg.level == H {
+		return nil, errors.New("Level H is not available on Micro QR")
+	}
+	for _, s := range segments {
+		if s.Mode == ECI {
+			return nil, errors.New("ECI segments are not available on Micro QR")
+		}
+	}
+
+	for v := cfg.minVersion; v <= cfg.maxVersion; v++ {
+		bits := microStreamBits(segments, v)
+		if bits < 0 || bits > qrspec.MicroDataBits(v, qrspec.Level(cfg.level)) {
+			continue
+		}
+
+		level := cfg.level
+		for cfg.boost && level < Q && qrspec.MicroECCodewords(v, qrspec.Level(level+1)) != 0 &&
+			bits <= qrspec.MicroDataBits(v, qrspec.Level(level+1)) {
+			level++
+		}
+		return buildMicro(segments, v, level, cfg.mask), nil
+	}
+	return nil, ErrTooLong
+}
+
+// microStreamBits returns the length of segments in a Micro QR symbol of a
+// version, or -1 when one of them does not fit
+func microStreamBits(segments []Segment, version int) int {
+	total := 0
+	for _, s := range segments {
+		n := s.microBits(version)
+		if n < 0 {
+			return -1
+		}
+		total += n
+	}
+	return total
+}
+
+// buildMicro draws the Micro QR symbol of segments known to fit
+func buildMicro(segments []Segment, version int, level Level, mask int) *Symbol {
+	capacity := qrspec.MicroDataBits(version, qrspec.Level(level))
+	w := &bitWriter{}
+	for _, s := range segments {
+		s.writeMicro(w, version)
+	}
+	w.write(0, minInt(2*version+1, capacity-w.bits))
+	w.write(0, minInt((8-w.bits%8)%8, capacity-w.bits))
+	for pad := 0xec; capacity-w.bits >= 8; pad ^= 0xec ^ 0x11 {
+		w.write(pad, 8)
+	}
+	// M1 and M3 end with a codeword of 4 bits, the high half of a byte
+	w.write(0, capacity-w.bits)
+
+	data := w.data
+	ecc := reedsolomon.Encode(data, qrspec.MicroECCodewords(version, qrspec.Level(level)))
+	stream := &bitWriter{}
+	for i, b := range data {
+		if n := minInt(8, capacity-i*8); n == 8 {
+			stream.write(int(b), 8)
+		} else {
+			stream.write(int(b)>>uint(8-n), n)
+		}
+	}
+	for _, b := range ecc {
+		stream.write(int(b), 8)
+	}
+
+	grid := qrspec.NewMicroGrid(version)
+	bit := 0
+	grid.Walk(func(x int, y int) {
+		if bit < stream.bits {
+			grid.Dark[y*grid.Size+x] = stream.data[bit>>3]&(0x80>>uint(bit&7)) != 0
+		}
+		bit++
+	})
+
+	if mask < 0 {
+		best := -1
+		for m := 0; m < 4; m++ {
+			if score := microScore(maskedMicro(grid, version, level, m)); score > best {
+				mask, best = m, score
+			}
+		}
+	}
+
+	return &Symbol{
+		Version: version,
+		Level:   level,
+		Mask:    mask,
+		Size:    grid.Size,
+		Micro:   true,
+		dark:    maskedMicro(grid, version, level, mask).Dark,
+	}
+}
+
+// maskedMicro returns a copy of a Micro QR grid with its data modules
+// flipped by a mask and its format information drawn
+func maskedMicro(grid *qrspec.Grid, version int, level Level, mask int) *qrspec.Grid {
+	g := grid.Clone()
+	for y := 0; y < g.Size; y++ {
+		for x := 0; x < g.Size; x++ {
+			if !g.IsFunction(x, y) && qrspec.MicroMask(mask, x, y) {
+				g.Dark[y*g.Size+x] = !g.Dark[y*g.Size+x]
+			}
+		}
+	}
+	g.DrawMicroFormat(qrspec.MicroFormatInfo(version, qrspec.Level(level), mask))
+	return g
+}
+
+// microScore rates a masked Micro QR symbol from its dark modules along the
+// right and bottom edges, the highest score being the best
+func microScore(g *qrspec.Grid) int {
+	right, bottom := 0, 0
+	for i := 1; i < g.Size; i++ {
+		if g.At(g.Size-1, i) {
+			right++
+		}
+		if g.At(i, g.Size-1) {
+			bottom++
+		}
+	}
+	if right <= bottom {
+		return right*16 + bottom
+	}
+	return bottom*16 + right
+}
@@ -0,0 +1,34 @@
+package encoder
+
+import "testing"
+
+func TestMicroFormatLayout(t *testing.T) {
+	// M2-L symbols with masks 0 to 3 carry the format information 0x55ae,
+	// 0x5099, 0x5fc0 and 0x5af7, as generated by libqrencode
+	want := [4]int{0x55ae, 0x5099, 0x5fc0, 0x5af7}
+	for mask, bits := range want {
+		s, err := Encode([]byte("01234567"), WithMicro(), WithVersions(2, 2), WithLevel(L), WithMask(mask))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.Size != 13 || s.Level != L {
+			t.Fatalf("encoded a symbol of %d modules at level %v, want M2-L", s.Size, s.Level)
+		}
+
+		// Bits 0 to 7 down column 8 from row 1, bits 8 to 14 along row 8
+		// from column 7 to 1
+		got := 0
+		for i := 0; i < 15; i++ {
+			x, y := 8, i+1
+			if i >= 8 {
+				x, y = 15-i, 8
+			}
+			if s.Dark(x, y) {
+				got |= 1 << uint(i)
+			}
+		}
+		if got != bits {
+			t.Errorf("mask %d: format information %#04x, want %#04x", mask, got, bits)
+		}
+	}
+}
@@ -0,0 +1,271 @@
+package encoder
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/quaresc/goquirc/internal/qrspec"
+	"golang.org/x/text/encoding/japanese"
+)
+
+// Mode is the encoding of a segment, valued as its mode indicator
+type Mode int
+
+// Segment modes
+const (
+	Numeric          Mode = 1
+	Alphanumeric     Mode = 2
+	StructuredAppend Mode = 3
+	Byte             Mode = 4
+	ECI              Mode = 7
+	Kanji            Mode = 8
+)
+
+// Segment is a run of data encoded with a single mode. Data holds digits,
+// alphanumeric characters, raw bytes or Shift-JIS pairs according to the
+// mode, ECI segments carry their assignment number in Assignment instead
+// and structured append headers their position and parity
+type Segment struct {
+	Mode       Mode
+	Data       []byte
+	Assignment int
+}
+
+// MakeNumeric returns a numeric segment, digits being limited to 0-9
+func MakeNumeric(digits string) (Segment, error) {
+	for i := 0; i < len(digits); i++ {
+		if digits[i] < '0' || digits[i] > '9' {
+			return Segment{}, errors.New("Numeric segments only hold digits")
+		}
+	}
+	return Segment{Mode: Numeric, Data: []byte(digits)}, nil
+}
+
+// MakeAlphanumeric returns an alphanumeric segment, text being limited to
+// digits, uppercase letters, space and $%*+-./:
+func MakeAlphanumeric(text string) (Segment, error) {
+	for i := 0; i < len(text); i++ {
+		if strings.IndexByte(qrspec.Alphanumeric, text[i]) < 0 {
+			return Segment{}, errors.New("Alphanumeric segments only hold digits, uppercase letters, space and $%*+-./:")
+		}
+	}
+	return Segment{Mode: Alphanumeric, Data: []byte(text)}, nil
+}
+
+// MakeBytes returns a byte segment
+func MakeBytes(data []byte) Segment {
+	return Segment{Mode: Byte, Data: append([]byte(nil), data...)}
+}
+
+// MakeKanji returns a kanji segment from UTF-8 text, whose characters must
+// all be double byte in Shift-JIS
+func MakeKanji(text string) (Segment, error) {
+	sjis, err := japanese.ShiftJIS.NewEncoder().Bytes([]byte(text))
+	if err != nil || len(sjis)%2 != 0 {
+		return Segment{}, errors.New("Kanji segments only hold double byte Shift-JIS characters")
+	}
+	for i := 0; i < len(sjis); i += 2 {
+		if c := int(sjis[i])<<8 | int(sjis[i+1]); !(c >= 0x8140 && c <= 0x9ffc) && !(c >= 0xe040 && c <= 0xebbf) {
+			return Segment{}, errors.New("Kanji segments only hold double byte Shift-JIS characters")
+		}
+	}
+	return Segment{Mode: Kanji, Data: sjis}, nil
+}
+
+// MakeECI returns a segment switching the character set of the following
+// ones to an ECI assignment number
+func MakeECI(assignment int) (Segment, error) {
+	if assignment < 0 || assignment >= 1000000 {
+		return Segment{}, errors.New("ECI assignment number must be below 1000000")
+	}
+	return Segment{Mode: ECI, Assignment: assignment}, nil
+}
+
+// MakeSegments returns a single segment in the most compact mode able to
+// hold the whole payload
+func MakeSegments(payload []byte) []Segment {
+	if s, err := MakeNumeric(string(payload)); err == nil {
+		return []Segment{s}
+	}
+	if s, err := MakeAlphanumeric(string(payload)); err == nil {
+		return []Segment{s}
+	}
+	return []Segment{MakeBytes(payload)}
+}
+
+// length returns the count of characters of a segment
+func (s Segment) length() int {
+	if s.Mode == Kanji {
+		return len(s.Data) / 2
+	}
+	return len(s.Data)
+}
+
+// bits returns the length of a segment in a symbol of a version, or -1 when
+// its character count does not fit in the indicator
+func (s Segment) bits(version int) int {
+	switch s.Mode {
+	case ECI:
+		switch {
+		case s.Assignment < 1<<7:
+			return 4 + 8
+		case s.Assignment < 1<<14:
+			return 4 + 16
+		}
+		return 4 + 24
+	case StructuredAppend:
+		return 4 + 16
+	}
+
+	count := qrspec.CountBits(int(s.Mode), version)
+	if s.length() >= 1<<uint(count) {
+		return -1
+	}
+	return 4 + count + s.dataBits()
+}
+
+// microIndicator returns the Micro QR mode indicator of a segment, or -1
+// for modes Micro QR lacks
+func (s Segment) microIndicator() int {
+	switch s.Mode {
+	case Numeric:
+		return 0
+	case Alphanumeric:
+		return 1
+	case Byte:
+		return 2
+	case Kanji:
+		return 3
+	}
+	return -1
+}
+
+// microBits returns the length of a segment in a Micro QR symbol of a
+// version, or -1 when its mode is not available or its character count does
+// not fit in the indicator
+func (s Segment) microBits(version int) int {
+	indicator := s.microIndicator()
+	if indicator < 0 {
+		return -1
+	}
+	count := qrspec.MicroCountBits(indicator, version)
+	if count == 0 || s.length() >= 1<<uint(count) {
+		return -1
+	}
+	return version - 1 + count + s.dataBits()
+}
+
+// dataBits returns the length of the characters of a segment
+func (s Segment) dataBits() int {
+	n := s.length()
+	switch s.Mode {
+	case Numeric:
+		return n/3*10 + [3]int{0, 4, 7}[n%3]
+	case Alphanumeric:
+		return n/2*11 + n%2*6
+	case Byte:
+		return n * 8
+	case Kanji:
+		return n * 13
+	}
+	return 0
+}
+
+// write appends a segment to a bit stream
+func (s Segment) write(w *bitWriter, version int) {
+	w.write(int(s.Mode), 4)
+	switch s.Mode {
+	case ECI:
+		switch {
+		case s.Assignment < 1<<7:
+			w.write(s.Assignment, 8)
+		case s.Assignment < 1<<14:
+			w.write(2<<14|s.Assignment, 16)
+		default:
+			w.write(6<<21|s.Assignment, 24)
+		}
+		return
+	case StructuredAppend:
+		w.write(int(s.Data[0]), 8)
+		w.write(int(s.Data[1]), 8)
+		return
+	}
+
+	w.write(s.length(), qrspec.CountBits(int(s.Mode), version))
+	s.writeData(w)
+}
+
+// writeMicro appends a segment to the bit stream of a Micro QR symbol
+func (s Segment) writeMicro(w *bitWriter, version int) {
+	indicator := s.microIndicator()
+	w.write(indicator, version-1)
+	w.write(s.length(), qrspec.MicroCountBits(indicator, version))
+	s.writeData(w)
+}
+
+// writeData appends the characters of a segment to a bit stream
+func (s Segment) writeData(w *bitWriter) {
+	switch s.Mode {
+	case Numeric:
+		for i := 0; i < len(s.Data); i += 3 {
+			n := minInt(3, len(s.Data)-i)
+			value := 0
+			for _, c := range s.Data[i : i+n] {
+				value = value*10 + int(c-'0')
+			}
+			w.write(value, [4]int{0, 4, 7, 10}[n])
+		}
+	case Alphanumeric:
+		for i := 0; i+1 < len(s.Data); i += 2 {
+			w.write(alphanumeric(s.Data[i])*45+alphanumeric(s.Data[i+1]), 11)
+		}
+		if len(s.Data)%2 != 0 {
+			w.write(alphanumeric(s.Data[len(s.Data)-1]), 6)
+		}
+	case Byte:
+		for _, b := range s.Data {
+			w.write(int(b), 8)
+		}
+	case Kanji:
+		for i := 0; i+1 < len(s.Data); i += 2 {
+			c := int(s.Data[i])<<8 | int(s.Data[i+1])
+			if c <= 0x9ffc {
+				c -= 0x8140
+			} else {
+				c -= 0xc140
+			}
+			w.write((c>>8)*0xc0+c&0xff, 13)
+		}
+	}
+}
+
+// alphanumeric returns the value of an alphanumeric character
+func alphanumeric(c byte) int {
+	return strings.IndexByte(qrspec.Alphanumeric, c)
+}
+
+// bitWriter appends big endian bit fields to a byte slice
+type bitWriter struct {
+	data []byte
+	bits int
+}
+
+// write appends the n low bits of value
+func (w *bitWriter) write(value int, n int) {
+	for i := n - 1; i >= 0; i-- {
+		if w.bits%8 == 0 {
+			w.data = append(w.data, 0)
+		}
+		if value>>uint(i)&1 != 0 {
+			w.data[w.bits/8] |= 0x80 >> uint(w.bits%8)
+		}
+		w.bits++
+	}
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
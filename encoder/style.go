@@ -0,0 +1,74 @@
+package encoder
+
+import "image/color"
+
+// style holds the rendering parameters of a symbol
+type style struct {
+	moduleSize int
+	quietZone  int
+	dark       color.Color
+	light      color.Color
+
+	// ascii and lightBackground tune terminal rendering
+	ascii           bool
+	lightBackground bool
+}
+
+// RenderOption tunes how a symbol is rendered
+type RenderOption func(*style)
+
+// WithModuleSize sets the side of a module, in pixels or SVG user units, 4
+// by default
+func WithModuleSize(size int) RenderOption {
+	return func(st *style) {
+		st.moduleSize = size
+	}
+}
+
+// WithQuietZone sets the width in modules of the light margin, the one
+// required by the symbol by default
+func WithQuietZone(modules int) RenderOption {
+	return func(st *style) {
+		st.quietZone = modules
+	}
+}
+
+// WithColors sets the colors of dark and light modules, black and white by
+// default. A transparent light color leaves the background undrawn
+func WithColors(dark color.Color, light color.Color) RenderOption {
+	return func(st *style) {
+		st.dark, st.light = dark, light
+	}
+}
+
+// WithASCII renders a symbol for terminals with two characters per module
+// and one line per row, for fonts lacking block elements
+func WithASCII() RenderOption {
+	return func(st *style) {
+		st.ascii = true
+	}
+}
+
+// WithLightBackground renders a symbol for terminals printing dark text on
+// a light background, which draw dark modules with glyphs instead of light
+// ones
+func WithLightBackground() RenderOption {
+	return func(st *style) {
+		st.lightBackground = true
+	}
+}
+
+// newStyle applies options over the defaults of a symbol
+func newStyle(s *Symbol, opts []RenderOption) style {
+	st := style{moduleSize: 4, quietZone: s.QuietZone(), dark: color.Black, light: color.White}
+	for _, opt := range opts {
+		opt(&st)
+	}
+	if st.moduleSize < 1 {
+		st.moduleSize = 1
+	}
+	if st.quietZone < 0 {
+		st.quietZone = 0
+	}
+	return st
+}
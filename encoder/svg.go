@@ -0,0 +1,65 @@
+package encoder
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image/color"
+	"image/png"
+	"io"
+)
+
+// SVG writes the symbol as an SVG document, dark modules being drawn as a
+// single path in a coordinate system of one unit per module
+func (s *Symbol) SVG(w io.Writer, opts ...RenderOption) error {
+	st := newStyle(s, opts)
+	side := s.Size + 2*st.quietZone
+	b := bufio.NewWriter(w)
+
+	fmt.Fprintf(b, `<svg xmlns="http://www.w3.org/2000/svg" version="1.1" viewBox="0 0 %d %d" width="%d" height="%d" shape-rendering="crispEdges">`+"\n",
+		side, side, side*st.moduleSize, side*st.moduleSize)
+	if _, _, _, a := st.light.RGBA(); a != 0 {
+		fmt.Fprintf(b, `<rect width="%d" height="%d"%s/>`+"\n", side, side, svgFill(st.light))
+	}
+
+	// Horizontal runs of dark modules make a rectangle each
+	fmt.Fprintf(b, `<path%s d="`, svgFill(st.dark))
+	for y := 0; y < s.Size; y++ {
+		for x := 0; x < s.Size; x++ {
+			if !s.Dark(x, y) {
+				continue
+			}
+			run := 1
+			for s.Dark(x+run, y) {
+				run++
+			}
+			fmt.Fprintf(b, "M%d %dh%dv1h-%dz", x+st.quietZone, y+st.quietZone, run, run)
+			x += run
+		}
+	}
+	b.WriteString(`"/>` + "\n")
+
+	if s.Logo != nil && !s.logo.Empty() {
+		var logo bytes.Buffer
+		if err := png.Encode(&logo, s.Logo); err != nil {
+			return err
+		}
+		area := s.logo
+		fmt.Fprintf(b, `<image x="%d" y="%d" width="%d" height="%d" href="data:image/png;base64,%s"/>`+"\n",
+			area.Min.X+st.quietZone, area.Min.Y+st.quietZone, area.Dx(), area.Dy(), base64.StdEncoding.EncodeToString(logo.Bytes()))
+	}
+
+	b.WriteString("</svg>\n")
+	return b.Flush()
+}
+
+// svgFill returns the fill attributes of a color
+func svgFill(c color.Color) string {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	fill := fmt.Sprintf(` fill="#%02x%02x%02x"`, n.R, n.G, n.B)
+	if n.A != 0xff {
+		fill += fmt.Sprintf(` fill-opacity="%.3g"`, float64(n.A)/0xff)
+	}
+	return fill
+}
@@ -0,0 +1,52 @@
+package encoder
+
+import (
+	"bufio"
+	"io"
+)
+
+// Terminal writes the symbol as text, each character drawing two rows of
+// modules with Unicode half blocks. Glyphs draw light modules, as most
+// terminals print light text on a dark background
+func (s *Symbol) Terminal(w io.Writer, opts ...RenderOption) error {
+	st := newStyle(s, opts)
+	q := st.quietZone
+	b := bufio.NewWriter(w)
+
+	// inked tells whether the module is drawn with a glyph
+	inked := func(x int, y int) bool {
+		return s.Dark(x, y) == st.lightBackground
+	}
+
+	if st.ascii {
+		for y := -q; y < s.Size+q; y++ {
+			for x := -q; x < s.Size+q; x++ {
+				if inked(x, y) {
+					b.WriteString("##")
+				} else {
+					b.WriteString("  ")
+				}
+			}
+			b.WriteByte('\n')
+		}
+		return b.Flush()
+	}
+
+	for y := -q; y < s.Size+q; y += 2 {
+		for x := -q; x < s.Size+q; x++ {
+			top, bottom := inked(x, y), y+1 < s.Size+q && inked(x, y+1)
+			switch {
+			case top && bottom:
+				b.WriteString("█")
+			case top:
+				b.WriteString("▀")
+			case bottom:
+				b.WriteString("▄")
+			default:
+				b.WriteByte(' ')
+			}
+		}
+		b.WriteByte('\n')
+	}
+	return b.Flush()
+}
@@ -0,0 +1,129 @@
+package goquirc
+
+// Equalize spreads the histogram of images over the whole range of
+// intensities, which restores the contrast of uniformly faded images
+func Equalize() Filter {
+	return func(pix []byte, w int, h int) []byte {
+		var histogram [256]int
+		for _, p := range pix {
+			histogram[p]++
+		}
+		lut := equalization(histogram[:], len(pix))
+
+		out := make([]byte, len(pix))
+		for i, p := range pix {
+			out[i] = lut[p]
+		}
+		return out
+	}
+}
+
+// CLAHE equalizes images by tiles, on a grid of tiles by tiles, clipping
+// each tile histogram at clip times its mean bin count so that noise in flat
+// areas is not amplified (usually 2 to 4), and blends neighbouring tiles. It
+// recovers low contrast qrcodes in unevenly lit scenes
+func CLAHE(tiles int, clip float64) Filter {
+	return func(pix []byte, w int, h int) []byte {
+		nx, ny := minInt(maxInt(tiles, 1), w), minInt(maxInt(tiles, 1), h)
+		luts := make([][256]byte, nx*ny)
+		for ty := 0; ty < ny; ty++ {
+			for tx := 0; tx < nx; tx++ {
+				x0, x1 := tx*w/nx, (tx+1)*w/nx
+				y0, y1 := ty*h/ny, (ty+1)*h/ny
+
+				var histogram [256]int
+				for y := y0; y < y1; y++ {
+					for _, p := range pix[y*w+x0 : y*w+x1] {
+						histogram[p]++
+					}
+				}
+				count := (x1 - x0) * (y1 - y0)
+				if clip > 0 {
+					clipHistogram(histogram[:], int(clip*float64(count)/256)+1)
+				}
+				luts[ty*nx+tx] = equalization(histogram[:], count)
+			}
+		}
+
+		// Each pixel blends the mappings of the four tiles whose centers
+		// surround it
+		out := make([]byte, len(pix))
+		for y := 0; y < h; y++ {
+			ty0, ty1, fy := neighbours(y, h, ny)
+			for x := 0; x < w; x++ {
+				tx0, tx1, fx := neighbours(x, w, nx)
+				p := pix[y*w+x]
+				top := (1-fx)*float64(luts[ty0*nx+tx0][p]) + fx*float64(luts[ty0*nx+tx1][p])
+				bottom := (1-fx)*float64(luts[ty1*nx+tx0][p]) + fx*float64(luts[ty1*nx+tx1][p])
+				out[y*w+x] = byte((1-fy)*top + fy*bottom + 0.5)
+			}
+		}
+		return out
+	}
+}
+
+// equalization returns the mapping of intensities flattening a histogram of
+// count pixels
+func equalization(histogram []int, count int) [256]byte {
+	var lut [256]byte
+	if count == 0 {
+		return lut
+	}
+	cumulated := 0
+	for v, n := range histogram {
+		cumulated += n
+		lut[v] = byte(cumulated * 255 / count)
+	}
+	return lut
+}
+
+// clipHistogram caps the bins of a histogram at limit and spreads the excess
+// evenly over all bins
+func clipHistogram(histogram []int, limit int) {
+	excess := 0
+	for v, n := range histogram {
+		if n > limit {
+			excess += n - limit
+			histogram[v] = limit
+		}
+	}
+	for v := range histogram {
+		histogram[v] += excess / len(histogram)
+	}
+	for v := 0; v < excess%len(histogram); v++ {
+		histogram[v]++
+	}
+}
+
+// neighbours returns the indexes of the two tiles whose centers surround a
+// coordinate along an axis of length split into n tiles, and the weight of
+// the second one
+func neighbours(c int, length int, n int) (int, int, float64) {
+	position := (float64(c)+0.5)*float64(n)/float64(length) - 0.5
+	if position <= 0 {
+		return 0, 0, 0
+	}
+	if position >= float64(n-1) {
+		return n - 1, n - 1, 0
+	}
+	first := int(position)
+	return first, first + 1, position - float64(first)
+}
+
+// Flatten divides images by their background, estimated as the mean of the
+// window of radius pixels around each pixel, which evens out strong lighting
+// gradients. radius should exceed the size of the largest qrcode expected
+func Flatten(radius int) Filter {
+	return func(pix []byte, w int, h int) []byte {
+		sums, _ := integral(pix, w, h, false)
+		out := make([]byte, len(pix))
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				sum, n := boxSum(sums, w, h, x, y, radius)
+				background := maxInt(int(sum)/n, 1)
+				out[y*w+x] = byte(minInt(int(pix[y*w+x])*255/background, 255))
+			}
+		}
+		return out
+	}
+}
@@ -0,0 +1,18 @@
+package goquirc
+
+import "errors"
+
+// Errors reported when decoding an extracted qrcode, they can be told apart
+// with errors.Is
+var (
+	ErrInvalidGridSize = errors.New("Invalid grid size")
+	ErrInvalidVersion  = errors.New("Invalid version")
+	ErrFormatECC       = errors.New("Format data ECC failure")
+	ErrDataECC         = errors.New("ECC failure")
+	ErrUnknownDataType = errors.New("Unknown data type")
+	ErrDataOverflow    = errors.New("Data overflow")
+	ErrDataUnderflow   = errors.New("Data underflow")
+)
+
+// ErrNotFound is returned when no qrcode could be decoded from an image
+var ErrNotFound = errors.New("No qrcode found")
@@ -0,0 +1,159 @@
+package goquirc
+
+import "math"
+
+// Filter transforms a tightly packed grayscale image of w by h pixels before
+// identification and returns the result, leaving pix untouched since it may
+// be the caller's buffer
+type Filter func(pix []byte, w int, h int) []byte
+
+// Gamma corrects the exposure of images by raising their normalized
+// intensities to the power 1/g, a g above one brightening underexposed
+// frames and below one darkening overexposed ones. A g not above zero leaves
+// images unchanged
+func Gamma(g float64) Filter {
+	if g <= 0 {
+		g = 1
+	}
+	var lut [256]byte
+	for v := range lut {
+		lut[v] = byte(255*math.Pow(float64(v)/255, 1/g) + 0.5)
+	}
+	return func(pix []byte, w int, h int) []byte {
+		out := make([]byte, len(pix))
+		for i, p := range pix {
+			out[i] = lut[p]
+		}
+		return out
+	}
+}
+
+// Otsu binarizes images with the global threshold maximizing the variance
+// between dark and light pixels, which suits evenly lit images
+func Otsu() Filter {
+	return func(pix []byte, w int, h int) []byte {
+		var histogram [256]int
+		for _, p := range pix {
+			histogram[p]++
+		}
+
+		total, sum := len(pix), 0
+		for v, n := range histogram {
+			sum += v * n
+		}
+		threshold, best := 0, -1.0
+		background, weighted := 0, 0
+		for v, n := range histogram {
+			if background += n; background == 0 {
+				continue
+			}
+			foreground := total - background
+			if foreground == 0 {
+				break
+			}
+			weighted += v * n
+			mb := float64(weighted) / float64(background)
+			mf := float64(sum-weighted) / float64(foreground)
+			if variance := float64(background) * float64(foreground) * (mb - mf) * (mb - mf); variance > best {
+				threshold, best = v, variance
+			}
+		}
+
+		out := make([]byte, len(pix))
+		for i, p := range pix {
+			if int(p) > threshold {
+				out[i] = 255
+			}
+		}
+		return out
+	}
+}
+
+// AdaptiveMean binarizes images against the mean of the window of radius
+// pixels around each pixel lowered by offset, which copes with shadows and
+// gradients
+func AdaptiveMean(radius int, offset int) Filter {
+	return func(pix []byte, w int, h int) []byte {
+		sums, _ := integral(pix, w, h, false)
+		out := make([]byte, len(pix))
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				sum, n := boxSum(sums, w, h, x, y, radius)
+				if int(pix[y*w+x])*n > int(sum)-offset*n {
+					out[y*w+x] = 255
+				}
+			}
+		}
+		return out
+	}
+}
+
+// Sauvola binarizes images against a local threshold derived from the mean
+// and standard deviation of the window of radius pixels around each pixel,
+// k weighting the deviation (usually 0.2 to 0.5). It is more robust than
+// AdaptiveMean on low contrast areas
+func Sauvola(radius int, k float64) Filter {
+	return func(pix []byte, w int, h int) []byte {
+		sums, squares := integral(pix, w, h, true)
+		out := make([]byte, len(pix))
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				sum, n := boxSum(sums, w, h, x, y, radius)
+				square, _ := boxSum(squares, w, h, x, y, radius)
+				mean := float64(sum) / float64(n)
+				deviation := math.Sqrt(math.Max(0, float64(square)/float64(n)-mean*mean))
+				if float64(pix[y*w+x]) > mean*(1+k*(deviation/128-1)) {
+					out[y*w+x] = 255
+				}
+			}
+		}
+		return out
+	}
+}
+
+// integral returns the summed area tables of pixels and of their squares,
+// with an extra leading row and column of zeros
+func integral(pix []byte, w int, h int, squared bool) ([]uint64, []uint64) {
+	sums := make([]uint64, (w+1)*(h+1))
+	var squares []uint64
+	if squared {
+		squares = make([]uint64, (w+1)*(h+1))
+	}
+	for y := 0; y < h; y++ {
+		var row, rowSquares uint64
+		for x := 0; x < w; x++ {
+			p := uint64(pix[y*w+x])
+			row += p
+			sums[(y+1)*(w+1)+x+1] = sums[y*(w+1)+x+1] + row
+			if squared {
+				rowSquares += p * p
+				squares[(y+1)*(w+1)+x+1] = squares[y*(w+1)+x+1] + rowSquares
+			}
+		}
+	}
+	return sums, squares
+}
+
+// boxSum returns the sum of a summed area table over the window of radius
+// pixels around a pixel, clipped to the image, and the number of pixels in it
+func boxSum(table []uint64, w int, h int, x int, y int, radius int) (uint64, int) {
+	x0, y0 := maxInt(x-radius, 0), maxInt(y-radius, 0)
+	x1, y1 := minInt(x+radius+1, w), minInt(y+radius+1, h)
+	stride := w + 1
+	sum := table[y1*stride+x1] + table[y0*stride+x0] - table[y0*stride+x1] - table[y1*stride+x0]
+	return sum, (x1 - x0) * (y1 - y0)
+}
+
+func maxInt(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
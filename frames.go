@@ -0,0 +1,109 @@
+//go:build go1.21
+
+package goquirc
+
+// #include "decode.h"
+import "C"
+import (
+	"context"
+	"errors"
+	"runtime"
+	"unsafe"
+)
+
+// frameCapacity is the number of processings decoded by a single cgo call of
+// DecodeFrames
+const frameCapacity = 64
+
+// DecodeFrames reveals the qrcodes of grayscale frames in frame order, with
+// a single cgo call for as many frames as their qrcodes fit in a shared
+// result buffer. Options needing work on the Go side between identification
+// and decoding, such as preprocessing, retries, deadlines or minimum sizes,
+// fall back to a reveal per frame
+func (d *Decoder) DecodeFrames(frames []Frame, opts ...Option) ([]FrameResult, error) {
+	if !d.qr.allocated() {
+		return nil, errors.New("Decoder is closed")
+	}
+
+	cfg := newConfig(opts)
+	results := make([]FrameResult, len(frames))
+	if cfg.reloads() || cfg.binarized || cfg.decodeWorkers > 1 || cfg.budget > 0 || cfg.observe != nil || !cfg.decodesAtOnce() || !validFrames(frames) {
+		for i, frame := range frames {
+			results[i].Frame = frame
+			results[i].Result, results[i].Err = d.Reveal(&frame.Image, frame.Width, frame.Height, opts...)
+		}
+		return results, nil
+	}
+
+	// Frames are handed to C in an array of pointers to pinned images
+	var pinner runtime.Pinner
+	defer pinner.Unpin()
+	cframes := make([]C.struct_goquirc_frame, len(frames))
+	for i, frame := range frames {
+		cframes[i].w, cframes[i].h = C.int(frame.Width), C.int(frame.Height)
+		if len(frame.Image) > 0 {
+			pinner.Pin(&frame.Image[0])
+			cframes[i].image = (*C.uint8_t)(unsafe.Pointer(&frame.Image[0]))
+			cframes[i].size = C.size_t(len(frame.Image))
+		}
+	}
+
+	d.frames += uint64(len(frames))
+	if len(d.qr.results) < frameCapacity {
+		d.qr.results = make(decodeResults, frameCapacity)
+	}
+	for done := 0; done < len(frames); {
+		n := int(C.goquirc_decode_frames(d.qr.qrStruct, &cframes[done], C.int(len(frames)-done),
+			C.int(cfg.maxCodes), &d.qr.results[0], C.int(len(d.qr.results))))
+		for i := done; i < done+n; i++ {
+			results[i] = d.frameResult(frames[i], &cframes[i], cfg)
+		}
+		done += n
+	}
+	return results, nil
+}
+
+// validFrames tells whether quirc can allocate buffers for all frames,
+// invalid ones being left to Reveal for their error
+func validFrames(frames []Frame) bool {
+	for _, frame := range frames {
+		if checkDimensions(frame.Width, frame.Height) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// frameResult converts the processings of a frame decoded by
+// goquirc_decode_frames, decoding those which did not fit in the result
+// buffer when the frame is the last one processed
+func (d *Decoder) frameResult(frame Frame, cframe *C.struct_goquirc_frame, cfg *config) FrameResult {
+	result := FrameResult{Frame: frame}
+	if cframe.err != 0 {
+		// The buffer dimensions are unknown after a failed resize
+		d.qr.width, d.qr.height = -1, -1
+		result.Err = errors.New("Failed to allocate video memory")
+		return result
+	}
+	d.qr.width, d.qr.height = frame.Width, frame.Height
+
+	first, decoded := int(cframe.first), int(cframe.decoded)
+	found := int(cframe.found)
+	limit := found
+	if cfg.maxCodes > 0 && cfg.maxCodes < limit {
+		limit = cfg.maxCodes
+	}
+
+	decodes := append(decodeResults(nil), d.qr.results[first:first+decoded]...)
+	if decoded < limit {
+		rest := make(decodeResults, limit-decoded)
+		C.goquirc_decode_range(d.qr.qrStruct, C.int(decoded), C.int(len(rest)), &rest[0])
+		decodes = append(decodes, rest...)
+	}
+
+	outcomes := d.qr.outcomes(decodes, cfg, false)
+	result.Result, result.Err = d.qr.report(context.Background(), cfg, found, limit, func(i int) outcome {
+		return outcomes[i]
+	})
+	return result
+}
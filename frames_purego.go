@@ -0,0 +1,21 @@
+//go:build !cgo
+
+package goquirc
+
+import "errors"
+
+// DecodeFrames reveals the qrcodes of grayscale frames in frame order, the
+// pure Go engine revealing them one after the other as there is no cgo call
+// to spare
+func (d *Decoder) DecodeFrames(frames []Frame, opts ...Option) ([]FrameResult, error) {
+	if !d.qr.allocated() {
+		return nil, errors.New("Decoder is closed")
+	}
+
+	results := make([]FrameResult, len(frames))
+	for i, frame := range frames {
+		results[i].Frame = frame
+		results[i].Result, results[i].Err = d.Reveal(&frame.Image, frame.Width, frame.Height, opts...)
+	}
+	return results, nil
+}
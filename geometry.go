@@ -0,0 +1,181 @@
+package goquirc
+
+import (
+	"image"
+	"math"
+)
+
+// Rotation returns the clockwise angle in degrees, within [0, 360), between
+// the image horizontal axis and the top edge of the qrcode, averaged with its
+// bottom edge
+func (code QRcode) Rotation() float64 {
+	c := code.Corners
+	dx := float64(c[1].X - c[0].X + c[2].X - c[3].X)
+	dy := float64(c[1].Y - c[0].Y + c[2].Y - c[3].Y)
+
+	angle := math.Atan2(dy, dx) * 180 / math.Pi
+	if angle < 0 {
+		angle += 360
+	}
+	return angle
+}
+
+// Orientation returns the rotation rounded to the closest quarter turn, one
+// of 0, 90, 180 or 270
+func (code QRcode) Orientation() int {
+	return int(math.Floor(code.Rotation()/90+0.5)) % 4 * 90
+}
+
+// Homography is a 3x3 perspective transform, applied to column vectors
+type Homography [3][3]float64
+
+// Map transforms a point and returns its projected coordinates
+func (m Homography) Map(x float64, y float64) (float64, float64) {
+	w := m[2][0]*x + m[2][1]*y + m[2][2]
+	return (m[0][0]*x + m[0][1]*y + m[0][2]) / w, (m[1][0]*x + m[1][1]*y + m[1][2]) / w
+}
+
+// Homography returns the perspective transform mapping module coordinates to
+// image pixels, where the module at column x and row y covers the square from
+// (x, y) to (x+1, y+1), built from the corners of the qrcode
+func (code QRcode) Homography() Homography {
+	x0, y0 := float64(code.Corners[0].X), float64(code.Corners[0].Y)
+	x1, y1 := float64(code.Corners[1].X), float64(code.Corners[1].Y)
+	x2, y2 := float64(code.Corners[2].X), float64(code.Corners[2].Y)
+	x3, y3 := float64(code.Corners[3].X), float64(code.Corners[3].Y)
+
+	// Map the unit square onto the corners, then scale it to the grid size
+	var a, b, d, e, g, h float64
+	dx3, dy3 := x0-x1+x2-x3, y0-y1+y2-y3
+	if den := (x1-x2)*(y3-y2) - (x3-x2)*(y1-y2); (dx3 == 0 && dy3 == 0) || den == 0 {
+		a, b, d, e = x1-x0, x3-x0, y1-y0, y3-y0
+	} else {
+		g = (dx3*(y3-y2) - (x3-x2)*dy3) / den
+		h = ((x1-x2)*dy3 - dx3*(y1-y2)) / den
+		a, b = x1-x0+g*x1, x3-x0+h*x3
+		d, e = y1-y0+g*y1, y3-y0+h*y3
+	}
+
+	s := float64(code.Size)
+	if s == 0 {
+		s = 1
+	}
+	return Homography{
+		{a / s, b / s, x0},
+		{d / s, e / s, y0},
+		{g / s, h / s, 1},
+	}
+}
+
+// Bounds returns the smallest rectangle containing the four corners
+func (code QRcode) Bounds() image.Rectangle {
+	first := image.Pt(code.Corners[0].X, code.Corners[0].Y)
+	r := image.Rectangle{Min: first, Max: first}
+	for _, c := range code.Corners[1:] {
+		if c.X < r.Min.X {
+			r.Min.X = c.X
+		}
+		if c.Y < r.Min.Y {
+			r.Min.Y = c.Y
+		}
+		if c.X > r.Max.X {
+			r.Max.X = c.X
+		}
+		if c.Y > r.Max.Y {
+			r.Max.Y = c.Y
+		}
+	}
+	// Max is exclusive
+	return image.Rectangle{Min: r.Min, Max: r.Max.Add(image.Pt(1, 1))}
+}
+
+// Center returns the intersection of the diagonals of the qrcode, which is the
+// projection of its center whatever the perspective
+func (code QRcode) Center() Position {
+	x, y := code.Homography().Map(float64(code.Size)/2, float64(code.Size)/2)
+	return Position{int(math.Floor(x + 0.5)), int(math.Floor(y + 0.5))}
+}
+
+// Area returns the area in square pixels of the quadrilateral bounded by the
+// four corners
+func (code QRcode) Area() float64 {
+	var sum int
+	for i, c := range code.Corners {
+		next := code.Corners[(i+1)%4]
+		sum += c.X*next.Y - next.X*c.Y
+	}
+	return math.Abs(float64(sum)) / 2
+}
+
+// shortestSide returns the length in pixels of the shortest side of a
+// quadrilateral
+func shortestSide(corners [4]Position) float64 {
+	shortest := math.Inf(1)
+	for i, c := range corners {
+		next := corners[(i+1)%4]
+		shortest = math.Min(shortest, math.Hypot(float64(next.X-c.X), float64(next.Y-c.Y)))
+	}
+	return shortest
+}
+
+// vertex is a position with fractional coordinates
+type vertex struct {
+	x, y float64
+}
+
+// polygon returns the corners of a quadrilateral as vertices in
+// counterclockwise order of the image plane, whatever their original order
+func polygon(corners [4]Position) []vertex {
+	poly := make([]vertex, 4)
+	for i, c := range corners {
+		poly[i] = vertex{float64(c.X), float64(c.Y)}
+	}
+	if signedArea(poly) < 0 {
+		poly[1], poly[3] = poly[3], poly[1]
+	}
+	return poly
+}
+
+// signedArea returns the area of a polygon, positive when its vertices turn
+// counterclockwise
+func signedArea(poly []vertex) float64 {
+	var sum float64
+	for i, v := range poly {
+		next := poly[(i+1)%len(poly)]
+		sum += v.x*next.y - next.x*v.y
+	}
+	return sum / 2
+}
+
+// intersectionArea returns the area shared by two convex quadrilaterals,
+// clipping the first one by each edge of the second one
+func intersectionArea(a [4]Position, b [4]Position) float64 {
+	poly, clip := polygon(a), polygon(b)
+	for i, c0 := range clip {
+		if len(poly) == 0 {
+			return 0
+		}
+		c1 := clip[(i+1)%len(clip)]
+		side := func(v vertex) float64 {
+			return (c1.x-c0.x)*(v.y-c0.y) - (c1.y-c0.y)*(v.x-c0.x)
+		}
+
+		var kept []vertex
+		for j, p := range poly {
+			q := poly[(j+1)%len(poly)]
+			sp, sq := side(p), side(q)
+			if sp >= 0 {
+				kept = append(kept, p)
+			}
+			if (sp >= 0) != (sq >= 0) {
+				t := sp / (sp - sq)
+				kept = append(kept, vertex{p.x + t*(q.x-p.x), p.y + t*(q.y-p.y)})
+			}
+		}
+		poly = kept
+	}
+	if len(poly) < 3 {
+		return 0
+	}
+	return math.Abs(signedArea(poly))
+}
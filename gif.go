@@ -0,0 +1,59 @@
+package goquirc
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"io"
+)
+
+// DecodeGIF reveals the qrcodes of every frame of an animated GIF, each frame
+// being composited over the previous ones according to their disposal method.
+// Transparent pixels and the areas no frame covers are read as white, like
+// Luminance reads any transparency. Results are indexed by frame
+func DecodeGIF(r io.Reader, opts ...Option) ([]Result, error) {
+	g, err := gif.DecodeAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	d, err := NewDecoder()
+	if err != nil {
+		return nil, err
+	}
+	defer d.Close()
+
+	var background color.Color = color.Transparent
+	if palette, ok := g.Config.ColorModel.(color.Palette); ok && int(g.BackgroundIndex) < len(palette) {
+		background = palette[g.BackgroundIndex]
+	}
+
+	canvas := image.NewRGBA(image.Rect(0, 0, g.Config.Width, g.Config.Height))
+	var previous *image.RGBA
+	results := make([]Result, len(g.Image))
+	for i, frame := range g.Image {
+		disposal := byte(0)
+		if i < len(g.Disposal) {
+			disposal = g.Disposal[i]
+		}
+		if disposal == gif.DisposalPrevious {
+			previous = image.NewRGBA(canvas.Rect)
+			copy(previous.Pix, canvas.Pix)
+		}
+
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+		if results[i], err = d.RevealImage(canvas, opts...); err != nil {
+			return results[:i], err
+		}
+
+		switch disposal {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, frame.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			copy(canvas.Pix, previous.Pix)
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,46 @@
+package goquirc_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"testing"
+
+	"github.com/quaresc/goquirc"
+	"github.com/quaresc/goquirc/encoder"
+)
+
+func TestDecodeGIFTransparentBackground(t *testing.T) {
+	symbol, err := encoder.Encode([]byte("animated"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	drawn := symbol.Image(encoder.WithModuleSize(moduleSize), encoder.WithColors(color.Black, color.Transparent)).(*image.Paletted)
+
+	// The frame leaves a margin of the canvas uncovered
+	frame := image.NewPaletted(drawn.Bounds().Add(image.Pt(10, 10)), color.Palette{color.Transparent, color.Black})
+	copy(frame.Pix, drawn.Pix)
+	var buf bytes.Buffer
+	err = gif.EncodeAll(&buf, &gif.GIF{
+		Image:    []*image.Paletted{frame},
+		Delay:    []int{0},
+		Disposal: []byte{gif.DisposalBackground},
+		Config: image.Config{
+			ColorModel: frame.Palette,
+			Width:      frame.Rect.Max.X + 10,
+			Height:     frame.Rect.Max.Y + 10,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := goquirc.DecodeGIF(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || len(results[0].Code) != 1 || results[0].Code[0].Text() != "animated" {
+		t.Fatalf("decoded %v, want the animated qrcode", results)
+	}
+}
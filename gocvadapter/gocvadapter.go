@@ -0,0 +1,95 @@
+// Package gocvadapter feeds gocv.Mat frames to goquirc, so that vision
+// pipelines built on gocv can reveal the qrcodes of their frames without
+// going through image.Image. It needs OpenCV, like gocv itself
+//
+// A single channel CV_8UC1 mat reaches the decoder without any copy on the Go
+// side, and a CV_8UC3 mat, in the BGR order of OpenCV, with a single
+// conversion to luminance:
+//
+//	d, err := goquirc.NewDecoder()
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	defer d.Close()
+//
+//	frame := gocv.NewMat()
+//	defer frame.Close()
+//	for webcam.Read(&frame) {
+//		result, err := gocvadapter.Reveal(ctx, d, frame)
+//		...
+//	}
+package gocvadapter
+
+import (
+	"context"
+	"errors"
+
+	"gocv.io/x/gocv"
+
+	"github.com/quaresc/goquirc"
+)
+
+// Gray returns the luminance of a CV_8UC1 or CV_8UC3 mat along with its
+// dimensions, ready for Reveal. The pixels of a continuous CV_8UC1 mat are
+// returned without copy and are only valid until the mat is closed, the
+// others being copied into a new buffer
+func Gray(mat gocv.Mat) ([]byte, int, int, error) {
+	if mat.Empty() {
+		return nil, 0, 0, errors.New("Empty mat")
+	}
+	w, h := mat.Cols(), mat.Rows()
+
+	kind := mat.Type()
+	if kind != gocv.MatTypeCV8UC1 && kind != gocv.MatTypeCV8UC3 {
+		return nil, 0, 0, errors.New("Unsupported mat type, CV_8UC1 or CV_8UC3 expected")
+	}
+
+	// DataPtrUint8 needs continuous rows, which regions of interest lack
+	// until they are cloned
+	cloned := !mat.IsContinuous()
+	if cloned {
+		mat = mat.Clone()
+		defer mat.Close()
+	}
+	pix, err := mat.DataPtrUint8()
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	if kind == gocv.MatTypeCV8UC3 {
+		gray, err := bgrToGray(pix, w, h)
+		return gray, w, h, err
+	}
+	if len(pix) < w*h {
+		return nil, 0, 0, errors.New("Mat buffer is too short")
+	}
+	if cloned {
+		return append([]byte(nil), pix[:w*h]...), w, h, nil
+	}
+	return pix[:w*h], w, h, nil
+}
+
+// Reveal counts and decodes the qrcodes of a CV_8UC1 or CV_8UC3 mat with r,
+// which may be a Decoder reused from frame to frame or a Pool
+func Reveal(ctx context.Context, r goquirc.Revealer, mat gocv.Mat, opts ...goquirc.Option) (goquirc.Result, error) {
+	gray, w, h, err := Gray(mat)
+	if err != nil {
+		return goquirc.Result{}, err
+	}
+	return r.RevealContext(ctx, &gray, w, h, opts...)
+}
+
+// bgrToGray converts the continuous rows of 3 bytes per pixel BGR into a
+// tightly packed luminance plane, with the fixed point weights of
+// color.GrayModel
+func bgrToGray(pix []byte, w int, h int) ([]byte, error) {
+	if len(pix) < w*h*3 {
+		return nil, errors.New("Mat buffer is too short")
+	}
+	gray := make([]byte, w*h)
+	for i := range gray {
+		b, g, r := uint32(pix[i*3]), uint32(pix[i*3+1]), uint32(pix[i*3+2])
+		gray[i] = byte((19595*r + 38470*g + 7471*b + 1<<15) >> 16)
+	}
+	return gray, nil
+}
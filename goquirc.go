@@ -2,21 +2,25 @@
 // for fast qrcode processing
 package goquirc
 
-// #cgo LDFLAGS: -Lquirc -lquirc -lm
-// #cgo CFLAGS: -Iquirc/lib -O3 -DQUIRC_MAX_REGIONS=65534 -fPIC
-// #include <quirc.h>
-// #include <stdio.h>
-import "C"
 import (
+	"context"
 	"errors"
-	"unsafe"
+	"sync"
+	"time"
 )
 
 // Processing represents all informations needed by quirc to fully work
 type Processing struct {
-	qrStruct *C.struct_quirc
-	code     C.struct_quirc_code
-	data     C.struct_quirc_data
+	// engine holds the quirc context, in C or in pure Go when cgo is
+	// disabled
+	engine
+
+	// width and height are the dimensions of the source image buffer
+	width  int
+	height int
+
+	// regions is the capacity quirc was allocated with
+	regions RegionCapacity
 }
 
 // Position describes a location in the input image buffer
@@ -30,138 +34,446 @@ type QRcode struct {
 	Corners       [4]Position
 	Size          int
 	Version       int
-	ECCLevel      int
+	ECCLevel      ECCLevel
 	Mask          int
-	DataType      int
-	Payload       string
+	DataType      DataType
+	Payload       []byte
 	PayloadLength int
+	ECI           int
+	Append        *StructuredAppend
+	Inverted      bool
+	Mirrored      bool
+
+	cells []byte
+}
+
+// Text returns the payload as an UTF-8 string, transcoded from the character
+// set announced by its ECI header or from Shift-JIS in Kanji mode
+func (code QRcode) Text() string {
+	return transcode(code.Payload, code.ECI, code.DataType)
+}
+
+// Modules returns the grid of modules sampled from the image, indexed by row
+// then column, where true stands for a dark module
+func (code QRcode) Modules() [][]bool {
+	modules := make([][]bool, code.Size)
+	for y := range modules {
+		modules[y] = make([]bool, code.Size)
+		for x := range modules[y] {
+			i := y*code.Size + x
+			modules[y][x] = i>>3 < len(code.cells) && code.cells[i>>3]>>uint(i&7)&1 != 0
+		}
+	}
+	return modules
 }
 
-// Result contains all informations after a reveal process
+// Result contains all informations after a reveal process, Profile names
+// the profile which revealed it when profiles are used
 type Result struct {
-	Found  int
-	Usable int
-	Code   []QRcode
+	Found    int
+	Usable   int
+	Code     []QRcode
+	Failures []DecodeFailure
+	Messages []Message
+	Partial  bool
+	Profile  string
+}
+
+// DecodeFailure describes a processing which was found but could not be decoded
+type DecodeFailure struct {
+	Index   int
+	Corners [4]Position
+	Err     error
+
+	// frame holds the corners in the coordinates of the image given to quirc
+	frame [4]Position
+}
+
+// Error returns the message of the underlying decode error
+func (f DecodeFailure) Error() string {
+	return f.Err.Error()
 }
 
-// Version provides current version of quirc
-func (qr *Processing) Version() string {
-	return C.GoString(C.quirc_version())
+// Unwrap returns the underlying decode error
+func (f DecodeFailure) Unwrap() error {
+	return f.Err
 }
 
-// Create allocates memory for library usage
-func (qr *Processing) Create() error {
-	if qr.qrStruct = C.quirc_new(); qr.qrStruct == nil {
-		return errors.New("Failed to allocate memory")
+// maxPixels is the largest source image quirc can handle, as it indexes its
+// buffers with C ints
+const maxPixels = 1<<31 - 1
+
+// checkDimensions verifies quirc can allocate a w by h source image
+func checkDimensions(w int, h int) error {
+	if w < 0 || h < 0 {
+		return errors.New("Image dimensions must not be negative")
+	}
+	if h > 0 && w > maxPixels/h {
+		return errors.New("Image dimensions are too large")
 	}
 	return nil
 }
 
-// Destroy frees memory after library usage
-func (qr *Processing) Destroy() {
-	C.quirc_destroy(qr.qrStruct)
+// end runs identification on the loaded image
+func (qr *Processing) end(cfg *config) {
+	if cfg.binarized {
+		qr.EndBinarized()
+		return
+	}
+	qr.End()
 }
 
-// Resize allocates memory for source image buffer
-func (qr *Processing) Resize(w int, h int) error {
-	if C.quirc_resize(qr.qrStruct, C.int(w), C.int(h)) == -1 {
-		return errors.New("Failed to allocate video memory")
+// invert turns the loaded source image into its negative
+func (qr *Processing) invert() {
+	buffer, _, _ := qr.buffer()
+	for i := range buffer {
+		buffer[i] = 255 - buffer[i]
 	}
-	return nil
 }
 
-// Count returns the count of all Processings detected
-func (qr *Processing) Count() int {
-	return int(C.quirc_count(qr.qrStruct))
+// Reveal allows to count all found processings by providing a source image with
+// its dimensions and returns an error if an allocation went wrong
+func (qr *Processing) Reveal(image *[]byte, w int, h int, opts ...Option) (Result, error) {
+	return qr.RevealContext(context.Background(), image, w, h, opts...)
 }
 
-// Extract allows to work on a specific processing
-func (qr *Processing) Extract(index int) {
-	C.quirc_extract(qr.qrStruct, C.int(index), &qr.code)
+// RevealContext works like Reveal but stops as soon as ctx is done, checking it
+// between identification, extraction and decoding of each processing. The
+// processings decoded so far are returned along with the context error
+func (qr *Processing) RevealContext(ctx context.Context, image *[]byte, w int, h int, opts ...Option) (Result, error) {
+	if err := qr.Create(); err != nil {
+		return Result{}, err
+	}
+	defer qr.Destroy()
+
+	return qr.reveal(ctx, image, w, h, newConfig(opts))
 }
 
-// Decode gives informations from previously extracted processing
-func (qr *Processing) Decode() error {
-	if decodeError := C.quirc_decode(&qr.code, &qr.data); decodeError != C.QUIRC_SUCCESS {
-		return errors.New(C.GoString(C.quirc_strerror(decodeError)))
+// RevealFunc works like Reveal but hands each processing to fn as soon as it
+// is decoded instead of gathering them, it stops when fn returns false
+func (qr *Processing) RevealFunc(image *[]byte, w int, h int, fn func(QRcode) bool, opts ...Option) error {
+	if err := qr.Create(); err != nil {
+		return err
 	}
-	return nil
+	defer qr.Destroy()
+
+	cfg := newConfig(opts)
+	cfg.visit = fn
+	_, err := qr.reveal(context.Background(), image, w, h, cfg)
+	return err
 }
 
-// Load permits to load a byte array (source image) for further detection work
-func (qr *Processing) Load(image *[]byte) {
-	var w C.int
-	var h C.int
+// RevealFirst works like Reveal but stops at the first processing successfully
+// decoded and returns it, or ErrNotFound when none could be decoded
+func (qr *Processing) RevealFirst(image *[]byte, w int, h int, opts ...Option) (QRcode, error) {
+	if err := qr.Create(); err != nil {
+		return QRcode{}, err
+	}
+	defer qr.Destroy()
+
+	return qr.revealFirst(context.Background(), image, w, h, newConfig(opts))
+}
+
+// revealFirst runs a detection process stopping at the first decoded processing
+func (qr *Processing) revealFirst(ctx context.Context, image *[]byte, w int, h int, cfg *config) (QRcode, error) {
+	cfg.first = true
+	result, err := qr.reveal(ctx, image, w, h, cfg)
+	if err != nil {
+		return QRcode{}, err
+	}
+	if len(result.Code) == 0 {
+		return QRcode{}, ErrNotFound
+	}
+	return result.Code[0], nil
+}
 
-	data := C.quirc_begin(qr.qrStruct, &w, &h)
+// reveal runs the whole detection process on an already created context
+func (qr *Processing) reveal(ctx context.Context, image *[]byte, w int, h int, cfg *config) (Result, error) {
+	if err := ctx.Err(); err != nil {
+		return Result{}, err
+	}
+	cfg.start()
 
-	indexableData := (*[1 << 30]C.uint8_t)(unsafe.Pointer(data))
+	if len(cfg.profiles) > 0 {
+		return qr.profiled(ctx, image, w, h, cfg)
+	}
 
-	var i C.int
-	var imageSize C.int
-	imageSize = w*h - 1
-	for i = 0; i < imageSize; i++ {
-		(*indexableData)[i] = *(*C.uint8_t)(unsafe.Pointer(&(*image)[i]))
+	if !cfg.roi.Empty() {
+		crop, roi, err := cropROI(*image, w, h, cfg.roi)
+		if err != nil {
+			return Result{}, err
+		}
+		image, w, h = &crop, roi.Dx(), roi.Dy()
+		cfg = cfg.mapped(func(p Position) Position {
+			return Position{p.X + roi.Min.X, p.Y + roi.Min.Y}
+		})
 	}
+
+	if cfg.maxDimension > 0 && (w > cfg.maxDimension || h > cfg.maxDimension) {
+		scaled, sw, sh := downscale(*image, w, h, cfg.maxDimension)
+		fw, fh := w, h
+		image, w, h = &scaled, sw, sh
+		cfg = cfg.mapped(func(p Position) Position {
+			return Position{p.X * fw / sw, p.Y * fh / sh}
+		})
+	}
+
+	for _, filter := range cfg.filters {
+		filtered := filter(*image, w, h)
+		image = &filtered
+	}
+
+	if cfg.rotations {
+		return qr.rotated(ctx, image, w, h, cfg)
+	}
+
+	return qr.search(ctx, image, w, h, cfg)
 }
 
-// End announces detection end
-func (qr *Processing) End() {
-	C.quirc_end(qr.qrStruct)
+// search runs a detection at native resolution, or over the levels of the
+// pyramid
+func (qr *Processing) search(ctx context.Context, image *[]byte, w int, h int, cfg *config) (Result, error) {
+	if len(cfg.pyramid) > 0 {
+		return qr.pyramid(ctx, image, w, h, cfg)
+	}
+
+	return qr.scan(ctx, image, w, h, cfg)
 }
 
-// Reveal allows to count all found processings by providing a source image with
-// its dimensions and returns an error if an allocation went wrong
-func (qr *Processing) Reveal(image *[]byte, w int, h int) (Result, error) {
-	var result Result
-	var err error
+// scan runs a detection over the whole image, or over each of its tiles
+func (qr *Processing) scan(ctx context.Context, image *[]byte, w int, h int, cfg *config) (Result, error) {
+	if cfg.tileSize > 0 && (w > cfg.tileSize || h > cfg.tileSize) {
+		return qr.tiled(ctx, image, w, h, cfg)
+	}
 
-	if err = qr.Create(); err != nil {
-		return result, err
+	return qr.detect(ctx, image, w, h, cfg)
+}
+
+// detect identifies and decodes processings over a whole source image
+func (qr *Processing) detect(ctx context.Context, image *[]byte, w int, h int, cfg *config) (Result, error) {
+	if cfg.rectify {
+		return qr.rectified(ctx, image, w, h, cfg)
 	}
-	defer qr.Destroy()
 
-	if err = qr.Resize(w, h); err != nil {
+	if err := qr.Resize(w, h); err != nil {
+		return Result{}, err
+	}
+
+	result, err := qr.identify(ctx, image, cfg, false)
+	if err != nil {
 		return result, err
 	}
 
+	if result.Usable == 0 && cfg.inverted && !cfg.expired() {
+		inverted, err := qr.identify(ctx, image, cfg, true)
+		if err != nil {
+			return result, err
+		}
+		if inverted.Usable > 0 {
+			result = inverted
+		}
+	}
+
+	return result, nil
+}
+
+// identify loads the image, negated when inverted is set, and reveals its
+// processings, in a single cgo call when nothing has to be checked between
+// two of them
+func (qr *Processing) identify(ctx context.Context, image *[]byte, cfg *config, inverted bool) (Result, error) {
+	if ctx.Done() == nil && cfg.deadline.IsZero() && cfg.decodeWorkers <= 1 && !cfg.binarized && cfg.observe == nil && cfg.decodesAtOnce() {
+		found, outcomes := qr.decodeAll(*image, cfg, inverted)
+		return qr.report(ctx, cfg, found, len(outcomes), func(i int) outcome {
+			return outcomes[i]
+		})
+	}
+
+	start := time.Now()
 	qr.Load(image)
-	qr.End()
+	if inverted {
+		qr.invert()
+	}
+	qr.end(cfg)
+	if cfg.observe != nil {
+		qr.observeIdentify(cfg, start)
+	}
+	return qr.collect(ctx, cfg, inverted)
+}
+
+// collect extracts and decodes every processing identified since last End
+func (qr *Processing) collect(ctx context.Context, cfg *config, inverted bool) (Result, error) {
+	if err := ctx.Err(); err != nil {
+		return Result{}, err
+	}
+
+	found := qr.Count()
+	limit := found
+	if cfg.maxCodes > 0 && cfg.maxCodes < limit {
+		limit = cfg.maxCodes
+	}
+
+	if cfg.decodeWorkers > 1 && limit > 1 {
+		outcomes := qr.processAll(ctx, limit, cfg, inverted)
+		return qr.report(ctx, cfg, found, limit, func(i int) outcome {
+			return outcomes[i]
+		})
+	}
+	return qr.report(ctx, cfg, found, limit, func(i int) outcome {
+		if ctx.Err() != nil || cfg.expired() {
+			return outcome{}
+		}
+		return qr.process(ctx, i, cfg, inverted)
+	})
+}
+
+// report gathers the outcomes of the first limit processings out of found,
+// next returning them in order
+func (qr *Processing) report(ctx context.Context, cfg *config, found int, limit int, next func(int) outcome) (Result, error) {
+	result := Result{Found: found}
+	for i := 0; i < limit; i++ {
+		o := next(i)
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		if !o.done {
+			result.Partial = true
+			break
+		}
+		if o.skipped {
+			continue
+		}
 
-	result.Found = qr.Count()
-	result.Usable = result.Found
-	for i := 0; i < result.Found; i++ {
-		qr.Extract(i)
-		if err = qr.Decode(); err == nil {
-			result.Code = append(result.Code, QRcode{
-				Corners: [4]Position{
-					Position{
-						(int)(qr.code.corners[0].x),
-						(int)(qr.code.corners[0].y),
-					},
-					Position{
-						(int)(qr.code.corners[1].x),
-						(int)(qr.code.corners[1].y),
-					},
-					Position{
-						(int)(qr.code.corners[2].x),
-						(int)(qr.code.corners[2].y),
-					},
-					Position{
-						(int)(qr.code.corners[3].x),
-						(int)(qr.code.corners[3].y),
-					}},
-				DataType:      (int)(qr.data.data_type),
-				ECCLevel:      (int)(qr.data.ecc_level),
-				Mask:          (int)(qr.data.mask),
-				Payload:       C.GoString((*C.char)(unsafe.Pointer(&qr.data.payload[0]))),
-				PayloadLength: len(C.GoString((*C.char)(unsafe.Pointer(&qr.data.payload[0])))),
-				Size:          (int)(qr.code.size),
-				Version:       (int)(qr.data.version)})
+		if o.err == nil {
+			result.Usable++
+			if cfg.visit != nil {
+				if !cfg.visit(o.code) {
+					break
+				}
+				continue
+			}
+			result.Code = append(result.Code, o.code)
+			if cfg.first {
+				break
+			}
 		} else {
-			result.Usable--
+			failure := DecodeFailure{
+				Index:   i,
+				Corners: cfg.place(o.corners),
+				Err:     o.err,
+				frame:   o.corners}
+			if cfg.visitFailure != nil {
+				if !cfg.visitFailure(failure) {
+					break
+				}
+				continue
+			}
+			result.Failures = append(result.Failures, failure)
 		}
 	}
+	result.Messages = Assemble(result.Code)
 
 	return result, nil
 }
+
+// outcome is the result of the extraction and decoding of a processing,
+// done is left unset when the deadline or ctx stopped it beforehand
+type outcome struct {
+	done    bool
+	skipped bool
+	code    QRcode
+	corners [4]Position
+	err     error
+}
+
+// process extracts and decodes the processing at index i
+func (qr *Processing) process(ctx context.Context, i int, cfg *config, inverted bool) outcome {
+	if cfg.observe != nil {
+		return qr.processObserved(ctx, i, cfg, inverted)
+	}
+
+	qr.Extract(i)
+	if ctx.Err() != nil {
+		return outcome{done: true, corners: qr.corners()}
+	}
+	if qr.skipped(cfg) {
+		return outcome{done: true, skipped: true}
+	}
+	return qr.finish(qr.Decode(), cfg, inverted)
+}
+
+// processObserved works like process, reporting the extraction and the
+// decoding to the phase observer
+func (qr *Processing) processObserved(ctx context.Context, i int, cfg *config, inverted bool) outcome {
+	start := time.Now()
+	qr.Extract(i)
+	cfg.observe(PhaseSpan{Phase: PhaseExtract, Start: start, End: time.Now(), Index: i})
+	if ctx.Err() != nil {
+		return outcome{done: true, corners: qr.corners()}
+	}
+	if qr.skipped(cfg) {
+		return outcome{done: true, skipped: true}
+	}
+
+	start = time.Now()
+	o := qr.finish(qr.Decode(), cfg, inverted)
+	cfg.observe(PhaseSpan{Phase: PhaseDecode, Start: start, End: time.Now(), Index: i, Err: o.err})
+	return o
+}
+
+// skipped tells whether the extracted processing is below the minimum size
+func (qr *Processing) skipped(cfg *config) bool {
+	return cfg.minSize > 0 && shortestSide(cfg.place(qr.corners())) < float64(cfg.minSize)
+}
+
+// finish builds the outcome of the extracted processing once decoded with
+// err, retrying on its mirrored form if needed
+func (qr *Processing) finish(err error, cfg *config, inverted bool) outcome {
+	o := outcome{done: true, corners: qr.corners(), err: err}
+	mirrored := false
+	if o.err != nil && cfg.mirrored {
+		qr.flip()
+		if qr.Decode() == nil {
+			o.err, mirrored = nil, true
+		}
+	}
+	if o.err == nil {
+		o.code = qr.qrcode(o.corners)
+		o.code.Mirrored = mirrored
+		o.code.Inverted = inverted
+		o.code.Corners = cfg.place(o.code.Corners)
+	}
+	return o
+}
+
+// processAll extracts and decodes the first limit processings with
+// decodeWorkers goroutines, each one owning its code and data while sharing
+// the identification, which quirc only reads
+func (qr *Processing) processAll(ctx context.Context, limit int, cfg *config, inverted bool) []outcome {
+	outcomes := make([]outcome, limit)
+	workers := cfg.decodeWorkers
+	if workers > limit {
+		workers = limit
+	}
+
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for n := 0; n < workers; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			worker := qr.share()
+			for i := range next {
+				if ctx.Err() == nil && !cfg.expired() {
+					outcomes[i] = worker.process(ctx, i, cfg, inverted)
+				}
+			}
+		}()
+	}
+	for i := 0; i < limit; i++ {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	return outcomes
+}
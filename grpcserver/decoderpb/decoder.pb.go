@@ -0,0 +1,792 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: decoder.proto
+
+// Reveals the qrcodes of images with goquirc
+
+package decoderpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// EccLevel is the error correction level of a qrcode
+type EccLevel int32
+
+const (
+	EccLevel_ECC_LEVEL_UNSPECIFIED EccLevel = 0
+	EccLevel_ECC_LEVEL_L           EccLevel = 1
+	EccLevel_ECC_LEVEL_M           EccLevel = 2
+	EccLevel_ECC_LEVEL_Q           EccLevel = 3
+	EccLevel_ECC_LEVEL_H           EccLevel = 4
+)
+
+// Enum value maps for EccLevel.
+var (
+	EccLevel_name = map[int32]string{
+		0: "ECC_LEVEL_UNSPECIFIED",
+		1: "ECC_LEVEL_L",
+		2: "ECC_LEVEL_M",
+		3: "ECC_LEVEL_Q",
+		4: "ECC_LEVEL_H",
+	}
+	EccLevel_value = map[string]int32{
+		"ECC_LEVEL_UNSPECIFIED": 0,
+		"ECC_LEVEL_L":           1,
+		"ECC_LEVEL_M":           2,
+		"ECC_LEVEL_Q":           3,
+		"ECC_LEVEL_H":           4,
+	}
+)
+
+func (x EccLevel) Enum() *EccLevel {
+	p := new(EccLevel)
+	*p = x
+	return p
+}
+
+func (x EccLevel) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (EccLevel) Descriptor() protoreflect.EnumDescriptor {
+	return file_decoder_proto_enumTypes[0].Descriptor()
+}
+
+func (EccLevel) Type() protoreflect.EnumType {
+	return &file_decoder_proto_enumTypes[0]
+}
+
+func (x EccLevel) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use EccLevel.Descriptor instead.
+func (EccLevel) EnumDescriptor() ([]byte, []int) {
+	return file_decoder_proto_rawDescGZIP(), []int{0}
+}
+
+// DataType is the encoding mode of the first segment of a qrcode
+type DataType int32
+
+const (
+	DataType_DATA_TYPE_UNSPECIFIED  DataType = 0
+	DataType_DATA_TYPE_NUMERIC      DataType = 1
+	DataType_DATA_TYPE_ALPHANUMERIC DataType = 2
+	DataType_DATA_TYPE_BYTE         DataType = 3
+	DataType_DATA_TYPE_KANJI        DataType = 4
+)
+
+// Enum value maps for DataType.
+var (
+	DataType_name = map[int32]string{
+		0: "DATA_TYPE_UNSPECIFIED",
+		1: "DATA_TYPE_NUMERIC",
+		2: "DATA_TYPE_ALPHANUMERIC",
+		3: "DATA_TYPE_BYTE",
+		4: "DATA_TYPE_KANJI",
+	}
+	DataType_value = map[string]int32{
+		"DATA_TYPE_UNSPECIFIED":  0,
+		"DATA_TYPE_NUMERIC":      1,
+		"DATA_TYPE_ALPHANUMERIC": 2,
+		"DATA_TYPE_BYTE":         3,
+		"DATA_TYPE_KANJI":        4,
+	}
+)
+
+func (x DataType) Enum() *DataType {
+	p := new(DataType)
+	*p = x
+	return p
+}
+
+func (x DataType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (DataType) Descriptor() protoreflect.EnumDescriptor {
+	return file_decoder_proto_enumTypes[1].Descriptor()
+}
+
+func (DataType) Type() protoreflect.EnumType {
+	return &file_decoder_proto_enumTypes[1]
+}
+
+func (x DataType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use DataType.Descriptor instead.
+func (DataType) EnumDescriptor() ([]byte, []int) {
+	return file_decoder_proto_rawDescGZIP(), []int{1}
+}
+
+// Image is either an encoded image in any format known to goquirc
+// DecodeReader (PNG, JPEG, GIF, WebP, TIFF, BMP, PGM or PPM), or raw 8 bits
+// grayscale pixels when width and height are set
+type Image struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Data          []byte                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	Width         int32                  `protobuf:"varint,2,opt,name=width,proto3" json:"width,omitempty"`
+	Height        int32                  `protobuf:"varint,3,opt,name=height,proto3" json:"height,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Image) Reset() {
+	*x = Image{}
+	mi := &file_decoder_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Image) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Image) ProtoMessage() {}
+
+func (x *Image) ProtoReflect() protoreflect.Message {
+	mi := &file_decoder_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Image.ProtoReflect.Descriptor instead.
+func (*Image) Descriptor() ([]byte, []int) {
+	return file_decoder_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Image) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *Image) GetWidth() int32 {
+	if x != nil {
+		return x.Width
+	}
+	return 0
+}
+
+func (x *Image) GetHeight() int32 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+// Result is the outcome of the reveal process of an image
+type Result struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Found         int32                  `protobuf:"varint,1,opt,name=found,proto3" json:"found,omitempty"`
+	Decoded       int32                  `protobuf:"varint,2,opt,name=decoded,proto3" json:"decoded,omitempty"`
+	Codes         []*Code                `protobuf:"bytes,3,rep,name=codes,proto3" json:"codes,omitempty"`
+	Failures      []*Failure             `protobuf:"bytes,4,rep,name=failures,proto3" json:"failures,omitempty"`
+	Messages      []*Message             `protobuf:"bytes,5,rep,name=messages,proto3" json:"messages,omitempty"`
+	Partial       bool                   `protobuf:"varint,6,opt,name=partial,proto3" json:"partial,omitempty"`
+	Profile       string                 `protobuf:"bytes,7,opt,name=profile,proto3" json:"profile,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Result) Reset() {
+	*x = Result{}
+	mi := &file_decoder_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Result) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Result) ProtoMessage() {}
+
+func (x *Result) ProtoReflect() protoreflect.Message {
+	mi := &file_decoder_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Result.ProtoReflect.Descriptor instead.
+func (*Result) Descriptor() ([]byte, []int) {
+	return file_decoder_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Result) GetFound() int32 {
+	if x != nil {
+		return x.Found
+	}
+	return 0
+}
+
+func (x *Result) GetDecoded() int32 {
+	if x != nil {
+		return x.Decoded
+	}
+	return 0
+}
+
+func (x *Result) GetCodes() []*Code {
+	if x != nil {
+		return x.Codes
+	}
+	return nil
+}
+
+func (x *Result) GetFailures() []*Failure {
+	if x != nil {
+		return x.Failures
+	}
+	return nil
+}
+
+func (x *Result) GetMessages() []*Message {
+	if x != nil {
+		return x.Messages
+	}
+	return nil
+}
+
+func (x *Result) GetPartial() bool {
+	if x != nil {
+		return x.Partial
+	}
+	return false
+}
+
+func (x *Result) GetProfile() string {
+	if x != nil {
+		return x.Profile
+	}
+	return ""
+}
+
+// Point is a location in the image, in pixels
+type Point struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	X             int32                  `protobuf:"varint,1,opt,name=x,proto3" json:"x,omitempty"`
+	Y             int32                  `protobuf:"varint,2,opt,name=y,proto3" json:"y,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Point) Reset() {
+	*x = Point{}
+	mi := &file_decoder_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Point) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Point) ProtoMessage() {}
+
+func (x *Point) ProtoReflect() protoreflect.Message {
+	mi := &file_decoder_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Point.ProtoReflect.Descriptor instead.
+func (*Point) Descriptor() ([]byte, []int) {
+	return file_decoder_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Point) GetX() int32 {
+	if x != nil {
+		return x.X
+	}
+	return 0
+}
+
+func (x *Point) GetY() int32 {
+	if x != nil {
+		return x.Y
+	}
+	return 0
+}
+
+// Code is a decoded qrcode, text is only set when the payload transcodes to
+// printable UTF-8
+type Code struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Payload       []byte                 `protobuf:"bytes,1,opt,name=payload,proto3" json:"payload,omitempty"`
+	Text          string                 `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"`
+	Version       int32                  `protobuf:"varint,3,opt,name=version,proto3" json:"version,omitempty"`
+	EccLevel      EccLevel               `protobuf:"varint,4,opt,name=ecc_level,json=eccLevel,proto3,enum=goquirc.v1.EccLevel" json:"ecc_level,omitempty"`
+	Mask          int32                  `protobuf:"varint,5,opt,name=mask,proto3" json:"mask,omitempty"`
+	DataType      DataType               `protobuf:"varint,6,opt,name=data_type,json=dataType,proto3,enum=goquirc.v1.DataType" json:"data_type,omitempty"`
+	Eci           int32                  `protobuf:"varint,7,opt,name=eci,proto3" json:"eci,omitempty"`
+	Corners       []*Point               `protobuf:"bytes,8,rep,name=corners,proto3" json:"corners,omitempty"`
+	Append        *StructuredAppend      `protobuf:"bytes,9,opt,name=append,proto3" json:"append,omitempty"`
+	Inverted      bool                   `protobuf:"varint,10,opt,name=inverted,proto3" json:"inverted,omitempty"`
+	Mirrored      bool                   `protobuf:"varint,11,opt,name=mirrored,proto3" json:"mirrored,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Code) Reset() {
+	*x = Code{}
+	mi := &file_decoder_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Code) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Code) ProtoMessage() {}
+
+func (x *Code) ProtoReflect() protoreflect.Message {
+	mi := &file_decoder_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Code.ProtoReflect.Descriptor instead.
+func (*Code) Descriptor() ([]byte, []int) {
+	return file_decoder_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Code) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *Code) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *Code) GetVersion() int32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *Code) GetEccLevel() EccLevel {
+	if x != nil {
+		return x.EccLevel
+	}
+	return EccLevel_ECC_LEVEL_UNSPECIFIED
+}
+
+func (x *Code) GetMask() int32 {
+	if x != nil {
+		return x.Mask
+	}
+	return 0
+}
+
+func (x *Code) GetDataType() DataType {
+	if x != nil {
+		return x.DataType
+	}
+	return DataType_DATA_TYPE_UNSPECIFIED
+}
+
+func (x *Code) GetEci() int32 {
+	if x != nil {
+		return x.Eci
+	}
+	return 0
+}
+
+func (x *Code) GetCorners() []*Point {
+	if x != nil {
+		return x.Corners
+	}
+	return nil
+}
+
+func (x *Code) GetAppend() *StructuredAppend {
+	if x != nil {
+		return x.Append
+	}
+	return nil
+}
+
+func (x *Code) GetInverted() bool {
+	if x != nil {
+		return x.Inverted
+	}
+	return false
+}
+
+func (x *Code) GetMirrored() bool {
+	if x != nil {
+		return x.Mirrored
+	}
+	return false
+}
+
+// StructuredAppend tells the position of a qrcode within a sequence
+type StructuredAppend struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Index         int32                  `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	Total         int32                  `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`
+	Parity        uint32                 `protobuf:"varint,3,opt,name=parity,proto3" json:"parity,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StructuredAppend) Reset() {
+	*x = StructuredAppend{}
+	mi := &file_decoder_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StructuredAppend) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StructuredAppend) ProtoMessage() {}
+
+func (x *StructuredAppend) ProtoReflect() protoreflect.Message {
+	mi := &file_decoder_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StructuredAppend.ProtoReflect.Descriptor instead.
+func (*StructuredAppend) Descriptor() ([]byte, []int) {
+	return file_decoder_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *StructuredAppend) GetIndex() int32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *StructuredAppend) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *StructuredAppend) GetParity() uint32 {
+	if x != nil {
+		return x.Parity
+	}
+	return 0
+}
+
+// Failure is a qrcode which was found but could not be decoded
+type Failure struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Error         string                 `protobuf:"bytes,1,opt,name=error,proto3" json:"error,omitempty"`
+	Corners       []*Point               `protobuf:"bytes,2,rep,name=corners,proto3" json:"corners,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Failure) Reset() {
+	*x = Failure{}
+	mi := &file_decoder_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Failure) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Failure) ProtoMessage() {}
+
+func (x *Failure) ProtoReflect() protoreflect.Message {
+	mi := &file_decoder_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Failure.ProtoReflect.Descriptor instead.
+func (*Failure) Descriptor() ([]byte, []int) {
+	return file_decoder_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Failure) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *Failure) GetCorners() []*Point {
+	if x != nil {
+		return x.Corners
+	}
+	return nil
+}
+
+// Message is a payload reassembled from structured append qrcodes
+type Message struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Payload       []byte                 `protobuf:"bytes,1,opt,name=payload,proto3" json:"payload,omitempty"`
+	Text          string                 `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"`
+	Total         int32                  `protobuf:"varint,3,opt,name=total,proto3" json:"total,omitempty"`
+	Parts         int32                  `protobuf:"varint,4,opt,name=parts,proto3" json:"parts,omitempty"`
+	Complete      bool                   `protobuf:"varint,5,opt,name=complete,proto3" json:"complete,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Message) Reset() {
+	*x = Message{}
+	mi := &file_decoder_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Message) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Message) ProtoMessage() {}
+
+func (x *Message) ProtoReflect() protoreflect.Message {
+	mi := &file_decoder_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Message.ProtoReflect.Descriptor instead.
+func (*Message) Descriptor() ([]byte, []int) {
+	return file_decoder_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Message) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *Message) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *Message) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *Message) GetParts() int32 {
+	if x != nil {
+		return x.Parts
+	}
+	return 0
+}
+
+func (x *Message) GetComplete() bool {
+	if x != nil {
+		return x.Complete
+	}
+	return false
+}
+
+var File_decoder_proto protoreflect.FileDescriptor
+
+const file_decoder_proto_rawDesc = "" +
+	"\n" +
+	"\rdecoder.proto\x12\n" +
+	"goquirc.v1\"I\n" +
+	"\x05Image\x12\x12\n" +
+	"\x04data\x18\x01 \x01(\fR\x04data\x12\x14\n" +
+	"\x05width\x18\x02 \x01(\x05R\x05width\x12\x16\n" +
+	"\x06height\x18\x03 \x01(\x05R\x06height\"\xf6\x01\n" +
+	"\x06Result\x12\x14\n" +
+	"\x05found\x18\x01 \x01(\x05R\x05found\x12\x18\n" +
+	"\adecoded\x18\x02 \x01(\x05R\adecoded\x12&\n" +
+	"\x05codes\x18\x03 \x03(\v2\x10.goquirc.v1.CodeR\x05codes\x12/\n" +
+	"\bfailures\x18\x04 \x03(\v2\x13.goquirc.v1.FailureR\bfailures\x12/\n" +
+	"\bmessages\x18\x05 \x03(\v2\x13.goquirc.v1.MessageR\bmessages\x12\x18\n" +
+	"\apartial\x18\x06 \x01(\bR\apartial\x12\x18\n" +
+	"\aprofile\x18\a \x01(\tR\aprofile\"#\n" +
+	"\x05Point\x12\f\n" +
+	"\x01x\x18\x01 \x01(\x05R\x01x\x12\f\n" +
+	"\x01y\x18\x02 \x01(\x05R\x01y\"\xf5\x02\n" +
+	"\x04Code\x12\x18\n" +
+	"\apayload\x18\x01 \x01(\fR\apayload\x12\x12\n" +
+	"\x04text\x18\x02 \x01(\tR\x04text\x12\x18\n" +
+	"\aversion\x18\x03 \x01(\x05R\aversion\x121\n" +
+	"\tecc_level\x18\x04 \x01(\x0e2\x14.goquirc.v1.EccLevelR\beccLevel\x12\x12\n" +
+	"\x04mask\x18\x05 \x01(\x05R\x04mask\x121\n" +
+	"\tdata_type\x18\x06 \x01(\x0e2\x14.goquirc.v1.DataTypeR\bdataType\x12\x10\n" +
+	"\x03eci\x18\a \x01(\x05R\x03eci\x12+\n" +
+	"\acorners\x18\b \x03(\v2\x11.goquirc.v1.PointR\acorners\x124\n" +
+	"\x06append\x18\t \x01(\v2\x1c.goquirc.v1.StructuredAppendR\x06append\x12\x1a\n" +
+	"\binverted\x18\n" +
+	" \x01(\bR\binverted\x12\x1a\n" +
+	"\bmirrored\x18\v \x01(\bR\bmirrored\"V\n" +
+	"\x10StructuredAppend\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\x05R\x05index\x12\x14\n" +
+	"\x05total\x18\x02 \x01(\x05R\x05total\x12\x16\n" +
+	"\x06parity\x18\x03 \x01(\rR\x06parity\"L\n" +
+	"\aFailure\x12\x14\n" +
+	"\x05error\x18\x01 \x01(\tR\x05error\x12+\n" +
+	"\acorners\x18\x02 \x03(\v2\x11.goquirc.v1.PointR\acorners\"\x7f\n" +
+	"\aMessage\x12\x18\n" +
+	"\apayload\x18\x01 \x01(\fR\apayload\x12\x12\n" +
+	"\x04text\x18\x02 \x01(\tR\x04text\x12\x14\n" +
+	"\x05total\x18\x03 \x01(\x05R\x05total\x12\x14\n" +
+	"\x05parts\x18\x04 \x01(\x05R\x05parts\x12\x1a\n" +
+	"\bcomplete\x18\x05 \x01(\bR\bcomplete*i\n" +
+	"\bEccLevel\x12\x19\n" +
+	"\x15ECC_LEVEL_UNSPECIFIED\x10\x00\x12\x0f\n" +
+	"\vECC_LEVEL_L\x10\x01\x12\x0f\n" +
+	"\vECC_LEVEL_M\x10\x02\x12\x0f\n" +
+	"\vECC_LEVEL_Q\x10\x03\x12\x0f\n" +
+	"\vECC_LEVEL_H\x10\x04*\x81\x01\n" +
+	"\bDataType\x12\x19\n" +
+	"\x15DATA_TYPE_UNSPECIFIED\x10\x00\x12\x15\n" +
+	"\x11DATA_TYPE_NUMERIC\x10\x01\x12\x1a\n" +
+	"\x16DATA_TYPE_ALPHANUMERIC\x10\x02\x12\x12\n" +
+	"\x0eDATA_TYPE_BYTE\x10\x03\x12\x13\n" +
+	"\x0fDATA_TYPE_KANJI\x10\x042:\n" +
+	"\aDecoder\x12/\n" +
+	"\x06Decode\x12\x11.goquirc.v1.Image\x1a\x12.goquirc.v1.ResultB1Z/github.com/quaresc/goquirc/grpcserver/decoderpbb\x06proto3"
+
+var (
+	file_decoder_proto_rawDescOnce sync.Once
+	file_decoder_proto_rawDescData []byte
+)
+
+func file_decoder_proto_rawDescGZIP() []byte {
+	file_decoder_proto_rawDescOnce.Do(func() {
+		file_decoder_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_decoder_proto_rawDesc), len(file_decoder_proto_rawDesc)))
+	})
+	return file_decoder_proto_rawDescData
+}
+
+var file_decoder_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_decoder_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_decoder_proto_goTypes = []any{
+	(EccLevel)(0),            // 0: goquirc.v1.EccLevel
+	(DataType)(0),            // 1: goquirc.v1.DataType
+	(*Image)(nil),            // 2: goquirc.v1.Image
+	(*Result)(nil),           // 3: goquirc.v1.Result
+	(*Point)(nil),            // 4: goquirc.v1.Point
+	(*Code)(nil),             // 5: goquirc.v1.Code
+	(*StructuredAppend)(nil), // 6: goquirc.v1.StructuredAppend
+	(*Failure)(nil),          // 7: goquirc.v1.Failure
+	(*Message)(nil),          // 8: goquirc.v1.Message
+}
+var file_decoder_proto_depIdxs = []int32{
+	5, // 0: goquirc.v1.Result.codes:type_name -> goquirc.v1.Code
+	7, // 1: goquirc.v1.Result.failures:type_name -> goquirc.v1.Failure
+	8, // 2: goquirc.v1.Result.messages:type_name -> goquirc.v1.Message
+	0, // 3: goquirc.v1.Code.ecc_level:type_name -> goquirc.v1.EccLevel
+	1, // 4: goquirc.v1.Code.data_type:type_name -> goquirc.v1.DataType
+	4, // 5: goquirc.v1.Code.corners:type_name -> goquirc.v1.Point
+	6, // 6: goquirc.v1.Code.append:type_name -> goquirc.v1.StructuredAppend
+	4, // 7: goquirc.v1.Failure.corners:type_name -> goquirc.v1.Point
+	2, // 8: goquirc.v1.Decoder.Decode:input_type -> goquirc.v1.Image
+	3, // 9: goquirc.v1.Decoder.Decode:output_type -> goquirc.v1.Result
+	9, // [9:10] is the sub-list for method output_type
+	8, // [8:9] is the sub-list for method input_type
+	8, // [8:8] is the sub-list for extension type_name
+	8, // [8:8] is the sub-list for extension extendee
+	0, // [0:8] is the sub-list for field type_name
+}
+
+func init() { file_decoder_proto_init() }
+func file_decoder_proto_init() {
+	if File_decoder_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_decoder_proto_rawDesc), len(file_decoder_proto_rawDesc)),
+			NumEnums:      2,
+			NumMessages:   7,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_decoder_proto_goTypes,
+		DependencyIndexes: file_decoder_proto_depIdxs,
+		EnumInfos:         file_decoder_proto_enumTypes,
+		MessageInfos:      file_decoder_proto_msgTypes,
+	}.Build()
+	File_decoder_proto = out.File
+	file_decoder_proto_goTypes = nil
+	file_decoder_proto_depIdxs = nil
+}
@@ -0,0 +1,5 @@
+// Package decoderpb holds the protocol buffers and the client generated from
+// decoder.proto, the definition of the gRPC decoding service
+package decoderpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative decoder.proto
@@ -0,0 +1,171 @@
+// Package grpcserver implements the gRPC decoding service defined by
+// decoderpb/decoder.proto, whose generated Go client lives in decoderpb.
+// Other languages generate their own client from the same definition
+//
+// A decoding service boils down to:
+//
+//	pool, err := goquirc.NewPool(0)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	defer pool.Close()
+//	s := grpc.NewServer()
+//	decoderpb.RegisterDecoderServer(s, &grpcserver.Server{Pool: pool})
+//	lis, err := net.Listen("tcp", ":50051")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	log.Fatal(s.Serve(lis))
+package grpcserver
+
+import (
+	"bytes"
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/quaresc/goquirc"
+	"github.com/quaresc/goquirc/grpcserver/decoderpb"
+	"github.com/quaresc/goquirc/internal/imagedecode"
+	"github.com/quaresc/goquirc/internal/jsonresult"
+)
+
+// DefaultMaxPixels bounds the dimensions of images when Server leaves
+// MaxPixels unset
+const DefaultMaxPixels = imagedecode.DefaultMaxPixels
+
+// Server reveals the qrcodes of the images it receives
+type Server struct {
+	decoderpb.UnimplementedDecoderServer
+
+	// Pool runs the reveal processes, typically a *goquirc.Pool or an
+	// instrumented metrics.Pool, it must be safe for concurrent use. When
+	// nil each image gets its own quirc context
+	Pool goquirc.Revealer
+
+	// Options tune every reveal process
+	Options []goquirc.Option
+
+	// MaxPixels bounds the dimensions of images, larger encoded ones being
+	// rejected before they are decoded, zero falls back to DefaultMaxPixels
+	MaxPixels int
+}
+
+var _ decoderpb.DecoderServer = (*Server)(nil)
+
+// eccLevels maps goquirc ECC levels to their protocol buffer values
+var eccLevels = map[goquirc.ECCLevel]decoderpb.EccLevel{
+	goquirc.ECCLevelL: decoderpb.EccLevel_ECC_LEVEL_L,
+	goquirc.ECCLevelM: decoderpb.EccLevel_ECC_LEVEL_M,
+	goquirc.ECCLevelQ: decoderpb.EccLevel_ECC_LEVEL_Q,
+	goquirc.ECCLevelH: decoderpb.EccLevel_ECC_LEVEL_H,
+}
+
+// dataTypes maps goquirc data types to their protocol buffer values
+var dataTypes = map[goquirc.DataType]decoderpb.DataType{
+	goquirc.DataTypeNumeric:      decoderpb.DataType_DATA_TYPE_NUMERIC,
+	goquirc.DataTypeAlphanumeric: decoderpb.DataType_DATA_TYPE_ALPHANUMERIC,
+	goquirc.DataTypeByte:         decoderpb.DataType_DATA_TYPE_BYTE,
+	goquirc.DataTypeKanji:        decoderpb.DataType_DATA_TYPE_KANJI,
+}
+
+// Decode reveals all qrcodes of an image
+func (s *Server) Decode(ctx context.Context, img *decoderpb.Image) (*decoderpb.Result, error) {
+	gray, width, height, err := luminance(img, s.MaxPixels)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	result, err := imagedecode.Reveal(ctx, s.Pool, s.Options, gray, width, height)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, status.FromContextError(ctx.Err()).Err()
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return newResult(result), nil
+}
+
+// luminance returns the grayscale pixels of an image message no larger than
+// maxPixels
+func luminance(img *decoderpb.Image, maxPixels int) ([]byte, int, int, error) {
+	data := img.GetData()
+	width, height := int(img.GetWidth()), int(img.GetHeight())
+	if width != 0 || height != 0 {
+		if width <= 0 || height <= 0 {
+			return nil, 0, 0, errors.New("Raw image dimensions must be positive")
+		}
+		if err := imagedecode.CheckPixels(width, height, maxPixels); err != nil {
+			return nil, 0, 0, err
+		}
+		if len(data) != width*height {
+			return nil, 0, 0, errors.New("Raw image data does not match its dimensions")
+		}
+		return data, width, height, nil
+	}
+	return imagedecode.Luminance(bytes.NewReader(data), maxPixels)
+}
+
+// newResult converts the outcome of a reveal process to its protocol buffer
+func newResult(result goquirc.Result) *decoderpb.Result {
+	out := &decoderpb.Result{
+		Found:   int32(result.Found),
+		Decoded: int32(result.Usable),
+		Partial: result.Partial,
+		Profile: result.Profile,
+	}
+	for _, code := range result.Code {
+		c := &decoderpb.Code{
+			Payload:  code.Payload,
+			Text:     text(code.Text()),
+			Version:  int32(code.Version),
+			EccLevel: eccLevels[code.ECCLevel],
+			Mask:     int32(code.Mask),
+			DataType: dataTypes[code.DataType],
+			Eci:      int32(code.ECI),
+			Corners:  points(code.Corners),
+			Inverted: code.Inverted,
+			Mirrored: code.Mirrored,
+		}
+		if code.Append != nil {
+			c.Append = &decoderpb.StructuredAppend{
+				Index:  int32(code.Append.Index),
+				Total:  int32(code.Append.Total),
+				Parity: uint32(code.Append.Parity),
+			}
+		}
+		out.Codes = append(out.Codes, c)
+	}
+	for _, failure := range result.Failures {
+		out.Failures = append(out.Failures, &decoderpb.Failure{Error: failure.Error(), Corners: points(failure.Corners)})
+	}
+	for _, message := range result.Messages {
+		out.Messages = append(out.Messages, &decoderpb.Message{
+			Payload:  message.Payload,
+			Text:     text(message.Text()),
+			Total:    int32(message.Total),
+			Parts:    int32(len(message.Parts)),
+			Complete: message.Complete,
+		})
+	}
+	return out
+}
+
+// text keeps a transcoded payload only when it is printable
+func text(s string) string {
+	if !jsonresult.Printable(s) {
+		return ""
+	}
+	return s
+}
+
+// points converts corners to their protocol buffer
+func points(corners [4]goquirc.Position) []*decoderpb.Point {
+	out := make([]*decoderpb.Point, len(corners))
+	for i, c := range corners {
+		out[i] = &decoderpb.Point{X: int32(c.X), Y: int32(c.Y)}
+	}
+	return out
+}
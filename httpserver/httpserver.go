@@ -0,0 +1,200 @@
+// Package httpserver provides an http.Handler revealing the qrcodes of
+// POSTed images and answering with their JSON form, and a WebSocket one
+// decoding streams of frames
+//
+// A decoding microservice boils down to:
+//
+//	pool, err := goquirc.NewPool(0)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	defer pool.Close()
+//	http.Handle("/decode", &httpserver.Handler{Pool: pool})
+//	log.Fatal(http.ListenAndServe(":8080", nil))
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+
+	"github.com/quaresc/goquirc"
+	"github.com/quaresc/goquirc/internal/imagedecode"
+	"github.com/quaresc/goquirc/internal/jsonresult"
+)
+
+// DefaultMaxBytes bounds the size of request bodies when Handler leaves
+// MaxBytes unset
+const DefaultMaxBytes = 32 << 20
+
+// DefaultMaxPixels bounds the dimensions of images when Handler leaves
+// MaxPixels unset
+const DefaultMaxPixels = imagedecode.DefaultMaxPixels
+
+// Handler reveals the qrcodes of images POSTed either as the raw request
+// body, in any format known to DecodeReader, or as the files of a
+// multipart/form-data request.
+//
+// A raw body is answered with the JSON form of its result: found, decoded,
+// codes, failures and messages. A multipart request is answered with
+// {"images": [...]}, holding in order the result of every file part along
+// with its field and file names, and an error for the parts which could not
+// be decoded. Requests which cannot be processed are answered with
+// {"error": "..."} and a 4xx or 5xx status
+type Handler struct {
+	// Pool runs the reveal processes, typically a *goquirc.Pool or an
+	// instrumented metrics.Pool, it must be safe for concurrent use. When
+	// nil each image gets its own quirc context
+	Pool goquirc.Revealer
+
+	// Options tune every reveal process
+	Options []goquirc.Option
+
+	// MaxBytes bounds the size of request bodies, zero falls back to
+	// DefaultMaxBytes
+	MaxBytes int64
+
+	// MaxPixels bounds the dimensions of images, larger ones being rejected
+	// before they are decoded, zero falls back to DefaultMaxPixels
+	MaxPixels int
+}
+
+var _ http.Handler = (*Handler)(nil)
+
+// Image is the JSON form of a file part of a multipart request
+type Image struct {
+	Field    string `json:"field"`
+	Filename string `json:"filename,omitempty"`
+	Error    string `json:"error,omitempty"`
+	jsonresult.Result
+}
+
+// ServeHTTP answers a decode request
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, http.StatusMethodNotAllowed, errors.New("Only POST is allowed"))
+		return
+	}
+
+	limit := h.MaxBytes
+	if limit <= 0 {
+		limit = DefaultMaxBytes
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, limit)
+
+	mediaType, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		h.serveMultipart(w, r, params["boundary"])
+		return
+	}
+
+	result, err := decode(r.Context(), h.Pool, h.Options, h.MaxPixels, r.Body)
+	if err != nil {
+		writeError(w, status(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, jsonresult.New(result))
+}
+
+// serveMultipart decodes every file part of a multipart request
+func (h *Handler) serveMultipart(w http.ResponseWriter, r *http.Request, boundary string) {
+	if boundary == "" {
+		writeError(w, http.StatusBadRequest, errors.New("Missing multipart boundary"))
+		return
+	}
+
+	images := []Image{}
+	parts := multipart.NewReader(r.Body, boundary)
+	for {
+		part, err := parts.NextPart()
+		if err == io.EOF {
+			break
+		}
+		var tooLarge *http.MaxBytesError
+		if err != nil && !errors.As(err, &tooLarge) {
+			err = badRequest{err}
+		}
+		if err != nil {
+			writeError(w, status(err), err)
+			return
+		}
+		if part.FileName() == "" {
+			// Plain form values carry no image
+			part.Close()
+			continue
+		}
+
+		image := Image{Field: part.FormName(), Filename: part.FileName(), Result: jsonresult.Empty()}
+		result, err := decode(r.Context(), h.Pool, h.Options, h.MaxPixels, part)
+		part.Close()
+		switch {
+		case errors.As(err, &tooLarge) || r.Context().Err() != nil:
+			writeError(w, status(err), err)
+			return
+		case err != nil:
+			image.Error = err.Error()
+		default:
+			image.Result = jsonresult.New(result)
+		}
+		images = append(images, image)
+	}
+	writeJSON(w, http.StatusOK, struct {
+		Images []Image `json:"images"`
+	}{images})
+}
+
+// decode reveals the qrcodes of an encoded image no larger than maxPixels
+// with a decoder of pool, or its own quirc context when pool is nil
+func decode(ctx context.Context, pool goquirc.Revealer, opts []goquirc.Option, maxPixels int, body io.Reader) (goquirc.Result, error) {
+	gray, width, height, err := imagedecode.Luminance(body, maxPixels)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return goquirc.Result{}, err
+		}
+		return goquirc.Result{}, badRequest{err}
+	}
+	return imagedecode.Reveal(ctx, pool, opts, gray, width, height)
+}
+
+// badRequest marks the errors caused by the content of a request
+type badRequest struct {
+	error
+}
+
+// Unwrap returns the underlying error
+func (e badRequest) Unwrap() error {
+	return e.error
+}
+
+// status returns the HTTP status matching an error
+func status(err error) int {
+	var tooLarge *http.MaxBytesError
+	var bad badRequest
+	switch {
+	case errors.As(err, &tooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.As(err, &bad):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// writeError answers with the JSON form of an error
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, struct {
+		Error string `json:"error"`
+	}{err.Error()})
+}
+
+// writeJSON answers with the JSON form of a value
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,132 @@
+package httpserver
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/quaresc/goquirc"
+	"github.com/quaresc/goquirc/internal/jsonresult"
+)
+
+// StreamHandler upgrades requests to WebSocket connections over which
+// clients send a stream of encoded frames, such as JPEG snapshots of a
+// getUserMedia video, as binary messages. Each decoded frame is answered
+// with a text message holding the JSON form of its result along with the
+// number of the frame, counted from one, and an error when it could not be
+// decoded.
+//
+// Frames arriving while the previous one is decoded replace each other, only
+// the latest one is decoded next, so that results keep up with the camera
+// rather than lagging behind it. Skipped frames show as gaps in the numbers.
+// A browser streams its camera along these lines:
+//
+//	const ws = new WebSocket("wss://example.com/stream");
+//	ws.onmessage = (e) => console.log(JSON.parse(e.data).codes);
+//	setInterval(() => {
+//		canvas.getContext("2d").drawImage(video, 0, 0);
+//		canvas.toBlob((blob) => ws.send(blob), "image/jpeg", 0.8);
+//	}, 100);
+type StreamHandler struct {
+	// Pool runs the reveal processes, typically a *goquirc.Pool or an
+	// instrumented metrics.Pool, it must be safe for concurrent use. When
+	// nil each frame gets its own quirc context
+	Pool goquirc.Revealer
+
+	// Options tune every reveal process
+	Options []goquirc.Option
+
+	// MaxBytes bounds the size of frames, zero falls back to
+	// DefaultMaxBytes
+	MaxBytes int64
+
+	// MaxPixels bounds the dimensions of frames, larger ones being rejected
+	// before they are decoded, zero falls back to DefaultMaxPixels
+	MaxPixels int
+
+	// CheckOrigin accepts the origin of upgrade requests, when nil only
+	// requests from the same host are accepted
+	CheckOrigin func(r *http.Request) bool
+}
+
+var _ http.Handler = (*StreamHandler)(nil)
+
+// Frame is the JSON form of the result of a streamed frame
+type Frame struct {
+	Frame int    `json:"frame"`
+	Error string `json:"error,omitempty"`
+	jsonresult.Result
+}
+
+// streamedFrame is a frame waiting to be decoded
+type streamedFrame struct {
+	number int
+	data   []byte
+}
+
+// ServeHTTP upgrades a request and decodes the frames it streams until the
+// connection is closed
+func (h *StreamHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	upgrader := websocket.Upgrader{CheckOrigin: h.CheckOrigin}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade already answered the request
+		return
+	}
+	defer conn.Close()
+
+	limit := h.MaxBytes
+	if limit <= 0 {
+		limit = DefaultMaxBytes
+	}
+	conn.SetReadLimit(limit)
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	// latest holds the last frame received, the reader is its only sender so
+	// that it never blocks once the slot is drained
+	latest := make(chan streamedFrame, 1)
+	go func() {
+		defer cancel()
+		number := 0
+		for {
+			kind, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if kind != websocket.BinaryMessage {
+				continue
+			}
+			number++
+			select {
+			case <-latest:
+			default:
+			}
+			latest <- streamedFrame{number: number, data: data}
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case frame := <-latest:
+			out := Frame{Frame: frame.number, Result: jsonresult.Empty()}
+			result, err := decode(ctx, h.Pool, h.Options, h.MaxPixels, bytes.NewReader(frame.data))
+			switch {
+			case ctx.Err() != nil:
+				return
+			case err != nil:
+				out.Error = err.Error()
+			default:
+				out.Result = jsonresult.New(result)
+			}
+			if err := conn.WriteJSON(out); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,807 @@
+//go:build !cgo
+
+package goquirc
+
+import (
+	"math"
+
+	"github.com/quaresc/goquirc/internal/qrspec"
+)
+
+// Pixel values of the pixel map, regions being labelled from pixelRegion on
+const (
+	pixelWhite  = 0
+	pixelBlack  = 1
+	pixelRegion = 2
+)
+
+// Capacities of the identification tables, as in quirc
+const (
+	maxCapstones = 32
+	maxGrids     = maxCapstones * 2
+)
+
+// regionLabels is the count of labels of each region capacity, the first
+// pixelRegion values of the pixel map being reserved
+var regionLabels = [...]int{
+	RegionsLarge:  65534,
+	RegionsMedium: 4094,
+	RegionsSmall:  254,
+}
+
+// point is a location in the source image
+type point struct {
+	x int
+	y int
+}
+
+// region is a connected area of dark pixels
+type region struct {
+	seed     point
+	count    int
+	capstone int
+}
+
+// capstone is a finder pattern, a ring around a stone
+type capstone struct {
+	ring    int
+	stone   int
+	corners [4]point
+	center  point
+	c       perspective
+	grid    int
+}
+
+// qrGrid is a group of three capstones forming a qrcode
+type qrGrid struct {
+	caps        [3]int
+	alignRegion int
+	align       point
+	tpep        [3]point
+	size        int
+	c           perspective
+}
+
+// identifier is the pure Go port of the identification of quirc
+type identifier struct {
+	image  []byte
+	pixels []uint16
+	w      int
+	h      int
+
+	labels    int
+	regions   []region
+	capstones []capstone
+	grids     []qrGrid
+
+	// stack holds the seeds of the flood fill
+	stack []point
+}
+
+// newIdentifier allocates an identifier for a region capacity
+func newIdentifier(capacity RegionCapacity) *identifier {
+	return &identifier{labels: regionLabels[capacity]}
+}
+
+// resize allocates the source image buffer and pixel map
+func (q *identifier) resize(w int, h int) {
+	q.image = make([]byte, w*h)
+	q.pixels = make([]uint16, w*h)
+	q.w, q.h = w, h
+	q.reset()
+}
+
+// reset forgets the previous identification
+func (q *identifier) reset() {
+	q.regions = append(q.regions[:0], make([]region, pixelRegion)...)
+	q.capstones = q.capstones[:0]
+	q.grids = q.grids[:0]
+}
+
+// end thresholds the source image, scans it for capstones and groups them,
+// the threshold being picked with Otsu's method unless binarized is set
+func (q *identifier) end(binarized bool) {
+	q.reset()
+
+	threshold := byte(128)
+	if !binarized {
+		threshold = q.otsu()
+	}
+	for i, v := range q.image {
+		if v < threshold {
+			q.pixels[i] = pixelBlack
+		} else {
+			q.pixels[i] = pixelWhite
+		}
+	}
+
+	for y := 0; y < q.h; y++ {
+		q.finderScan(y)
+	}
+	for i := range q.capstones {
+		q.testGrouping(i)
+	}
+}
+
+// otsu returns the threshold maximizing the variance between the dark and
+// light pixels of the source image
+func (q *identifier) otsu() byte {
+	var histogram [256]int
+	for _, v := range q.image {
+		histogram[v]++
+	}
+
+	sum := 0.0
+	for i, n := range histogram {
+		sum += float64(i * n)
+	}
+
+	var threshold byte
+	sumB, max, q1 := 0.0, 0.0, 0
+	for i, n := range histogram {
+		q1 += n
+		if q1 == 0 {
+			continue
+		}
+		q2 := len(q.image) - q1
+		if q2 == 0 {
+			break
+		}
+		sumB += float64(i * n)
+		m := sumB/float64(q1) - (sum-sumB)/float64(q2)
+		if variance := m * m * float64(q1) * float64(q2); variance >= max {
+			threshold, max = byte(i), variance
+		}
+	}
+	return threshold
+}
+
+// fill relabels the pixels connected to x, y from one value to another,
+// handing each filled span to fn when set
+func (q *identifier) fill(x int, y int, from uint16, to uint16, fn func(y int, left int, right int)) {
+	q.stack = append(q.stack[:0], point{x, y})
+	for len(q.stack) > 0 {
+		p := q.stack[len(q.stack)-1]
+		q.stack = q.stack[:len(q.stack)-1]
+
+		row := q.pixels[p.y*q.w : (p.y+1)*q.w]
+		if row[p.x] != from {
+			continue
+		}
+		left, right := p.x, p.x
+		for left > 0 && row[left-1] == from {
+			left--
+		}
+		for right < q.w-1 && row[right+1] == from {
+			right++
+		}
+		for i := left; i <= right; i++ {
+			row[i] = to
+		}
+		if fn != nil {
+			fn(p.y, left, right)
+		}
+
+		for _, y := range [2]int{p.y - 1, p.y + 1} {
+			if y < 0 || y >= q.h {
+				continue
+			}
+			next := q.pixels[y*q.w : (y+1)*q.w]
+			for i := left; i <= right; i++ {
+				if next[i] == from && (i == left || next[i-1] != from) {
+					q.stack = append(q.stack, point{i, y})
+				}
+			}
+		}
+	}
+}
+
+// regionCode returns the region of the dark pixel at x, y, labelling it on
+// first use, or -1 for light pixels and once regions are exhausted
+func (q *identifier) regionCode(x int, y int) int {
+	if x < 0 || y < 0 || x >= q.w || y >= q.h {
+		return -1
+	}
+
+	pixel := q.pixels[y*q.w+x]
+	if pixel >= pixelRegion {
+		return int(pixel)
+	}
+	if pixel == pixelWhite || len(q.regions) >= q.labels {
+		return -1
+	}
+
+	code := len(q.regions)
+	q.regions = append(q.regions, region{seed: point{x, y}, capstone: -1})
+	q.fill(x, y, pixel, uint16(code), func(y int, left int, right int) {
+		q.regions[code].count += right - left + 1
+	})
+	return code
+}
+
+// finderScan looks for the 1:1:3:1:1 runs of capstones along row y
+func (q *identifier) finderScan(y int) {
+	row := q.pixels[y*q.w : (y+1)*q.w]
+	check := [5]int{1, 1, 3, 1, 1}
+	var pb [5]int
+	lastColor, runLength, runCount := false, 0, 0
+
+	for x := 0; x < q.w; x++ {
+		color := row[x] != pixelWhite
+		if x > 0 && color != lastColor {
+			copy(pb[:], pb[1:])
+			pb[4] = runLength
+			runLength = 0
+			runCount++
+
+			if !color && runCount >= 5 {
+				avg := (pb[0] + pb[1] + pb[3] + pb[4]) / 4
+				err := avg * 3 / 4
+				ok := true
+				for i := range pb {
+					if pb[i] < check[i]*avg-err || pb[i] > check[i]*avg+err {
+						ok = false
+					}
+				}
+				if ok {
+					q.testCapstone(x, y, pb)
+				}
+			}
+		}
+		runLength++
+		lastColor = color
+	}
+}
+
+// testCapstone checks the runs ending at x, y form a ring around a stone
+func (q *identifier) testCapstone(x int, y int, pb [5]int) {
+	ringRight := q.regionCode(x-pb[4], y)
+	stone := q.regionCode(x-pb[4]-pb[3]-pb[2], y)
+	ringLeft := q.regionCode(x-pb[4]-pb[3]-pb[2]-pb[1]-pb[0], y)
+	if ringLeft < 0 || ringRight < 0 || stone < 0 {
+		return
+	}
+	// The ring connects both sides and is disconnected from the stone
+	if ringLeft != ringRight || ringLeft == stone {
+		return
+	}
+
+	stoneReg, ringReg := &q.regions[stone], &q.regions[ringLeft]
+	if stoneReg.capstone >= 0 || ringReg.capstone >= 0 {
+		return
+	}
+	// The ratio should ideally be 37.5
+	ratio := stoneReg.count * 100 / ringReg.count
+	if ratio < 10 || ratio > 70 {
+		return
+	}
+
+	q.recordCapstone(ringLeft, stone)
+}
+
+// recordCapstone adds the capstone made of a ring and a stone
+func (q *identifier) recordCapstone(ring int, stone int) {
+	if len(q.capstones) >= maxCapstones {
+		return
+	}
+
+	index := len(q.capstones)
+	q.capstones = append(q.capstones, capstone{ring: ring, stone: stone, grid: -1})
+	cs := &q.capstones[index]
+	q.regions[stone].capstone = index
+	q.regions[ring].capstone = index
+
+	cs.corners = q.regionCorners(ring, q.regions[stone].seed)
+	cs.c.setup(cs.corners, 7, 7)
+	cs.center = cs.c.project(3.5, 3.5)
+}
+
+// regionCorners finds the four corners of a region, the first one being the
+// farthest from ref and the others following clockwise
+func (q *identifier) regionCorners(code int, ref point) [4]point {
+	reg := q.regions[code]
+	corners := [4]point{}
+	best := -1
+	q.fill(reg.seed.x, reg.seed.y, uint16(code), pixelBlack, func(y int, left int, right int) {
+		dy := y - ref.y
+		for _, x := range [2]int{left, right} {
+			dx := x - ref.x
+			if d := dx*dx + dy*dy; d > best {
+				best = d
+				corners[0] = point{x, y}
+			}
+		}
+	})
+
+	ref = point{corners[0].x - ref.x, corners[0].y - ref.y}
+	for i := range corners {
+		corners[i] = reg.seed
+	}
+	up := reg.seed.x*ref.x + reg.seed.y*ref.y
+	right := reg.seed.x*-ref.y + reg.seed.y*ref.x
+	scores := [4]int{up, right, -up, -right}
+
+	q.fill(reg.seed.x, reg.seed.y, pixelBlack, uint16(code), func(y int, left int, right int) {
+		for _, x := range [2]int{left, right} {
+			up := x*ref.x + y*ref.y
+			right := x*-ref.y + y*ref.x
+			for j, score := range [4]int{up, right, -up, -right} {
+				if score > scores[j] {
+					scores[j] = score
+					corners[j] = point{x, y}
+				}
+			}
+		}
+	})
+	return corners
+}
+
+// neighbour is a capstone aligned with another one, at distance in modules
+type neighbour struct {
+	index    int
+	distance float64
+}
+
+// testGrouping looks for the capstones aligned horizontally and vertically
+// with capstone i, which are the corners of a qrcode where i is top left
+func (q *identifier) testGrouping(i int) {
+	c1 := &q.capstones[i]
+	if c1.grid >= 0 {
+		return
+	}
+	var hlist, vlist []neighbour
+
+	// Capstones already grouped belong to another qrcode
+	for j := range q.capstones {
+		if i == j || q.capstones[j].grid >= 0 {
+			continue
+		}
+		u, v := c1.c.unproject(q.capstones[j].center)
+		u, v = math.Abs(u-3.5), math.Abs(v-3.5)
+		if u < 0.2*v {
+			hlist = append(hlist, neighbour{j, v})
+		}
+		if v < 0.2*u {
+			vlist = append(vlist, neighbour{j, u})
+		}
+	}
+	if len(hlist) == 0 || len(vlist) == 0 {
+		return
+	}
+
+	bestH, bestV, bestScore := -1, -1, 0.0
+	for _, hn := range hlist {
+		for _, vn := range vlist {
+			score := math.Abs(1 - hn.distance/vn.distance)
+			if score > 2.5 {
+				continue
+			}
+			if bestH < 0 || score < bestScore {
+				bestH, bestV, bestScore = hn.index, vn.index, score
+			}
+		}
+	}
+	if bestH < 0 || bestV < 0 {
+		return
+	}
+
+	q.recordGrid(bestH, i, bestV)
+}
+
+// recordGrid adds the qrcode whose top left capstone is b, a and c being the
+// two others
+func (q *identifier) recordGrid(a int, b int, c int) {
+	if len(q.grids) >= maxGrids {
+		return
+	}
+
+	// b should be on the left of the hypotenuse from a to c, making a, b
+	// and c clockwise
+	h0 := q.capstones[a].center
+	hd := point{q.capstones[c].center.x - h0.x, q.capstones[c].center.y - h0.y}
+	if (q.capstones[b].center.x-h0.x)*-hd.y+(q.capstones[b].center.y-h0.y)*hd.x > 0 {
+		a, c = c, a
+		hd = point{-hd.x, -hd.y}
+	}
+
+	index := len(q.grids)
+	q.grids = append(q.grids, qrGrid{caps: [3]int{a, b, c}, alignRegion: -1})
+	qr := &q.grids[index]
+
+	// Rotate each capstone so that its first corner is top left with
+	// respect to the grid
+	for _, i := range qr.caps {
+		q.capstones[i].rotate(h0, hd)
+		q.capstones[i].grid = index
+	}
+
+	ok := q.measureTiming(qr)
+	if ok {
+		// The alignment pattern is first estimated by extending the sides
+		// of capstones a and c
+		qr.align, ok = intersect(q.capstones[a].corners[0], q.capstones[a].corners[1],
+			q.capstones[c].corners[0], q.capstones[c].corners[3])
+	}
+	if !ok {
+		for _, i := range qr.caps {
+			q.capstones[i].grid = -1
+		}
+		q.grids = q.grids[:index]
+		return
+	}
+
+	// Grids above version 1 have an actual alignment pattern, whose point
+	// closest to the top left of the grid is used
+	if qr.size > 21 {
+		q.findAlignment(qr)
+		if qr.alignRegion >= 0 {
+			reg := q.regions[qr.alignRegion]
+			qr.align = reg.seed
+			score := -hd.y*qr.align.x + hd.x*qr.align.y
+			q.fill(reg.seed.x, reg.seed.y, uint16(qr.alignRegion), pixelBlack, nil)
+			q.fill(reg.seed.x, reg.seed.y, pixelBlack, uint16(qr.alignRegion), func(y int, left int, right int) {
+				for _, x := range [2]int{left, right} {
+					if d := -hd.y*x + hd.x*y; d < score {
+						score = d
+						qr.align = point{x, y}
+					}
+				}
+			})
+		}
+	}
+
+	q.setupGrid(qr)
+}
+
+// rotate turns the corners of a capstone so that the first one is the
+// farthest on the left of the line from h0 along hd
+func (cs *capstone) rotate(h0 point, hd point) {
+	best, bestScore := 0, 0
+	for j, p := range cs.corners {
+		score := (p.x-h0.x)*-hd.y + (p.y-h0.y)*hd.x
+		if j == 0 || score < bestScore {
+			best, bestScore = j, score
+		}
+	}
+
+	var corners [4]point
+	for j := range corners {
+		corners[j] = cs.corners[(j+best)%4]
+	}
+	cs.corners = corners
+	cs.c.setup(cs.corners, 7, 7)
+}
+
+// measureTiming reads the timing patterns between the capstones of a grid
+// to estimate its size
+func (q *identifier) measureTiming(qr *qrGrid) bool {
+	us := [3]float64{6.5, 6.5, 0.5}
+	vs := [3]float64{0.5, 6.5, 6.5}
+	for i, c := range qr.caps {
+		qr.tpep[i] = q.capstones[c].c.project(us[i], vs[i])
+	}
+
+	scan := q.timingScan(qr.tpep[1], qr.tpep[2])
+	if v := q.timingScan(qr.tpep[1], qr.tpep[0]); v > scan {
+		scan = v
+	}
+	if scan < 0 {
+		return false
+	}
+
+	// Choose the nearest allowable grid size
+	version := (scan*2 + 13 - 15) / 4
+	if version > qrspec.MaxVersion {
+		return false
+	}
+	qr.size = version*4 + 17
+	return true
+}
+
+// timingScan counts the dark modules met along the line from p0 to p1, or
+// returns -1 when it leaves the image
+func (q *identifier) timingScan(p0 point, p1 point) int {
+	if !q.inside(p0) || !q.inside(p1) {
+		return -1
+	}
+
+	n, d := p1.x-p0.x, p1.y-p0.y
+	x, y := p0.x, p0.y
+	dom, nondom := &y, &x
+	if abs(n) > abs(d) {
+		n, d = d, n
+		dom, nondom = &x, &y
+	}
+	nondomStep, domStep := 1, 1
+	if n < 0 {
+		n, nondomStep = -n, -1
+	}
+	if d < 0 {
+		d, domStep = -d, -1
+	}
+
+	a, runLength, count := 0, 0, 0
+	for i := 0; i <= d; i++ {
+		if y < 0 || y >= q.h || x < 0 || x >= q.w {
+			break
+		}
+		if q.pixels[y*q.w+x] != pixelWhite {
+			if runLength >= 2 {
+				count++
+			}
+			runLength = 0
+		} else {
+			runLength++
+		}
+
+		a += n
+		*dom += domStep
+		if a >= d {
+			*nondom += nondomStep
+			a -= d
+		}
+	}
+	return count
+}
+
+// inside tells whether p lies within the source image
+func (q *identifier) inside(p point) bool {
+	return p.x >= 0 && p.y >= 0 && p.x < q.w && p.y < q.h
+}
+
+// intersect returns the intersection of the line through p0 and p1 with the
+// one through q0 and q1, failing when they are parallel
+func intersect(p0 point, p1 point, q0 point, q1 point) (point, bool) {
+	// (a, b) and (c, d) are perpendicular to both lines
+	a, b := -(p1.y - p0.y), p1.x-p0.x
+	c, d := -(q1.y - q0.y), q1.x-q0.x
+	e, f := a*p1.x+b*p1.y, c*q1.x+d*q1.y
+
+	det := a*d - b*c
+	if det == 0 {
+		return point{}, false
+	}
+	return point{(d*e - b*f) / det, (-c*e + a*f) / det}, true
+}
+
+// findAlignment spirals out of the estimated alignment pattern of a grid
+// until it meets a region of about the size of a module
+func (q *identifier) findAlignment(qr *qrGrid) {
+	c0, c2 := &q.capstones[qr.caps[0]], &q.capstones[qr.caps[2]]
+	b := qr.align
+
+	// Guess two other corners of the alignment pattern to estimate its size
+	u, v := c0.c.unproject(b)
+	a := c0.c.project(u, v+1)
+	u, v = c2.c.unproject(b)
+	c := c2.c.project(u+1, v)
+	estimate := abs((a.x-b.x)*-(c.y-b.y) + (a.y-b.y)*(c.x-b.x))
+
+	dxs := [4]int{1, 0, -1, 0}
+	dys := [4]int{0, -1, 0, 1}
+	step, dir := 1, 0
+	for step*step < estimate*100 {
+		for i := 0; i < step; i++ {
+			if code := q.regionCode(b.x, b.y); code >= 0 {
+				if count := q.regions[code].count; count >= estimate/2 && count <= estimate*2 {
+					qr.alignRegion = code
+					return
+				}
+			}
+			b.x += dxs[dir]
+			b.y += dys[dir]
+		}
+		dir = (dir + 1) % 4
+		if dir&1 == 0 {
+			step++
+		}
+	}
+}
+
+// setupGrid maps the grid from the corners of its capstones and alignment
+// pattern, then refines the mapping
+func (q *identifier) setupGrid(qr *qrGrid) {
+	rect := [4]point{
+		q.capstones[qr.caps[1]].corners[0],
+		q.capstones[qr.caps[2]].corners[0],
+		qr.align,
+		q.capstones[qr.caps[0]].corners[0],
+	}
+	qr.c.setup(rect, float64(qr.size-7), float64(qr.size-7))
+	q.jiggle(qr)
+}
+
+// jiggle adjusts each coefficient of the mapping of a grid in turn, keeping
+// the changes which improve its fitness
+func (q *identifier) jiggle(qr *qrGrid) {
+	best := q.fitnessAll(qr)
+	var adjustments [8]float64
+	for i := range adjustments {
+		adjustments[i] = qr.c[i] * 0.02
+	}
+
+	for pass := 0; pass < 5; pass++ {
+		for i := 0; i < 16; i++ {
+			j := i >> 1
+			old := qr.c[j]
+			if i&1 != 0 {
+				qr.c[j] = old + adjustments[j]
+			} else {
+				qr.c[j] = old - adjustments[j]
+			}
+			if test := q.fitnessAll(qr); test > best {
+				best = test
+			} else {
+				qr.c[j] = old
+			}
+		}
+		for i := range adjustments {
+			adjustments[i] *= 0.5
+		}
+	}
+}
+
+// fitnessCell scores how dark the module at x, y of a grid looks
+func (q *identifier) fitnessCell(qr *qrGrid, x int, y int) int {
+	offsets := [3]float64{0.3, 0.5, 0.7}
+	score := 0
+	for _, v := range offsets {
+		for _, u := range offsets {
+			p := qr.c.project(float64(x)+u, float64(y)+v)
+			if !q.inside(p) {
+				continue
+			}
+			if q.pixels[p.y*q.w+p.x] != pixelWhite {
+				score++
+			} else {
+				score--
+			}
+		}
+	}
+	return score
+}
+
+// fitnessRing scores how dark the ring of modules at radius around cx, cy
+// looks
+func (q *identifier) fitnessRing(qr *qrGrid, cx int, cy int, radius int) int {
+	score := 0
+	for i := 0; i < radius*2; i++ {
+		score += q.fitnessCell(qr, cx-radius+i, cy-radius)
+		score += q.fitnessCell(qr, cx-radius, cy+radius-i)
+		score += q.fitnessCell(qr, cx+radius, cy-radius+i)
+		score += q.fitnessCell(qr, cx+radius-i, cy+radius)
+	}
+	return score
+}
+
+// fitnessAlignment scores the alignment pattern centered on cx, cy
+func (q *identifier) fitnessAlignment(qr *qrGrid, cx int, cy int) int {
+	return q.fitnessCell(qr, cx, cy) - q.fitnessRing(qr, cx, cy, 1) + q.fitnessRing(qr, cx, cy, 2)
+}
+
+// fitnessCapstone scores the capstone whose top left module is x, y
+func (q *identifier) fitnessCapstone(qr *qrGrid, x int, y int) int {
+	x, y = x+3, y+3
+	return q.fitnessCell(qr, x, y) + q.fitnessRing(qr, x, y, 1) - q.fitnessRing(qr, x, y, 2) + q.fitnessRing(qr, x, y, 3)
+}
+
+// fitnessAll scores the timing patterns, capstones and alignment patterns of
+// a grid
+func (q *identifier) fitnessAll(qr *qrGrid) int {
+	score := 0
+	for i := 0; i < qr.size-14; i++ {
+		expect := -1
+		if i&1 != 0 {
+			expect = 1
+		}
+		score += q.fitnessCell(qr, i+7, 6) * expect
+		score += q.fitnessCell(qr, 6, i+7) * expect
+	}
+
+	score += q.fitnessCapstone(qr, 0, 0)
+	score += q.fitnessCapstone(qr, qr.size-7, 0)
+	score += q.fitnessCapstone(qr, 0, qr.size-7)
+
+	version := (qr.size - 17) / 4
+	if version < qrspec.MinVersion || version > qrspec.MaxVersion {
+		return score
+	}
+	positions := qrspec.AlignmentPositions(version)
+	for i := 1; i+1 < len(positions); i++ {
+		score += q.fitnessAlignment(qr, 6, positions[i])
+		score += q.fitnessAlignment(qr, positions[i], 6)
+	}
+	for i := 1; i < len(positions); i++ {
+		for j := 1; j < len(positions); j++ {
+			score += q.fitnessAlignment(qr, positions[i], positions[j])
+		}
+	}
+	return score
+}
+
+// extract reads the corners and the modules of grid index, a dark module
+// setting its bit in cells
+func (q *identifier) extract(index int) ([4]point, int, []byte) {
+	qr := &q.grids[index]
+	size := float64(qr.size)
+	corners := [4]point{
+		qr.c.project(0, 0),
+		qr.c.project(size, 0),
+		qr.c.project(size, size),
+		qr.c.project(0, size),
+	}
+
+	cells := make([]byte, (qr.size*qr.size+7)/8)
+	i := 0
+	for y := 0; y < qr.size; y++ {
+		for x := 0; x < qr.size; x++ {
+			p := qr.c.project(float64(x)+0.5, float64(y)+0.5)
+			if q.inside(p) && q.pixels[p.y*q.w+p.x] != pixelWhite {
+				cells[i>>3] |= 1 << uint(i&7)
+			}
+			i++
+		}
+	}
+	return corners, qr.size, cells
+}
+
+// perspective maps grid coordinates u, v to the image as
+// x = (c0*u + c1*v + c2) / (c6*u + c7*v + 1) and
+// y = (c3*u + c4*v + c5) / (c6*u + c7*v + 1)
+type perspective [8]float64
+
+// setup maps the rectangle of w by h modules onto the corners of rect, given
+// clockwise from the top left one
+func (c *perspective) setup(rect [4]point, w float64, h float64) {
+	x0, y0 := float64(rect[0].x), float64(rect[0].y)
+	x1, y1 := float64(rect[1].x), float64(rect[1].y)
+	x2, y2 := float64(rect[2].x), float64(rect[2].y)
+	x3, y3 := float64(rect[3].x), float64(rect[3].y)
+
+	// Map the unit square first, then scale it to w by h
+	dx1, dx2, dx3 := x1-x2, x3-x2, x0-x1+x2-x3
+	dy1, dy2, dy3 := y1-y2, y3-y2, y0-y1+y2-y3
+	den := dx1*dy2 - dx2*dy1
+	g := (dx3*dy2 - dx2*dy3) / den
+	k := (dx1*dy3 - dx3*dy1) / den
+
+	*c = perspective{
+		(x1 - x0 + g*x1) / w, (x3 - x0 + k*x3) / h, x0,
+		(y1 - y0 + g*y1) / w, (y3 - y0 + k*y3) / h, y0,
+		g / w, k / h,
+	}
+}
+
+// project maps grid coordinates to the nearest pixel
+func (c *perspective) project(u float64, v float64) point {
+	den := c[6]*u + c[7]*v + 1
+	x := (c[0]*u + c[1]*v + c[2]) / den
+	y := (c[3]*u + c[4]*v + c[5]) / den
+	return point{round(x), round(y)}
+}
+
+// unproject maps a pixel back to grid coordinates
+func (c *perspective) unproject(p point) (float64, float64) {
+	x, y := float64(p.x), float64(p.y)
+	den := -c[0]*c[7]*y + c[1]*c[6]*y + (c[3]*c[7]-c[4]*c[6])*x + c[0]*c[4] - c[1]*c[3]
+	u := -(c[1]*(y-c[5]) - c[2]*c[7]*y + (c[5]*c[7]-c[4])*x + c[2]*c[4]) / den
+	v := (c[0]*(y-c[5]) - c[2]*c[6]*y + (c[5]*c[6]-c[3])*x + c[2]*c[3]) / den
+	return u, v
+}
+
+// round converts a pixel coordinate to the nearest integer like rint, out of
+// range values landing outside of any image
+func round(v float64) int {
+	if math.IsNaN(v) || v < math.MinInt32 || v > math.MaxInt32 {
+		return -1
+	}
+	return int(math.RoundToEven(v))
+}
+
+// abs returns the absolute value of v
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,83 @@
+package goquirc
+
+import (
+	"errors"
+	"image"
+)
+
+// RevealImage works like Reveal but accepts any image.Image as source
+// and handles the luminance conversion internally
+func (qr *Processing) RevealImage(img image.Image, opts ...Option) (Result, error) {
+	gray, w, h := Luminance(img)
+	return qr.Reveal(&gray, w, h, opts...)
+}
+
+// LoadGray loads a grayscale image whose dimensions match the ones given to
+// Resize, copied at once when its rows are contiguous
+func (qr *Processing) LoadGray(img *image.Gray) error {
+	bounds := img.Bounds()
+	buffer, w, h := qr.buffer()
+	if bounds.Dx() != w || bounds.Dy() != h {
+		return errors.New("Image dimensions do not match buffer")
+	}
+	if w == 0 || h == 0 {
+		return nil
+	}
+
+	copyPlane(buffer, img.Pix[img.PixOffset(bounds.Min.X, bounds.Min.Y):], w, h, img.Stride)
+	return nil
+}
+
+// Luminance converts an image into a tightly packed 8 bits grayscale buffer
+// and returns it with its dimensions, ready for Reveal. Translucent pixels
+// are composited over white, as transparent backgrounds are meant to show
+// light modules. The pixels of an *image.Gray whose rows are contiguous are
+// returned without copy
+func Luminance(img image.Image) ([]byte, int, int) {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w*h == 0 {
+		return nil, w, h
+	}
+	if src, ok := img.(*image.Gray); ok && src.Stride == w {
+		offset := src.PixOffset(bounds.Min.X, bounds.Min.Y)
+		return src.Pix[offset : offset+w*h], w, h
+	}
+	gray := make([]byte, w*h)
+
+	switch src := img.(type) {
+	case *image.Gray:
+		for y := 0; y < h; y++ {
+			offset := src.PixOffset(bounds.Min.X, bounds.Min.Y+y)
+			copy(gray[y*w:(y+1)*w], src.Pix[offset:offset+w])
+		}
+	case *image.RGBA:
+		offset := src.PixOffset(bounds.Min.X, bounds.Min.Y)
+		rgbaOverWhite(gray, src.Pix[offset:], w, h, src.Stride, true)
+	case *image.NRGBA:
+		offset := src.PixOffset(bounds.Min.X, bounds.Min.Y)
+		rgbaOverWhite(gray, src.Pix[offset:], w, h, src.Stride, false)
+	case *image.Gray16:
+		// Deep samples are auto scaled instead of truncated to their high byte
+		windowGray16(gray, w, h, func(x int, y int) uint16 {
+			return src.Gray16At(bounds.Min.X+x, bounds.Min.Y+y).Y
+		}, Window{})
+	case *image.YCbCr:
+		// Y plane already holds luminance
+		for y := 0; y < h; y++ {
+			offset := src.YOffset(bounds.Min.X, bounds.Min.Y+y)
+			copy(gray[y*w:(y+1)*w], src.Y[offset:offset+w])
+		}
+	default:
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				// Weights of color.GrayModel over premultiplied samples
+				r, g, b, a := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+				luma := (19595*r + 38470*g + 7471*b + 1<<15) >> 16
+				gray[y*w+x] = byte((luma + 0xffff - a) >> 8)
+			}
+		}
+	}
+
+	return gray, w, h
+}
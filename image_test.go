@@ -0,0 +1,54 @@
+package goquirc_test
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/quaresc/goquirc"
+	"github.com/quaresc/goquirc/encoder"
+)
+
+func TestRevealImageTransparentBackground(t *testing.T) {
+	symbol, err := encoder.Encode([]byte("transparent"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	paletted := symbol.Image(encoder.WithModuleSize(moduleSize), encoder.WithColors(color.Black, color.Transparent))
+	rgba := image.NewRGBA(paletted.Bounds())
+	draw.Draw(rgba, rgba.Bounds(), paletted, image.Point{}, draw.Src)
+	nrgba := image.NewNRGBA(paletted.Bounds())
+	draw.Draw(nrgba, nrgba.Bounds(), paletted, image.Point{}, draw.Src)
+
+	for name, img := range map[string]image.Image{"paletted": paletted, "rgba": rgba, "nrgba": nrgba} {
+		var qr goquirc.Processing
+		result, err := qr.RevealImage(img)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Usable != 1 || len(result.Code) != 1 || result.Code[0].Text() != "transparent" {
+			t.Errorf("%s: decoded %d qrcodes, want the transparent one", name, len(result.Code))
+		}
+	}
+}
+
+func TestLuminanceCompositesOverWhite(t *testing.T) {
+	tests := []struct {
+		name string
+		img  image.Image
+		want byte
+	}{
+		{"transparent rgba", image.NewRGBA(image.Rect(0, 0, 1, 1)), 0xff},
+		{"transparent nrgba", image.NewNRGBA(image.Rect(0, 0, 1, 1)), 0xff},
+		{"half black nrgba", &image.NRGBA{Pix: []byte{0, 0, 0, 0x80}, Stride: 4, Rect: image.Rect(0, 0, 1, 1)}, 0x7f},
+		{"half black rgba", &image.RGBA{Pix: []byte{0, 0, 0, 0x80}, Stride: 4, Rect: image.Rect(0, 0, 1, 1)}, 0x7f},
+		{"opaque gray nrgba", &image.NRGBA{Pix: []byte{0x40, 0x40, 0x40, 0xff}, Stride: 4, Rect: image.Rect(0, 0, 1, 1)}, 0x40},
+		{"transparent rgba64", image.NewRGBA64(image.Rect(0, 0, 1, 1)), 0xff},
+	}
+	for _, tt := range tests {
+		if gray, _, _ := goquirc.Luminance(tt.img); gray[0] != tt.want {
+			t.Errorf("%s: luminance %#x, want %#x", tt.name, gray[0], tt.want)
+		}
+	}
+}
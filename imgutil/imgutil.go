@@ -0,0 +1,113 @@
+// Package imgutil decodes image files into grayscale buffers ready to be
+// given to goquirc Reveal functions
+package imgutil
+
+import (
+	"bytes"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	"github.com/quaresc/goquirc"
+)
+
+// FromPNG decodes a PNG image and returns its luminance with its dimensions
+func FromPNG(r io.Reader) ([]byte, int, int, error) {
+	img, err := png.Decode(r)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	gray, w, h := goquirc.Luminance(img)
+	return gray, w, h, nil
+}
+
+// FromJPEG decodes a JPEG image and returns its luminance with its
+// dimensions, reduced so that none of them exceeds maxDim. Baseline images
+// are reduced by 2, 4 or 8 while decoding: their chroma is skipped and only
+// the low frequencies of their luminance blocks are transformed back, down to
+// the DC coefficient alone at 1/8, so that a 12MP photo costs little more than
+// its entropy decoding. Progressive images are fully decoded. Either way what
+// remains above maxDim is box filtered. A maxDim lower than one keeps the full
+// resolution
+func FromJPEG(r io.Reader, maxDim int) ([]byte, int, int, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	config, err := jpeg.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	var gray []byte
+	w, h := config.Width, config.Height
+	if n := 8 / dctFactor(reduction(w, h, maxDim)); n < 8 {
+		gray, w, h, err = scaleJPEG(data, n)
+		if err != nil && err != errUnsupported {
+			return nil, 0, 0, err
+		}
+	}
+	if gray == nil {
+		img, err := jpeg.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		gray, w, h = goquirc.Luminance(img)
+	}
+
+	if factor := reduction(w, h, maxDim); factor > 1 {
+		gray, w, h = shrink(gray, w, h, factor)
+	}
+	return gray, w, h, nil
+}
+
+// reduction returns the smallest integer factor bringing w and h down to
+// maxDim, one when maxDim is lower than one
+func reduction(w int, h int, maxDim int) int {
+	if maxDim < 1 {
+		return 1
+	}
+	factor := (w + maxDim - 1) / maxDim
+	if f := (h + maxDim - 1) / maxDim; f > factor {
+		factor = f
+	}
+	return factor
+}
+
+// dctFactor returns the largest reduction JPEG blocks can be decoded at
+// which does not exceed factor
+func dctFactor(factor int) int {
+	for _, f := range []int{8, 4, 2} {
+		if f <= factor {
+			return f
+		}
+	}
+	return 1
+}
+
+// shrink averages the pixels of each factor by factor block, the partial
+// blocks of the right and bottom edges are dropped
+func shrink(gray []byte, w int, h int, factor int) ([]byte, int, int) {
+	sw, sh := w/factor, h/factor
+	out := make([]byte, sw*sh)
+	sums := make([]uint32, sw)
+	area := uint32(factor * factor)
+
+	for y := 0; y < sh; y++ {
+		for i := range sums {
+			sums[i] = 0
+		}
+		for dy := 0; dy < factor; dy++ {
+			row := gray[(y*factor+dy)*w:]
+			for x := range sums {
+				for _, p := range row[x*factor : (x+1)*factor] {
+					sums[x] += uint32(p)
+				}
+			}
+		}
+		for x, sum := range sums {
+			out[y*sw+x] = byte((sum + area/2) / area)
+		}
+	}
+	return out, sw, sh
+}
@@ -0,0 +1,592 @@
+package imgutil
+
+import (
+	"errors"
+	"math"
+)
+
+// JPEG markers
+const (
+	markerSOF0 = 0xc0 // Baseline
+	markerSOF1 = 0xc1 // Extended sequential, Huffman coded
+	markerDHT  = 0xc4
+	markerRST0 = 0xd0
+	markerRST7 = 0xd7
+	markerSOI  = 0xd8
+	markerEOI  = 0xd9
+	markerSOS  = 0xda
+	markerDQT  = 0xdb
+	markerDRI  = 0xdd
+	markerAPPE = 0xee // Adobe, telling the color transform
+)
+
+// errUnsupported tells that a JPEG variant needs the full decoder, such as
+// progressive or arithmetic coded images
+var errUnsupported = errors.New("Unsupported JPEG variant")
+
+// unzig maps the zigzag order of coefficients to their natural order
+var unzig = [64]int{
+	0, 1, 8, 16, 9, 2, 3, 10,
+	17, 24, 32, 25, 18, 11, 4, 5,
+	12, 19, 26, 33, 40, 48, 41, 34,
+	27, 20, 13, 6, 7, 14, 21, 28,
+	35, 42, 49, 56, 57, 50, 43, 36,
+	29, 22, 15, 23, 30, 37, 44, 51,
+	58, 59, 52, 45, 38, 31, 39, 46,
+	53, 60, 61, 54, 47, 55, 62, 63,
+}
+
+// huffLookupBits is the length of the codes decoded by a single lookup
+const huffLookupBits = 9
+
+// huffman is a decoding table
+type huffman struct {
+	// lookup holds the length and value of the codes up to huffLookupBits
+	// long, indexed by the bits they start, zero for longer codes
+	lookup [1 << huffLookupBits]uint16
+
+	// maxCode is the largest code of each length, -1 when none, and
+	// valPtr the index in values of its smallest code minus that code
+	maxCode [17]int32
+	valPtr  [17]int32
+	values  []byte
+}
+
+// component is a color component of a frame
+type component struct {
+	id     byte
+	h, v   int
+	tq     int
+	dc, ac int
+	pred   int32
+}
+
+// jpegScaler decodes the luminance of a sequential Huffman coded JPEG,
+// transforming back only the n by n low frequencies of every block, its
+// chroma being entropy decoded and dropped
+type jpegScaler struct {
+	data []byte
+	pos  int
+
+	// Entropy coded bits, most significant first
+	bits   uint32
+	nbits  uint
+	atMark bool
+
+	n       int
+	cos     [8][8]float32
+	quant   [4][64]int32
+	dcTable [4]*huffman
+	acTable [4]*huffman
+
+	width, height int
+	hmax, vmax    int
+	comps         []component
+	restart       int
+	adobe         bool
+	transform     byte
+
+	// plane holds the luminance of whole blocks, stride wide
+	plane  []byte
+	stride int
+	done   bool
+}
+
+// scaleJPEG decodes the luminance of a JPEG scaled down by 8/n, n being 1, 2
+// or 4, and returns errUnsupported for the variants it cannot decode
+func scaleJPEG(data []byte, n int) ([]byte, int, int, error) {
+	d := &jpegScaler{data: data, n: n}
+	for x := 0; x < n; x++ {
+		for u := 0; u < n; u++ {
+			c := math.Cos(float64((2*x+1)*u) * math.Pi / float64(2*n))
+			if u == 0 {
+				c /= math.Sqrt2
+			}
+			d.cos[x][u] = float32(c)
+		}
+	}
+	if err := d.decode(); err != nil {
+		return nil, 0, 0, err
+	}
+
+	// Blocks overhanging the image are cropped
+	w, h := (d.width*n+7)/8, (d.height*n+7)/8
+	gray := make([]byte, w*h)
+	for y := 0; y < h; y++ {
+		copy(gray[y*w:(y+1)*w], d.plane[y*d.stride:])
+	}
+	return gray, w, h, nil
+}
+
+// decode parses the segments up to the end of the image
+func (d *jpegScaler) decode() error {
+	if len(d.data) < 2 || d.data[0] != 0xff || d.data[1] != markerSOI {
+		return errors.New("Invalid JPEG, missing SOI marker")
+	}
+	d.pos = 2
+	for {
+		marker, segment, err := d.segment()
+		if err != nil {
+			return err
+		}
+		switch {
+		case marker == markerEOI:
+			if !d.done {
+				return errors.New("Invalid JPEG, missing luminance scan")
+			}
+			return nil
+		case marker == markerSOF0 || marker == markerSOF1:
+			err = d.frame(segment)
+		case marker >= 0xc2 && marker <= 0xcf && marker != markerDHT && marker != 0xc8 && marker != 0xcc:
+			// Progressive, lossless, hierarchical or arithmetic coded
+			return errUnsupported
+		case marker == markerDHT:
+			err = d.huffmanTables(segment)
+		case marker == markerDQT:
+			err = d.quantTables(segment)
+		case marker == markerDRI:
+			if len(segment) != 2 {
+				return errors.New("Invalid JPEG DRI segment")
+			}
+			d.restart = int(segment[0])<<8 | int(segment[1])
+		case marker == markerAPPE:
+			if len(segment) >= 12 && string(segment[:5]) == "Adobe" {
+				d.adobe, d.transform = true, segment[11]
+			}
+		case marker == markerSOS:
+			err = d.scan(segment)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// segment reads the next marker and the payload following it
+func (d *jpegScaler) segment() (byte, []byte, error) {
+	// Skip the fill bytes preceding markers
+	for d.pos < len(d.data) && d.data[d.pos] != 0xff {
+		d.pos++
+	}
+	for d.pos < len(d.data) && d.data[d.pos] == 0xff {
+		d.pos++
+	}
+	if d.pos >= len(d.data) {
+		return 0, nil, errors.New("Invalid JPEG, missing EOI marker")
+	}
+	marker := d.data[d.pos]
+	d.pos++
+	if marker == markerEOI {
+		return marker, nil, nil
+	}
+	if d.pos+2 > len(d.data) {
+		return 0, nil, errors.New("Truncated JPEG segment")
+	}
+	length := int(d.data[d.pos])<<8 | int(d.data[d.pos+1])
+	if length < 2 || d.pos+length > len(d.data) {
+		return 0, nil, errors.New("Truncated JPEG segment")
+	}
+	segment := d.data[d.pos+2 : d.pos+length]
+	d.pos += length
+	return marker, segment, nil
+}
+
+// frame parses a start of frame segment and allocates the luminance plane
+func (d *jpegScaler) frame(s []byte) error {
+	if d.comps != nil {
+		return errors.New("Invalid JPEG, multiple frames")
+	}
+	if len(s) < 6 {
+		return errors.New("Invalid JPEG SOF segment")
+	}
+	if s[0] != 8 {
+		// 12 bits samples
+		return errUnsupported
+	}
+	d.height, d.width = int(s[1])<<8|int(s[2]), int(s[3])<<8|int(s[4])
+	count := int(s[5])
+	if d.height == 0 || d.width == 0 || (count != 1 && count != 3) {
+		// Heights defined by a DNL marker, or CMYK images
+		return errUnsupported
+	}
+	if len(s) != 6+3*count {
+		return errors.New("Invalid JPEG SOF segment")
+	}
+
+	d.comps = make([]component, count)
+	for i := range d.comps {
+		c := &d.comps[i]
+		c.id, c.h, c.v, c.tq = s[6+3*i], int(s[7+3*i]>>4), int(s[7+3*i]&15), int(s[8+3*i])
+		if c.h < 1 || c.h > 4 || c.v < 1 || c.v > 4 || c.tq > 3 {
+			return errors.New("Invalid JPEG SOF segment")
+		}
+		if c.h > d.hmax {
+			d.hmax = c.h
+		}
+		if c.v > d.vmax {
+			d.vmax = c.v
+		}
+	}
+	if count == 1 {
+		// A single component is decoded block by block whatever its factors
+		d.comps[0].h, d.comps[0].v, d.hmax, d.vmax = 1, 1, 1, 1
+	} else if d.comps[0].h != d.hmax || d.comps[0].v != d.vmax {
+		return errUnsupported
+	}
+
+	mcuW, mcuH := 8*d.hmax, 8*d.vmax
+	blocksX := (d.width + mcuW - 1) / mcuW * d.hmax
+	blocksY := (d.height + mcuH - 1) / mcuH * d.vmax
+	d.stride = blocksX * d.n
+	d.plane = make([]byte, d.stride*blocksY*d.n)
+	return nil
+}
+
+// quantTables parses a DQT segment, whose tables are kept in zigzag order
+func (d *jpegScaler) quantTables(s []byte) error {
+	for len(s) > 0 {
+		precision, id := s[0]>>4, int(s[0]&15)
+		size := 65
+		if precision == 1 {
+			size = 129
+		}
+		if id > 3 || precision > 1 || len(s) < size {
+			return errors.New("Invalid JPEG DQT segment")
+		}
+		for k := range d.quant[id] {
+			if precision == 0 {
+				d.quant[id][k] = int32(s[1+k])
+			} else {
+				d.quant[id][k] = int32(s[1+2*k])<<8 | int32(s[2+2*k])
+			}
+		}
+		s = s[size:]
+	}
+	return nil
+}
+
+// huffmanTables parses a DHT segment
+func (d *jpegScaler) huffmanTables(s []byte) error {
+	for len(s) > 0 {
+		if len(s) < 17 {
+			return errors.New("Invalid JPEG DHT segment")
+		}
+		class, id := s[0]>>4, int(s[0]&15)
+		if class > 1 || id > 3 {
+			return errors.New("Invalid JPEG DHT segment")
+		}
+		total := 0
+		for _, count := range s[1:17] {
+			total += int(count)
+		}
+		if total > 256 || len(s) < 17+total {
+			return errors.New("Invalid JPEG DHT segment")
+		}
+
+		t := &huffman{values: s[17 : 17+total]}
+		code, k := int32(0), int32(0)
+		for length := 1; length <= 16; length++ {
+			count := int32(s[length])
+			if code+count > 1<<length {
+				return errors.New("Invalid JPEG DHT segment")
+			}
+			t.valPtr[length] = k - code
+			t.maxCode[length] = -1
+			if count > 0 {
+				t.maxCode[length] = code + count - 1
+			}
+			for i := int32(0); i < count; i++ {
+				if length <= huffLookupBits {
+					first := (code + i) << (huffLookupBits - length)
+					for j := int32(0); j < 1<<(huffLookupBits-length); j++ {
+						t.lookup[first+j] = uint16(length)<<8 | uint16(t.values[k+i])
+					}
+				}
+			}
+			k += count
+			code = (code + count) << 1
+		}
+		if class == 0 {
+			d.dcTable[id] = t
+		} else {
+			d.acTable[id] = t
+		}
+		s = s[17+total:]
+	}
+	return nil
+}
+
+// scan decodes the entropy coded segment following a start of scan,
+// skipping the ones leaving out the luminance
+func (d *jpegScaler) scan(s []byte) error {
+	if d.comps == nil || len(s) < 1 || len(s) != 4+2*int(s[0]) {
+		return errors.New("Invalid JPEG SOS segment")
+	}
+	if d.adobe && d.transform == 0 && len(d.comps) == 3 {
+		// RGB samples, whose first component is not the luminance
+		return errUnsupported
+	}
+	if len(d.comps) == 3 && !d.adobe && d.comps[0].id == 'R' && d.comps[1].id == 'G' && d.comps[2].id == 'B' {
+		return errUnsupported
+	}
+
+	var scanned []*component
+	luminance := false
+	for i := 0; i < int(s[0]); i++ {
+		id, tables := s[1+2*i], s[2+2*i]
+		var c *component
+		for j := range d.comps {
+			if d.comps[j].id == id {
+				c = &d.comps[j]
+			}
+		}
+		if c == nil || tables>>4 > 3 || tables&15 > 3 || d.dcTable[tables>>4] == nil || d.acTable[tables&15] == nil {
+			return errors.New("Invalid JPEG SOS segment")
+		}
+		c.dc, c.ac, c.pred = int(tables>>4), int(tables&15), 0
+		luminance = luminance || c == &d.comps[0]
+		scanned = append(scanned, c)
+	}
+	if !luminance || d.done {
+		d.skipEntropy()
+		return nil
+	}
+
+	d.bits, d.nbits, d.atMark = 0, 0, false
+	var err error
+	if len(scanned) == 1 {
+		err = d.decodeLuminance()
+	} else {
+		err = d.decodeInterleaved(scanned)
+	}
+	if err != nil {
+		return err
+	}
+	d.done = true
+	d.skipEntropy()
+	return nil
+}
+
+// decodeLuminance decodes a scan holding the luminance alone, whose blocks
+// come in raster order rather than grouped by MCU
+func (d *jpegScaler) decodeLuminance() error {
+	c := &d.comps[0]
+	w := (d.width*c.h/d.hmax + 7) / 8
+	h := (d.height*c.v/d.vmax + 7) / 8
+	var coefs [64]int32
+	for i := 0; i < w*h; i++ {
+		if err := d.restartAt(i); err != nil {
+			return err
+		}
+		if err := d.block(c, &coefs, true); err != nil {
+			return err
+		}
+		d.transformBlock(&coefs, i%w, i/w)
+	}
+	return nil
+}
+
+// decodeInterleaved decodes a scan holding the luminance along with the
+// chroma, MCU by MCU
+func (d *jpegScaler) decodeInterleaved(scanned []*component) error {
+	mcusX := (d.width + 8*d.hmax - 1) / (8 * d.hmax)
+	mcusY := (d.height + 8*d.vmax - 1) / (8 * d.vmax)
+	var coefs [64]int32
+	for i := 0; i < mcusX*mcusY; i++ {
+		if err := d.restartAt(i); err != nil {
+			return err
+		}
+		mx, my := i%mcusX, i/mcusX
+		for _, c := range scanned {
+			keep := c == &d.comps[0]
+			for by := 0; by < c.v; by++ {
+				for bx := 0; bx < c.h; bx++ {
+					if err := d.block(c, &coefs, keep); err != nil {
+						return err
+					}
+					if keep {
+						d.transformBlock(&coefs, mx*c.h+bx, my*c.v+by)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// restartAt consumes the restart marker due before the MCU i, if any
+func (d *jpegScaler) restartAt(i int) error {
+	if d.restart == 0 || i == 0 || i%d.restart != 0 {
+		return nil
+	}
+	d.bits, d.nbits, d.atMark = 0, 0, false
+	for d.pos < len(d.data) && d.data[d.pos] == 0xff {
+		d.pos++
+	}
+	if d.pos >= len(d.data) || d.data[d.pos] < markerRST0 || d.data[d.pos] > markerRST7 {
+		return errors.New("Invalid JPEG, missing restart marker")
+	}
+	d.pos++
+	for j := range d.comps {
+		d.comps[j].pred = 0
+	}
+	return nil
+}
+
+// skipEntropy moves past the entropy coded data up to the next marker
+func (d *jpegScaler) skipEntropy() {
+	for ; d.pos+1 < len(d.data); d.pos++ {
+		next := d.data[d.pos+1]
+		if d.data[d.pos] == 0xff && next != 0 && next != 0xff && (next < markerRST0 || next > markerRST7) {
+			return
+		}
+	}
+	d.pos = len(d.data)
+}
+
+// fill loads entropy coded bytes until at least 25 bits are buffered,
+// unstuffing the zeros following 0xff bytes and feeding zeros once a marker
+// is reached
+func (d *jpegScaler) fill() {
+	for d.nbits <= 24 {
+		var c byte
+		switch {
+		case d.atMark || d.pos >= len(d.data):
+			d.atMark = true
+		case d.data[d.pos] != 0xff:
+			c = d.data[d.pos]
+			d.pos++
+		case d.pos+1 < len(d.data) && d.data[d.pos+1] == 0:
+			c = 0xff
+			d.pos += 2
+		default:
+			d.atMark = true
+		}
+		d.bits |= uint32(c) << (24 - d.nbits)
+		d.nbits += 8
+	}
+}
+
+// receive reads n bits and extends their sign as the coding of coefficients
+// requires
+func (d *jpegScaler) receive(n uint) int32 {
+	if n == 0 {
+		return 0
+	}
+	d.fill()
+	v := int32(d.bits >> (32 - n))
+	d.bits <<= n
+	d.nbits -= n
+	if v < 1<<(n-1) {
+		v += -1<<n + 1
+	}
+	return v
+}
+
+// symbol decodes a Huffman coded symbol
+func (d *jpegScaler) symbol(t *huffman) (byte, error) {
+	d.fill()
+	if entry := t.lookup[d.bits>>(32-huffLookupBits)]; entry != 0 {
+		length := uint(entry >> 8)
+		d.bits <<= length
+		d.nbits -= length
+		return byte(entry), nil
+	}
+
+	code := int32(d.bits >> (32 - huffLookupBits))
+	for length := huffLookupBits + 1; length <= 16; length++ {
+		code = code<<1 | int32(d.bits>>(32-length)&1)
+		if code <= t.maxCode[length] {
+			d.bits <<= uint(length)
+			d.nbits -= uint(length)
+			return t.values[t.valPtr[length]+code], nil
+		}
+	}
+	return 0, errors.New("Invalid JPEG Huffman code")
+}
+
+// block decodes the coefficients of a block, dequantized and in natural
+// order when keep is set, only the n by n low frequencies being kept
+func (d *jpegScaler) block(c *component, coefs *[64]int32, keep bool) error {
+	s, err := d.symbol(d.dcTable[c.dc])
+	if err != nil {
+		return err
+	}
+	if s > 11 {
+		return errors.New("Invalid JPEG DC coefficient")
+	}
+	c.pred += d.receive(uint(s))
+	q := &d.quant[c.tq]
+	if keep {
+		*coefs = [64]int32{}
+		coefs[0] = c.pred * q[0]
+	}
+
+	for k := 1; k < 64; k++ {
+		rs, err := d.symbol(d.acTable[c.ac])
+		if err != nil {
+			return err
+		}
+		run, size := int(rs>>4), uint(rs&15)
+		if size == 0 {
+			if run != 15 {
+				// End of block
+				break
+			}
+			k += 15
+			continue
+		}
+		if k += run; k > 63 {
+			return errors.New("Invalid JPEG AC coefficient")
+		}
+		v := d.receive(size)
+		if i := unzig[k]; keep && i/8 < d.n && i%8 < d.n {
+			coefs[i] = v * q[k]
+		}
+	}
+	return nil
+}
+
+// transformBlock computes the n by n inverse DCT of the low frequencies of
+// a block, the 1/4 factor of the 8 by 8 transform making up for the loss of
+// the others, and stores its samples at block bx, by of the plane
+func (d *jpegScaler) transformBlock(coefs *[64]int32, bx int, by int) {
+	n := d.n
+	out := d.plane[by*n*d.stride+bx*n:]
+	if n == 1 {
+		out[0] = clampSample(float32(coefs[0]) / 8)
+		return
+	}
+
+	var rows [8][8]float32
+	for v := 0; v < n; v++ {
+		for x := 0; x < n; x++ {
+			var sum float32
+			for u := 0; u < n; u++ {
+				sum += float32(coefs[v*8+u]) * d.cos[x][u]
+			}
+			rows[v][x] = sum
+		}
+	}
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			var sum float32
+			for v := 0; v < n; v++ {
+				sum += rows[v][x] * d.cos[y][v]
+			}
+			out[y*d.stride+x] = clampSample(sum / 4)
+		}
+	}
+}
+
+// clampSample level shifts and rounds a sample to a byte
+func clampSample(v float32) byte {
+	v += 128.5
+	switch {
+	case v < 0:
+		return 0
+	case v > 255:
+		return 255
+	}
+	return byte(v)
+}
@@ -0,0 +1,64 @@
+package imgutil
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"math"
+	"testing"
+
+	"github.com/quaresc/goquirc"
+)
+
+func TestScaleJPEGMatchesFullDecode(t *testing.T) {
+	// Smooth content, whose high frequencies are negligible
+	src := image.NewYCbCr(image.Rect(0, 0, 203, 157), image.YCbCrSubsampleRatio420)
+	for y := 0; y < 157; y++ {
+		for x := 0; x < 203; x++ {
+			src.Y[y*src.YStride+x] = byte(128 + 60*math.Sin(float64(x)/9) + 50*math.Cos(float64(y)/23+float64(x)/40))
+		}
+	}
+	for i := range src.Cb {
+		src.Cb[i], src.Cr[i] = 100, 150
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, src, &jpeg.Options{Quality: 100}); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := jpeg.Decode(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	full, w, h := goquirc.Luminance(decoded)
+
+	for _, n := range []int{1, 2, 4} {
+		gray, sw, sh, err := scaleJPEG(buf.Bytes(), n)
+		if err != nil {
+			t.Fatalf("1/%d: %v", 8/n, err)
+		}
+		if sw != (w*n+7)/8 || sh != (h*n+7)/8 {
+			t.Fatalf("1/%d: got %dx%d", 8/n, sw, sh)
+		}
+		ref, rw, rh := shrink(full, w, h, 8/n)
+		for y := 0; y < rh; y++ {
+			for x := 0; x < rw; x++ {
+				if d := int(gray[y*sw+x]) - int(ref[y*rw+x]); d < -4 || d > 4 {
+					t.Fatalf("1/%d: pixel %d,%d is %d, box filtered %d", 8/n, x, y, gray[y*sw+x], ref[y*rw+x])
+				}
+			}
+		}
+	}
+}
+
+func TestScaleJPEGProgressive(t *testing.T) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, 64, 48)), nil); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	// Relabel the frame as progressive, which only the full decoder reads
+	data[bytes.Index(data, []byte{0xff, markerSOF0})+1] = 0xc2
+	if _, _, _, err := scaleJPEG(data, 1); err != errUnsupported {
+		t.Fatalf("got %v, want errUnsupported", err)
+	}
+}
@@ -0,0 +1,73 @@
+// Package imagedecode decodes the images received by the servers and reveals
+// their qrcodes, bounding their dimensions before their pixels are allocated
+package imagedecode
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+
+	"github.com/quaresc/goquirc"
+)
+
+// DefaultMaxPixels bounds the dimensions of decoded images when the servers
+// leave it unset, 8192x8192 pixels taking 256MiB once decoded as RGBA
+const DefaultMaxPixels = 64 << 20
+
+// Luminance decodes an image of any format known to goquirc.DecodeReader and
+// returns its grayscale pixels. Its header is decoded first so that images
+// larger than maxPixels, or DefaultMaxPixels when zero, are rejected without
+// decoding them
+func Luminance(r io.Reader, maxPixels int) ([]byte, int, int, error) {
+	if maxPixels <= 0 {
+		maxPixels = DefaultMaxPixels
+	}
+
+	var header bytes.Buffer
+	config, _, err := image.DecodeConfig(io.TeeReader(r, &header))
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("Cannot decode image: %w", err)
+	}
+	if config.Width <= 0 || config.Height <= 0 {
+		return nil, 0, 0, errors.New("Empty source image")
+	}
+	if err := CheckPixels(config.Width, config.Height, maxPixels); err != nil {
+		return nil, 0, 0, err
+	}
+
+	// Replay the sniffed header before the rest of the stream
+	img, _, err := image.Decode(io.MultiReader(&header, r))
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("Cannot decode image: %w", err)
+	}
+	if img.Bounds().Empty() {
+		return nil, 0, 0, errors.New("Empty source image")
+	}
+	gray, width, height := goquirc.Luminance(img)
+	return gray, width, height, nil
+}
+
+// CheckPixels fails when an image of width by height pixels exceeds
+// maxPixels, or DefaultMaxPixels when zero
+func CheckPixels(width int, height int, maxPixels int) error {
+	if maxPixels <= 0 {
+		maxPixels = DefaultMaxPixels
+	}
+	if int64(width)*int64(height) > int64(maxPixels) {
+		return fmt.Errorf("Image of %dx%d pixels exceeds %d pixels", width, height, maxPixels)
+	}
+	return nil
+}
+
+// Reveal reveals the qrcodes of grayscale pixels with a decoder of pool, or
+// its own quirc context when pool is nil
+func Reveal(ctx context.Context, pool goquirc.Revealer, opts []goquirc.Option, gray []byte, width int, height int) (goquirc.Result, error) {
+	if pool == nil {
+		var qr goquirc.Processing
+		return qr.RevealContext(ctx, &gray, width, height, opts...)
+	}
+	return pool.RevealContext(ctx, &gray, width, height, opts...)
+}
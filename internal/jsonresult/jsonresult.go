@@ -0,0 +1,160 @@
+// Package jsonresult converts the outcome of reveal processes to the JSON
+// form shared by the command line and the servers
+package jsonresult
+
+import (
+	"encoding/base64"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/quaresc/goquirc"
+)
+
+// Payload encodings
+const (
+	EncodingText   = "text"
+	EncodingBase64 = "base64"
+)
+
+// Result is the JSON form of the outcome of a reveal process
+type Result struct {
+	Found    int       `json:"found"`
+	Decoded  int       `json:"decoded"`
+	Codes    []Code    `json:"codes"`
+	Failures []Failure `json:"failures"`
+	Messages []Message `json:"messages,omitempty"`
+	Partial  bool      `json:"partial,omitempty"`
+	Profile  string    `json:"profile,omitempty"`
+}
+
+// Code is the JSON form of a decoded qrcode
+type Code struct {
+	Payload  string    `json:"payload"`
+	Encoding string    `json:"encoding"`
+	Version  int       `json:"version"`
+	ECC      string    `json:"ecc"`
+	Mask     int       `json:"mask"`
+	DataType string    `json:"data_type"`
+	ECI      int       `json:"eci,omitempty"`
+	Corners  [4][2]int `json:"corners"`
+	Append   *Append   `json:"append,omitempty"`
+	Inverted bool      `json:"inverted,omitempty"`
+	Mirrored bool      `json:"mirrored,omitempty"`
+}
+
+// Scan is the JSON form of a qrcode decoded from a source at a given time,
+// which the message sinks publish
+type Scan struct {
+	Source string    `json:"source"`
+	Time   time.Time `json:"time"`
+	Code
+}
+
+// Append is the JSON form of a structured append header
+type Append struct {
+	Index  int  `json:"index"`
+	Total  int  `json:"total"`
+	Parity byte `json:"parity"`
+}
+
+// Failure is the JSON form of a qrcode which could not be decoded
+type Failure struct {
+	Error   string    `json:"error"`
+	Corners [4][2]int `json:"corners"`
+}
+
+// Message is the JSON form of a payload reassembled from structured append
+// qrcodes
+type Message struct {
+	Payload  string `json:"payload"`
+	Encoding string `json:"encoding"`
+	Total    int    `json:"total"`
+	Parts    int    `json:"parts"`
+	Complete bool   `json:"complete"`
+}
+
+// Empty returns the JSON form of a reveal process which found nothing, with
+// empty rather than null lists
+func Empty() Result {
+	return Result{Codes: []Code{}, Failures: []Failure{}}
+}
+
+// New converts the outcome of a reveal process to its JSON form
+func New(result goquirc.Result) Result {
+	out := Empty()
+	out.Found = result.Found
+	out.Decoded = result.Usable
+	out.Partial = result.Partial
+	out.Profile = result.Profile
+	for _, code := range result.Code {
+		out.Codes = append(out.Codes, NewCode(code))
+	}
+	for _, failure := range result.Failures {
+		out.Failures = append(out.Failures, Failure{Error: failure.Error(), Corners: Corners(failure.Corners)})
+	}
+	for _, message := range result.Messages {
+		payload, encoding := Payload(message.Text(), message.Payload)
+		out.Messages = append(out.Messages, Message{
+			Payload:  payload,
+			Encoding: encoding,
+			Total:    message.Total,
+			Parts:    len(message.Parts),
+			Complete: message.Complete,
+		})
+	}
+	return out
+}
+
+// NewCode converts a decoded qrcode to its JSON form
+func NewCode(code goquirc.QRcode) Code {
+	payload, encoding := Payload(code.Text(), code.Payload)
+	out := Code{
+		Payload:  payload,
+		Encoding: encoding,
+		Version:  code.Version,
+		ECC:      code.ECCLevel.String(),
+		Mask:     code.Mask,
+		DataType: code.DataType.String(),
+		ECI:      code.ECI,
+		Corners:  Corners(code.Corners),
+		Inverted: code.Inverted,
+		Mirrored: code.Mirrored,
+	}
+	if code.Append != nil {
+		out.Append = &Append{Index: code.Append.Index, Total: code.Append.Total, Parity: code.Append.Parity}
+	}
+	return out
+}
+
+// Payload keeps a payload as text when it is printable, and falls back to
+// the base64 form of its raw bytes otherwise
+func Payload(text string, raw []byte) (string, string) {
+	if Printable(text) {
+		return text, EncodingText
+	}
+	return base64.StdEncoding.EncodeToString(raw), EncodingBase64
+}
+
+// Printable reports whether a text is valid UTF-8 free of control characters
+// other than whitespace
+func Printable(text string) bool {
+	if !utf8.ValidString(text) {
+		return false
+	}
+	for _, r := range text {
+		if r == utf8.RuneError || (unicode.IsControl(r) && !unicode.IsSpace(r)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Corners converts corners to pairs of coordinates
+func Corners(corners [4]goquirc.Position) [4][2]int {
+	var out [4][2]int
+	for i, c := range corners {
+		out[i] = [2]int{c.X, c.Y}
+	}
+	return out
+}
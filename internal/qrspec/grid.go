@@ -0,0 +1,194 @@
+package qrspec
+
+// Grid is a square matrix of modules, where function patterns are told apart
+// from the modules holding codewords
+type Grid struct {
+	Size     int
+	Dark     []bool
+	Function []bool
+
+	// timing is the column of the vertical timing pattern, skipped by Walk
+	timing int
+}
+
+// NewGrid returns the template of a symbol, where finder, timing and
+// alignment patterns are drawn and format and version areas are reserved
+// but left light
+func NewGrid(version int) *Grid {
+	size := Size(version)
+	g := &Grid{
+		Size:     size,
+		Dark:     make([]bool, size*size),
+		Function: make([]bool, size*size),
+		timing:   6,
+	}
+
+	for i := 0; i < size; i++ {
+		g.set(6, i, i%2 == 0)
+		g.set(i, 6, i%2 == 0)
+	}
+
+	g.finder(3, 3)
+	g.finder(size-4, 3)
+	g.finder(3, size-4)
+
+	positions := AlignmentPositions(version)
+	last := len(positions) - 1
+	for i, y := range positions {
+		for j, x := range positions {
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			g.alignment(x, y)
+		}
+	}
+
+	g.DrawFormat(0)
+	g.set(8, size-8, true)
+	if version >= 7 {
+		g.DrawVersion(0)
+	}
+
+	return g
+}
+
+// Clone returns a copy of the grid
+func (g *Grid) Clone() *Grid {
+	c := *g
+	c.Dark = append([]bool(nil), g.Dark...)
+	c.Function = append([]bool(nil), g.Function...)
+	return &c
+}
+
+// At tells whether the module at column x and row y is dark
+func (g *Grid) At(x int, y int) bool {
+	return g.Dark[y*g.Size+x]
+}
+
+// IsFunction tells whether the module at column x and row y belongs to a
+// function pattern
+func (g *Grid) IsFunction(x int, y int) bool {
+	return g.Function[y*g.Size+x]
+}
+
+// set draws a function module
+func (g *Grid) set(x int, y int, dark bool) {
+	g.Dark[y*g.Size+x] = dark
+	g.Function[y*g.Size+x] = true
+}
+
+// finder draws a finder pattern with its separator around a center
+func (g *Grid) finder(cx int, cy int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			x, y := cx+dx, cy+dy
+			if x < 0 || x >= g.Size || y < 0 || y >= g.Size {
+				continue
+			}
+			d := chebyshev(dx, dy)
+			g.set(x, y, d != 2 && d != 4)
+		}
+	}
+}
+
+// alignment draws an alignment pattern around a center
+func (g *Grid) alignment(cx int, cy int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			g.set(cx+dx, cy+dy, chebyshev(dx, dy) != 1)
+		}
+	}
+}
+
+// chebyshev returns the distance from the center on the widest axis
+func chebyshev(dx int, dy int) int {
+	if dx < 0 {
+		dx = -dx
+	}
+	if dy < 0 {
+		dy = -dy
+	}
+	if dx > dy {
+		return dx
+	}
+	return dy
+}
+
+// FormatCoordinates returns the column and row of bit i of both copies of
+// the format information
+func (g *Grid) FormatCoordinates(i int) (x1 int, y1 int, x2 int, y2 int) {
+	switch {
+	case i < 6:
+		x1, y1 = 8, i
+	case i < 8:
+		x1, y1 = 8, i+1
+	case i == 8:
+		x1, y1 = 7, 8
+	default:
+		x1, y1 = 14-i, 8
+	}
+	if i < 8 {
+		x2, y2 = g.Size-1-i, 8
+	} else {
+		x2, y2 = 8, g.Size-15+i
+	}
+	return
+}
+
+// DrawFormat draws both copies of the format information
+func (g *Grid) DrawFormat(bits int) {
+	for i := 0; i < 15; i++ {
+		dark := bits>>uint(i)&1 != 0
+		x1, y1, x2, y2 := g.FormatCoordinates(i)
+		g.set(x1, y1, dark)
+		g.set(x2, y2, dark)
+	}
+}
+
+// ReadFormat reads both copies of the format information
+func (g *Grid) ReadFormat() (int, int) {
+	var first, second int
+	for i := 0; i < 15; i++ {
+		x1, y1, x2, y2 := g.FormatCoordinates(i)
+		if g.At(x1, y1) {
+			first |= 1 << uint(i)
+		}
+		if g.At(x2, y2) {
+			second |= 1 << uint(i)
+		}
+	}
+	return first, second
+}
+
+// DrawVersion draws both copies of the version information
+func (g *Grid) DrawVersion(bits int) {
+	for i := 0; i < 18; i++ {
+		dark := bits>>uint(i)&1 != 0
+		a, b := g.Size-11+i%3, i/3
+		g.set(a, b, dark)
+		g.set(b, a, dark)
+	}
+}
+
+// Walk visits the modules holding codewords in placement order, from the
+// bottom right corner and going up and down two columns at a time
+func (g *Grid) Walk(visit func(x int, y int)) {
+	upward := true
+	for right := g.Size - 1; right >= 1; right -= 2 {
+		if right == g.timing {
+			right--
+		}
+		for vert := 0; vert < g.Size; vert++ {
+			y := vert
+			if upward {
+				y = g.Size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				if x := right - j; !g.IsFunction(x, y) {
+					visit(x, y)
+				}
+			}
+		}
+		upward = !upward
+	}
+}
@@ -0,0 +1,116 @@
+package qrspec
+
+// Capacity limits of Micro QR symbols, M1 to M4
+const (
+	MinMicroVersion = 1
+	MaxMicroVersion = 4
+)
+
+// microECCodewords is indexed by version then level, zero where the level is
+// not available. M1 only detects errors, it is reported as level L
+var microECCodewords = [5][4]int{
+	{},
+	{2, 0, 0, 0},
+	{5, 6, 0, 0},
+	{6, 8, 0, 0},
+	{8, 10, 14, 0},
+}
+
+// microCountBits is indexed by mode indicator then version, zero where the
+// mode is not available
+var microCountBits = [4][5]int{
+	{0, 3, 4, 5, 6},
+	{0, 0, 3, 4, 5},
+	{0, 0, 0, 4, 5},
+	{0, 0, 0, 3, 4},
+}
+
+// MicroSize returns the number of modules on each side of a Micro QR symbol
+func MicroSize(version int) int {
+	return version*2 + 9
+}
+
+// MicroECCodewords returns the number of error correction codewords of a
+// Micro QR symbol, zero when the level is not available
+func MicroECCodewords(version int, level Level) int {
+	return microECCodewords[version][level]
+}
+
+// MicroDataBits returns the number of data bits of a Micro QR symbol, zero
+// when the level is not available. M1 and M3 end their data with a codeword
+// of 4 bits
+func MicroDataBits(version int, level Level) int {
+	ecc := MicroECCodewords(version, level)
+	if ecc == 0 {
+		return 0
+	}
+	side := MicroSize(version) - 1
+	return side*side - 64 - ecc*8
+}
+
+// MicroCountBits returns the length of the character count indicator of a
+// mode, given by its Micro QR mode indicator, zero when the mode is not
+// available
+func MicroCountBits(indicator int, version int) int {
+	return microCountBits[indicator][version]
+}
+
+// MicroSymbolNumber returns the number identifying a version and level in
+// Micro QR format information, or -1 when the level is not available
+func MicroSymbolNumber(version int, level Level) int {
+	if MicroECCodewords(version, level) == 0 {
+		return -1
+	}
+	return [5]int{0, 0, 1, 3, 5}[version] + int(level)
+}
+
+// MicroFormatInfo returns the 15 bits of Micro QR format information, masked
+// and protected by their BCH code
+func MicroFormatInfo(version int, level Level, mask int) int {
+	data := MicroSymbolNumber(version, level)<<2 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	return (data<<10 | rem) ^ 0x4445
+}
+
+// MicroMask tells whether the data module at column x and row y is flipped by
+// a Micro QR mask pattern, which are the QR patterns 1, 4, 6 and 7
+func MicroMask(mask int, x int, y int) bool {
+	return Mask([4]int{1, 4, 6, 7}[mask], x, y)
+}
+
+// NewMicroGrid returns the template of a Micro QR symbol, where the finder
+// and timing patterns are drawn and the format area is reserved but left
+// light
+func NewMicroGrid(version int) *Grid {
+	size := MicroSize(version)
+	g := &Grid{
+		Size:     size,
+		Dark:     make([]bool, size*size),
+		Function: make([]bool, size*size),
+	}
+
+	for i := 0; i < size; i++ {
+		g.set(0, i, i%2 == 0)
+		g.set(i, 0, i%2 == 0)
+	}
+	g.finder(3, 3)
+	g.DrawMicroFormat(0)
+
+	return g
+}
+
+// DrawMicroFormat draws the single copy of Micro QR format information,
+// down the right side of the finder then leftwards below it
+func (g *Grid) DrawMicroFormat(bits int) {
+	for i := 0; i < 15; i++ {
+		dark := bits>>uint(i)&1 != 0
+		if i < 8 {
+			g.set(8, i+1, dark)
+		} else {
+			g.set(15-i, 8, dark)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package qrspec
+
+import "testing"
+
+// microFormats holds the format information of Micro QR symbols by mask and
+// symbol number, as tabulated by libqrencode
+var microFormats = [4][8]int{
+	{0x4445, 0x55ae, 0x6793, 0x7678, 0x06de, 0x1735, 0x2508, 0x34e3},
+	{0x4172, 0x5099, 0x62a4, 0x734f, 0x03e9, 0x1202, 0x203f, 0x31d4},
+	{0x4e2b, 0x5fc0, 0x6dfd, 0x7c16, 0x0cb0, 0x1d5b, 0x2f66, 0x3e8d},
+	{0x4b1c, 0x5af7, 0x68ca, 0x7921, 0x0987, 0x186c, 0x2a51, 0x3bba},
+}
+
+func TestMicroFormatInfo(t *testing.T) {
+	for version := MinMicroVersion; version <= MaxMicroVersion; version++ {
+		for _, level := range []Level{L, M, Q} {
+			symbol := MicroSymbolNumber(version, level)
+			if symbol < 0 {
+				continue
+			}
+			for mask := 0; mask < 4; mask++ {
+				if got, want := MicroFormatInfo(version, level, mask), microFormats[mask][symbol]; got != want {
+					t.Errorf("M%d %v mask %d: format %#04x, want %#04x", version, level, mask, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestDrawMicroFormat(t *testing.T) {
+	// Bits 0 to 7 go down column 8 from row 1, bits 8 to 14 along row 8
+	// from column 7 to 1
+	g := NewMicroGrid(2)
+	for i := 0; i < 15; i++ {
+		g.DrawMicroFormat(1 << uint(i))
+		x, y := 8, i+1
+		if i >= 8 {
+			x, y = 15-i, 8
+		}
+		for yy := 0; yy <= 8; yy++ {
+			for xx := 0; xx <= 8; xx++ {
+				onFormat := (xx == 8 && yy >= 1) || (yy == 8 && xx >= 1)
+				if onFormat && g.At(xx, yy) != (xx == x && yy == y) {
+					t.Fatalf("bit %d: module %d,%d dark %v", i, xx, yy, g.At(xx, yy))
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,313 @@
+// Package qrspec gathers the tables and symbol layout rules of the QR code
+// specification (ISO/IEC 18004) shared by the decoder and the encoder
+package qrspec
+
+// Level is an error correction level, ordered from the weakest to the strongest
+type Level int
+
+// Error correction levels
+const (
+	L Level = iota
+	M
+	Q
+	H
+)
+
+// formatBits holds the two bits identifying each level in format information
+var formatBits = [4]int{L: 1, M: 0, Q: 3, H: 2}
+
+// FormatBits returns the two bits identifying the level in format information,
+// which are also the values quirc reports as ecc_level
+func (l Level) FormatBits() int {
+	return formatBits[l]
+}
+
+// LevelFromFormat returns the level identified by two bits of format information
+func LevelFromFormat(bits int) Level {
+	for l, b := range formatBits {
+		if b == bits&3 {
+			return Level(l)
+		}
+	}
+	return M
+}
+
+// Capacity limits of the specification
+const (
+	MinVersion = 1
+	MaxVersion = 40
+)
+
+// eccCodewordsPerBlock is indexed by level then version
+var eccCodewordsPerBlock = [4][41]int{
+	{-1, 7, 10, 15, 20, 26, 18, 20, 24, 30, 18, 20, 24, 26, 30, 22, 24, 28, 30, 28, 28, 28, 28, 30, 30, 26, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	{-1, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26, 30, 22, 22, 24, 24, 28, 28, 26, 26, 26, 26, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28},
+	{-1, 13, 22, 18, 26, 18, 24, 18, 22, 20, 24, 28, 26, 24, 20, 30, 24, 28, 28, 26, 30, 28, 30, 30, 30, 30, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	{-1, 17, 28, 22, 16, 22, 28, 26, 26, 24, 28, 24, 28, 22, 24, 24, 30, 28, 28, 26, 28, 30, 24, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+}
+
+// errorCorrectionBlocks is indexed by level then version
+var errorCorrectionBlocks = [4][41]int{
+	{-1, 1, 1, 1, 1, 1, 2, 2, 2, 2, 4, 4, 4, 4, 4, 6, 6, 6, 6, 7, 8, 8, 9, 9, 10, 12, 12, 12, 13, 14, 15, 16, 17, 18, 19, 19, 20, 21, 22, 24, 25},
+	{-1, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5, 5, 8, 9, 9, 10, 10, 11, 13, 14, 16, 17, 17, 18, 20, 21, 23, 25, 26, 28, 29, 31, 33, 35, 37, 38, 40, 43, 45, 47, 49},
+	{-1, 1, 1, 2, 2, 4, 4, 6, 6, 8, 8, 8, 10, 12, 16, 12, 17, 16, 18, 21, 20, 23, 23, 25, 27, 29, 34, 34, 35, 38, 40, 43, 45, 48, 51, 53, 56, 59, 62, 65, 68},
+	{-1, 1, 1, 2, 4, 4, 4, 5, 6, 8, 8, 11, 11, 16, 16, 18, 16, 19, 21, 25, 25, 25, 34, 30, 32, 35, 37, 40, 42, 45, 48, 51, 54, 57, 60, 63, 66, 70, 74, 77, 81},
+}
+
+// Size returns the number of modules on each side of a symbol
+func Size(version int) int {
+	return version*4 + 17
+}
+
+// Version returns the version of a symbol from its size, or zero when the
+// size is not valid
+func Version(size int) int {
+	if size < Size(MinVersion) || size > Size(MaxVersion) || (size-17)%4 != 0 {
+		return 0
+	}
+	return (size - 17) / 4
+}
+
+// RawModules returns the number of modules available for data and error
+// correction codewords, remainder bits included
+func RawModules(version int) int {
+	modules := (16*version+128)*version + 64
+	if version >= 2 {
+		alignments := version/7 + 2
+		modules -= (25*alignments-10)*alignments - 55
+		if version >= 7 {
+			modules -= 36
+		}
+	}
+	return modules
+}
+
+// RawCodewords returns the number of data and error correction codewords
+func RawCodewords(version int) int {
+	return RawModules(version) / 8
+}
+
+// Blocks returns the number of error correction blocks
+func Blocks(version int, level Level) int {
+	return errorCorrectionBlocks[level][version]
+}
+
+// ECCodewordsPerBlock returns the number of error correction codewords in
+// each block
+func ECCodewordsPerBlock(version int, level Level) int {
+	return eccCodewordsPerBlock[level][version]
+}
+
+// DataCodewords returns the number of data codewords of a symbol
+func DataCodewords(version int, level Level) int {
+	return RawCodewords(version) - ECCodewordsPerBlock(version, level)*Blocks(version, level)
+}
+
+// BlockLengths returns the number of data codewords of each block, short
+// blocks coming first
+func BlockLengths(version int, level Level) []int {
+	blocks := Blocks(version, level)
+	raw := RawCodewords(version)
+	ecc := ECCodewordsPerBlock(version, level)
+	short := blocks - raw%blocks
+
+	lengths := make([]int, blocks)
+	for i := range lengths {
+		lengths[i] = raw/blocks - ecc
+		if i >= short {
+			lengths[i]++
+		}
+	}
+	return lengths
+}
+
+// Interleave splits data into blocks, appends to each its error correction
+// codewords computed by ecc and interleaves them into the final sequence
+func Interleave(data []byte, version int, level Level, ecc func([]byte, int) []byte) []byte {
+	lengths := BlockLengths(version, level)
+	eccLen := ECCodewordsPerBlock(version, level)
+
+	blocks := make([][]byte, len(lengths))
+	for i, n := range lengths {
+		blocks[i], data = data[:n], data[n:]
+	}
+
+	result := make([]byte, 0, RawCodewords(version))
+	for i := 0; i < lengths[len(lengths)-1]; i++ {
+		for _, block := range blocks {
+			if i < len(block) {
+				result = append(result, block[i])
+			}
+		}
+	}
+	parity := make([][]byte, len(blocks))
+	for i, block := range blocks {
+		parity[i] = ecc(block, eccLen)
+	}
+	for i := 0; i < eccLen; i++ {
+		for _, block := range parity {
+			result = append(result, block[i])
+		}
+	}
+	return result
+}
+
+// CodewordBlocks returns the block each codeword of the interleaved sequence
+// belongs to
+func CodewordBlocks(version int, level Level) []int {
+	lengths := BlockLengths(version, level)
+	eccLen := ECCodewordsPerBlock(version, level)
+
+	blocks := make([]int, 0, RawCodewords(version))
+	for i := 0; i < lengths[len(lengths)-1]; i++ {
+		for j, n := range lengths {
+			if i < n {
+				blocks = append(blocks, j)
+			}
+		}
+	}
+	for i := 0; i < eccLen; i++ {
+		for j := range lengths {
+			blocks = append(blocks, j)
+		}
+	}
+	return blocks
+}
+
+// Deinterleave splits the codeword sequence read from a symbol back into
+// blocks, each one made of its data followed by its error correction codewords
+func Deinterleave(codewords []byte, version int, level Level) [][]byte {
+	lengths := BlockLengths(version, level)
+	eccLen := ECCodewordsPerBlock(version, level)
+
+	blocks := make([][]byte, len(lengths))
+	for i, n := range lengths {
+		blocks[i] = make([]byte, 0, n+eccLen)
+	}
+	longest := lengths[len(lengths)-1]
+	for i := 0; i < longest; i++ {
+		for j := range blocks {
+			if i < lengths[j] {
+				blocks[j] = append(blocks[j], codewords[0])
+				codewords = codewords[1:]
+			}
+		}
+	}
+	for i := 0; i < eccLen; i++ {
+		for j := range blocks {
+			blocks[j] = append(blocks[j], codewords[0])
+			codewords = codewords[1:]
+		}
+	}
+	return blocks
+}
+
+// Alphanumeric lists the characters of the alphanumeric mode by value
+const Alphanumeric = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ $%*+-./:"
+
+// CountBits returns the length of the character count indicator of a mode
+func CountBits(mode int, version int) int {
+	group := 0
+	if version >= 27 {
+		group = 2
+	} else if version >= 10 {
+		group = 1
+	}
+
+	switch mode {
+	case 1:
+		return [3]int{10, 12, 14}[group]
+	case 2:
+		return [3]int{9, 11, 13}[group]
+	case 4:
+		return [3]int{8, 16, 16}[group]
+	}
+	return [3]int{8, 10, 12}[group]
+}
+
+// AlignmentPositions returns the coordinates of alignment pattern centers on
+// each axis
+func AlignmentPositions(version int) []int {
+	if version == 1 {
+		return nil
+	}
+
+	count := version/7 + 2
+	step := (version*8 + count*3 + 5) / (count*4 - 4) * 2
+	positions := make([]int, count)
+	positions[0] = 6
+	for i, pos := count-1, Size(version)-7; i > 0; i, pos = i-1, pos-step {
+		positions[i] = pos
+	}
+	return positions
+}
+
+// FormatInfo returns the 15 bits of format information, masked and protected
+// by their BCH code
+func FormatInfo(level Level, mask int) int {
+	data := level.FormatBits()<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	return (data<<10 | rem) ^ 0x5412
+}
+
+// DecodeFormat finds the format information closest to bits, failing when
+// more than 3 bits differ
+func DecodeFormat(bits int) (Level, int, bool) {
+	best, distance := 0, 4
+	for data := 0; data < 32; data++ {
+		level, mask := LevelFromFormat(data>>3), data&7
+		if d := hamming(bits, FormatInfo(level, mask)); d < distance {
+			best, distance = data, d
+		}
+	}
+	if distance > 3 {
+		return 0, 0, false
+	}
+	return LevelFromFormat(best >> 3), best & 7, true
+}
+
+// VersionInfo returns the 18 bits of version information protected by their
+// BCH code, only symbols from version 7 carry them
+func VersionInfo(version int) int {
+	rem := version
+	for i := 0; i < 12; i++ {
+		rem = (rem << 1) ^ ((rem >> 11) * 0x1f25)
+	}
+	return version<<12 | rem
+}
+
+// hamming returns the number of differing bits
+func hamming(a int, b int) int {
+	count := 0
+	for x := a ^ b; x != 0; x &= x - 1 {
+		count++
+	}
+	return count
+}
+
+// Mask tells whether the data module at column x and row y is flipped by a
+// mask pattern
+func Mask(mask int, x int, y int) bool {
+	switch mask {
+	case 0:
+		return (x+y)%2 == 0
+	case 1:
+		return y%2 == 0
+	case 2:
+		return x%3 == 0
+	case 3:
+		return (x+y)%3 == 0
+	case 4:
+		return (x/3+y/2)%2 == 0
+	case 5:
+		return x*y%2+x*y%3 == 0
+	case 6:
+		return (x*y%2+x*y%3)%2 == 0
+	case 7:
+		return ((x+y)%2+x*y%3)%2 == 0
+	}
+	return false
+}
@@ -0,0 +1,189 @@
+// Package reedsolomon implements the Reed-Solomon code over GF(256) used by
+// QR codes, with the 0x11d reducing polynomial and generator roots starting
+// at alpha^0
+package reedsolomon
+
+import "errors"
+
+// ErrUncorrectable is returned when a block holds more errors than its error
+// correction codewords can fix
+var ErrUncorrectable = errors.New("Too many errors in block")
+
+var (
+	exp [512]byte
+	log [256]int
+)
+
+func init() {
+	x := 1
+	for i := 0; i < 255; i++ {
+		exp[i] = byte(x)
+		log[x] = i
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= 0x11d
+		}
+	}
+	for i := 255; i < len(exp); i++ {
+		exp[i] = exp[i-255]
+	}
+}
+
+// mul multiplies two field elements
+func mul(a byte, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return exp[log[a]+log[b]]
+}
+
+// div divides two field elements, b must not be zero
+func div(a byte, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+	return exp[log[a]+255-log[b]]
+}
+
+// generator returns the coefficients of the generator polynomial of degree
+// n, highest degree first and leading one omitted
+func generator(n int) []byte {
+	g := make([]byte, n)
+	g[n-1] = 1
+	root := byte(1)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			g[j] = mul(g[j], root)
+			if j+1 < n {
+				g[j] ^= g[j+1]
+			}
+		}
+		root = mul(root, 2)
+	}
+	return g
+}
+
+// Encode returns the n error correction codewords of data
+func Encode(data []byte, n int) []byte {
+	g := generator(n)
+	rem := make([]byte, n)
+	for _, b := range data {
+		factor := b ^ rem[0]
+		copy(rem, rem[1:])
+		rem[n-1] = 0
+		for i := range rem {
+			rem[i] ^= mul(g[i], factor)
+		}
+	}
+	return rem
+}
+
+// Correct fixes in place a block made of data followed by n error correction
+// codewords and returns the number of corrected codewords
+func Correct(block []byte, n int) (int, error) {
+	syndromes := make([]byte, n)
+	clean := true
+	for i := range syndromes {
+		x := exp[i]
+		var s byte
+		for _, b := range block {
+			s = mul(s, x) ^ b
+		}
+		syndromes[i] = s
+		clean = clean && s == 0
+	}
+	if clean {
+		return 0, nil
+	}
+
+	// Berlekamp-Massey gives the error locator polynomial, lowest degree first
+	locator := []byte{1}
+	previous := []byte{1}
+	errs, shift, last := 0, 1, byte(1)
+	for k := 0; k < n; k++ {
+		delta := syndromes[k]
+		for i := 1; i <= errs && i < len(locator); i++ {
+			delta ^= mul(locator[i], syndromes[k-i])
+		}
+		if delta == 0 {
+			shift++
+			continue
+		}
+		factor := div(delta, last)
+		updated := make([]byte, maxInt(len(locator), len(previous)+shift))
+		copy(updated, locator)
+		for i, c := range previous {
+			updated[i+shift] ^= mul(factor, c)
+		}
+		if 2*errs <= k {
+			previous, last = locator, delta
+			errs = k + 1 - errs
+			shift = 1
+		} else {
+			shift++
+		}
+		locator = updated
+	}
+	if errs > n/2 || errs >= len(locator) {
+		return 0, ErrUncorrectable
+	}
+
+	// Evaluator polynomial is the product of syndromes and locator modulo x^n
+	evaluator := make([]byte, n)
+	for i := range evaluator {
+		for j := 0; j <= i && j < len(locator); j++ {
+			evaluator[i] ^= mul(locator[j], syndromes[i-j])
+		}
+	}
+
+	// Chien search for the error positions, then Forney for their values
+	found := 0
+	for k := range block {
+		position := len(block) - 1 - k
+		inverse := exp[(255-position%255)%255]
+		if eval(locator, inverse) != 0 {
+			continue
+		}
+		var derivative byte
+		for i := 1; i < len(locator); i += 2 {
+			derivative ^= mul(locator[i], pow(inverse, i-1))
+		}
+		if derivative == 0 {
+			return 0, ErrUncorrectable
+		}
+		block[k] ^= mul(exp[position%255], div(eval(evaluator, inverse), derivative))
+		found++
+	}
+	if found != errs {
+		return 0, ErrUncorrectable
+	}
+
+	return found, nil
+}
+
+// eval evaluates a polynomial given lowest degree first
+func eval(poly []byte, x byte) byte {
+	var result byte
+	for i := len(poly) - 1; i >= 0; i-- {
+		result = mul(result, x) ^ poly[i]
+	}
+	return result
+}
+
+// pow raises a field element to a non negative power
+func pow(x byte, n int) byte {
+	if n == 0 {
+		return 1
+	}
+	if x == 0 {
+		return 0
+	}
+	return exp[log[x]*n%255]
+}
+
+func maxInt(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
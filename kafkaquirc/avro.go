@@ -0,0 +1,81 @@
+package kafkaquirc
+
+import (
+	"encoding/binary"
+
+	"github.com/quaresc/goquirc/internal/jsonresult"
+)
+
+// AvroSchema is the schema of the Avro messages, mirroring the fields of the
+// JSON ones but the structured append header
+const AvroSchema = `{
+  "type": "record",
+  "name": "Scan",
+  "namespace": "com.github.quaresc.goquirc",
+  "fields": [
+    {"name": "source", "type": "string"},
+    {"name": "time", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+    {"name": "payload", "type": "string"},
+    {"name": "encoding", "type": "string"},
+    {"name": "version", "type": "int"},
+    {"name": "ecc", "type": "string"},
+    {"name": "mask", "type": "int"},
+    {"name": "data_type", "type": "string"},
+    {"name": "eci", "type": "int"},
+    {"name": "corners", "type": {"type": "array", "items": {"type": "array", "items": "int"}}},
+    {"name": "inverted", "type": "boolean"},
+    {"name": "mirrored", "type": "boolean"}
+  ]
+}`
+
+// avroScan encodes a scan in the Avro binary encoding of AvroSchema, after
+// the header of the Confluent wire format when schemaID is positive
+func avroScan(scan jsonresult.Scan, schemaID int) []byte {
+	var out []byte
+	if schemaID > 0 {
+		out = append(out, 0)
+		out = binary.BigEndian.AppendUint32(out, uint32(schemaID))
+	}
+
+	out = avroString(out, scan.Source)
+	out = avroLong(out, scan.Time.UnixMilli())
+	out = avroString(out, scan.Payload)
+	out = avroString(out, scan.Encoding)
+	out = avroLong(out, int64(scan.Version))
+	out = avroString(out, scan.ECC)
+	out = avroLong(out, int64(scan.Mask))
+	out = avroString(out, scan.DataType)
+	out = avroLong(out, int64(scan.ECI))
+
+	// Arrays are written as a single block followed by the empty one
+	out = avroLong(out, int64(len(scan.Corners)))
+	for _, corner := range scan.Corners {
+		out = avroLong(out, int64(len(corner)))
+		for _, v := range corner {
+			out = avroLong(out, int64(v))
+		}
+		out = avroLong(out, 0)
+	}
+	out = avroLong(out, 0)
+
+	out = avroBoolean(out, scan.Inverted)
+	return avroBoolean(out, scan.Mirrored)
+}
+
+// avroLong appends an int or a long as a zigzag varint
+func avroLong(out []byte, v int64) []byte {
+	return binary.AppendUvarint(out, uint64(v<<1^v>>63))
+}
+
+// avroString appends a string as its length followed by its bytes
+func avroString(out []byte, s string) []byte {
+	return append(avroLong(out, int64(len(s))), s...)
+}
+
+// avroBoolean appends a boolean as a single byte
+func avroBoolean(out []byte, b bool) []byte {
+	if b {
+		return append(out, 1)
+	}
+	return append(out, 0)
+}
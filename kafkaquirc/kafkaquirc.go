@@ -0,0 +1,133 @@
+// Package kafkaquirc writes decoded qrcodes to a Kafka topic, for warehouse
+// scanning pipelines whose consumers process scans at a volume no request
+// and response protocol would bear
+//
+// Every decoded qrcode becomes a message, in the JSON form of the servers
+// along with the source it was seen by and when, or in the Avro encoding of
+// AvroSchema. Messages are keyed by payload so that duplicates land in the
+// same partition, or by source to keep the scans of a camera in order:
+//
+//	producer := kafkaquirc.New(&kafka.Writer{
+//		Addr:     kafka.TCP("broker-1:9092", "broker-2:9092"),
+//		Topic:    "scans",
+//		Balancer: &kafka.Hash{},
+//	}, kafkaquirc.Config{Key: kafkaquirc.KeySource})
+//
+//	result, err := d.Reveal(&frame, w, h)
+//	if err == nil {
+//		err = producer.Publish(ctx, "dock-3", time.Now(), result)
+//	}
+package kafkaquirc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+
+	"github.com/quaresc/goquirc"
+	"github.com/quaresc/goquirc/internal/jsonresult"
+)
+
+// Format is the encoding of the messages
+type Format int
+
+// Supported formats
+const (
+	FormatJSON Format = iota
+	FormatAvro
+)
+
+// Key selects the key of the messages
+type Key int
+
+// Supported keys
+const (
+	// KeyPayloadHash keys messages by the hexadecimal SHA-256 of the raw
+	// payload
+	KeyPayloadHash Key = iota
+
+	// KeySource keys messages by the source of the scans
+	KeySource
+)
+
+// Writer writes messages to Kafka, such as a *kafka.Writer
+type Writer interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+var _ Writer = (*kafka.Writer)(nil)
+
+// Config tunes the messages written
+type Config struct {
+	Format Format
+	Key    Key
+
+	// SchemaID prefixes Avro messages with the header of the Confluent wire
+	// format when positive, for consumers resolving AvroSchema through a
+	// schema registry
+	SchemaID int
+}
+
+// Producer writes decoded qrcodes with a Kafka writer
+type Producer struct {
+	writer Writer
+	cfg    Config
+}
+
+// New returns a producer writing messages through writer, which stays owned
+// by the caller and sets the topic
+func New(writer Writer, cfg Config) *Producer {
+	return &Producer{writer: writer, cfg: cfg}
+}
+
+// Publish writes a message for every qrcode result decoded, seen by source
+// at a time, in a single batch. Failures are not written
+func (p *Producer) Publish(ctx context.Context, source string, at time.Time, result goquirc.Result) error {
+	if len(result.Code) == 0 {
+		return nil
+	}
+
+	msgs := make([]kafka.Message, 0, len(result.Code))
+	for _, code := range result.Code {
+		msg, err := p.message(source, at, code)
+		if err != nil {
+			return err
+		}
+		msgs = append(msgs, msg)
+	}
+	return p.writer.WriteMessages(ctx, msgs...)
+}
+
+// message encodes and keys a qrcode
+func (p *Producer) message(source string, at time.Time, code goquirc.QRcode) (kafka.Message, error) {
+	msg := kafka.Message{Time: at}
+	switch p.cfg.Key {
+	case KeyPayloadHash:
+		sum := sha256.Sum256(code.Payload)
+		msg.Key = []byte(hex.EncodeToString(sum[:]))
+	case KeySource:
+		msg.Key = []byte(source)
+	default:
+		return msg, errors.New("Unknown message key")
+	}
+
+	scan := jsonresult.Scan{Source: source, Time: at, Code: jsonresult.NewCode(code)}
+	switch p.cfg.Format {
+	case FormatJSON:
+		value, err := json.Marshal(scan)
+		if err != nil {
+			return msg, err
+		}
+		msg.Value = value
+	case FormatAvro:
+		msg.Value = avroScan(scan, p.cfg.SchemaID)
+	default:
+		return msg, errors.New("Unknown message format")
+	}
+	return msg, nil
+}
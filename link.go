@@ -0,0 +1,9 @@
+//go:build !goquirc_static
+
+package goquirc
+
+// The quirc library is looked up in the quirc submodule, the shared one
+// being preferred when both were built
+
+// #cgo LDFLAGS: -L${SRCDIR}/quirc -lquirc -lm
+import "C"
@@ -0,0 +1,14 @@
+//go:build goquirc_static
+
+package goquirc
+
+// The goquirc_static build tag links the archive of the quirc submodule by
+// path, so that no shared quirc can be picked, and makes the whole binary
+// static. Built with a musl toolchain, it needs nothing at run time and fits
+// in a FROM scratch container:
+//
+//	make -C quirc CC=musl-gcc libquirc.a
+//	CC=musl-gcc go build -tags goquirc_static ./cmd/goquirc
+
+// #cgo LDFLAGS: ${SRCDIR}/quirc/libquirc.a -lm -static
+import "C"
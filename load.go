@@ -0,0 +1,229 @@
+package goquirc
+
+import "errors"
+
+// frame starts a detection and returns the source image buffer of quirc,
+// checking it matches the dimensions of the image about to be loaded
+func (qr *Processing) frame(w int, h int) ([]byte, error) {
+	buffer, bw, bh := qr.buffer()
+	if bw != w || bh != h {
+		return nil, errors.New("Image dimensions do not match buffer")
+	}
+	return buffer, nil
+}
+
+// checkPlane verifies a buffer holds h rows of stride bytes, the last one
+// being at least width bytes long
+func checkPlane(pix []byte, width int, h int, stride int) error {
+	if stride < width {
+		return errors.New("Stride is shorter than a row")
+	}
+	if h > 0 && len(pix) < (h-1)*stride+width {
+		return errors.New("Image buffer is too short")
+	}
+	return nil
+}
+
+// LoadStride works like Load for a source image whose rows are stride bytes
+// apart, such as padded buffers or sub-images, without repacking it first
+func (qr *Processing) LoadStride(image []byte, stride int) error {
+	buffer, w, h := qr.buffer()
+	if err := checkPlane(image, w, h, stride); err != nil {
+		return err
+	}
+
+	copyPlane(buffer, image, w, h, stride)
+	return nil
+}
+
+// LoadRGBA loads an RGBA or NRGBA image (4 bytes per pixel, alpha ignored)
+// whose dimensions match the ones given to Resize, converting it to BT.601
+// luminance on the fly
+func (qr *Processing) LoadRGBA(pix []byte, w int, h int, stride int) error {
+	if err := checkPlane(pix, w*4, h, stride); err != nil {
+		return err
+	}
+	buffer, err := qr.frame(w, h)
+	if err != nil {
+		return err
+	}
+
+	rgbaToGray(buffer, pix, w, h, stride)
+	return nil
+}
+
+// LoadYUV420 loads the luminance of a planar or semi-planar YUV 4:2:0 frame
+// (NV12, NV21, I420, YV12) whose dimensions match the ones given to Resize.
+// As the Y plane comes first and already holds luminance, its rows of stride
+// bytes are copied as is and the chroma planes are never read
+func (qr *Processing) LoadYUV420(frame []byte, w int, h int, stride int) error {
+	if err := checkPlane(frame, w, h, stride); err != nil {
+		return err
+	}
+	buffer, err := qr.frame(w, h)
+	if err != nil {
+		return err
+	}
+
+	copyPlane(buffer, frame, w, h, stride)
+	return nil
+}
+
+// copyPlane copies h rows of w bytes spaced by stride into a packed plane
+func copyPlane(dst []byte, pix []byte, w int, h int, stride int) {
+	if stride == w {
+		copy(dst, pix[:w*h])
+		return
+	}
+	for y := 0; y < h; y++ {
+		copy(dst[y*w:(y+1)*w], pix[y*stride:y*stride+w])
+	}
+}
+
+// LoadYUYV loads the luminance of a packed YUV 4:2:2 frame laid out as
+// Y0 U Y1 V (YUYV, YUY2), whose dimensions match the ones given to Resize
+func (qr *Processing) LoadYUYV(frame []byte, w int, h int, stride int) error {
+	return qr.loadPacked422(frame, w, h, stride, 0)
+}
+
+// LoadUYVY loads the luminance of a packed YUV 4:2:2 frame laid out as
+// U Y0 V Y1 (UYVY), whose dimensions match the ones given to Resize
+func (qr *Processing) LoadUYVY(frame []byte, w int, h int, stride int) error {
+	return qr.loadPacked422(frame, w, h, stride, 1)
+}
+
+// loadPacked422 extracts every other byte, starting at offset, of packed
+// 4:2:2 rows
+func (qr *Processing) loadPacked422(frame []byte, w int, h int, stride int, offset int) error {
+	if err := checkPlane(frame, w*2, h, stride); err != nil {
+		return err
+	}
+	buffer, err := qr.frame(w, h)
+	if err != nil {
+		return err
+	}
+
+	packedLuma(buffer, frame[offset:], w, h, stride)
+	return nil
+}
+
+// BayerPattern describes the color filter layout of a raw sensor, named after
+// the colors of its top left 2x2 cell
+type BayerPattern int
+
+// Supported Bayer patterns
+const (
+	BayerRGGB BayerPattern = iota
+	BayerGRBG
+	BayerGBRG
+	BayerBGGR
+)
+
+// LoadBayer loads a raw 8 bits Bayer frame whose dimensions match the ones
+// given to Resize. Luminance is approximated by the green channel, which
+// holds half of the photosites and most of the perceived brightness, green
+// sites are copied and the others get the average of their green neighbours
+func (qr *Processing) LoadBayer(frame []byte, w int, h int, stride int, pattern BayerPattern) error {
+	if err := checkPlane(frame, w, h, stride); err != nil {
+		return err
+	}
+	buffer, err := qr.frame(w, h)
+	if err != nil {
+		return err
+	}
+
+	// Green sites are where x+y has this parity
+	green := 1
+	if pattern == BayerGRBG || pattern == BayerGBRG {
+		green = 0
+	}
+
+	at := func(x int, y int) (uint32, uint32) {
+		if x < 0 || x >= w || y < 0 || y >= h {
+			return 0, 0
+		}
+		return uint32(frame[y*stride+x]), 1
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if (x+y)&1 == green {
+				buffer[y*w+x] = frame[y*stride+x]
+				continue
+			}
+			var sum, count uint32
+			for _, d := range [4][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}} {
+				v, n := at(x+d[0], y+d[1])
+				sum += v
+				count += n
+			}
+			if count == 0 {
+				buffer[y*w+x] = frame[y*stride+x]
+			} else {
+				buffer[y*w+x] = byte((sum + count/2) / count)
+			}
+		}
+	}
+	return nil
+}
+
+// Window maps 16 bits samples to 8 bits: samples up to Low turn black, from
+// High turn white and the ones in between are scaled linearly. The zero
+// Window scales each frame between its darkest and brightest samples
+type Window struct {
+	Low  uint16
+	High uint16
+}
+
+// LoadGray16 loads a 16 bits grayscale frame, with a stride counted in
+// samples, whose dimensions match the ones given to Resize, mapping its
+// samples to 8 bits through a window
+func (qr *Processing) LoadGray16(pix []uint16, w int, h int, stride int, window Window) error {
+	if stride < w {
+		return errors.New("Stride is shorter than a row")
+	}
+	if h > 0 && len(pix) < (h-1)*stride+w {
+		return errors.New("Image buffer is too short")
+	}
+	buffer, err := qr.frame(w, h)
+	if err != nil {
+		return err
+	}
+
+	windowGray16(buffer, w, h, func(x int, y int) uint16 {
+		return pix[y*stride+x]
+	}, window)
+	return nil
+}
+
+// windowGray16 maps 16 bits samples into a packed 8 bits plane
+func windowGray16(dst []byte, w int, h int, sample func(x int, y int) uint16, window Window) {
+	if window == (Window{}) {
+		window.Low, window.High = 0xffff, 0
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				v := sample(x, y)
+				if v < window.Low {
+					window.Low = v
+				}
+				if v > window.High {
+					window.High = v
+				}
+			}
+		}
+	}
+
+	low, high := uint32(window.Low), uint32(window.High)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := uint32(sample(x, y))
+			switch {
+			case v <= low:
+				dst[y*w+x] = 0
+			case v >= high:
+				dst[y*w+x] = 255
+			default:
+				dst[y*w+x] = byte((v - low) * 255 / (high - low))
+			}
+		}
+	}
+}
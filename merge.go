@@ -0,0 +1,96 @@
+package goquirc
+
+import "bytes"
+
+// duplicateOverlap is the share of their area two quadrilaterals must have
+// in common to be taken for the same qrcode
+const duplicateOverlap = 0.5
+
+// merge gathers the results of several detections over the same image,
+// keeping a single copy of the qrcodes revealed by more than one of them and
+// dropping the failures of qrcodes decoded by another one. Visits and early
+// stops of cfg apply to the merged result
+func merge(results []Result, cfg *config) Result {
+	var merged Result
+	var sources []int
+	for i, result := range results {
+		merged.Found += result.Found
+		merged.Partial = merged.Partial || result.Partial
+		for _, code := range result.Code {
+			merged.Code, sources = addCode(merged.Code, sources, code, i)
+		}
+	}
+
+	for _, result := range results {
+	failures:
+		for _, failure := range result.Failures {
+			for _, code := range merged.Code {
+				if covered(failure.Corners, code.Corners) {
+					continue failures
+				}
+			}
+			merged.Failures = append(merged.Failures, failure)
+		}
+	}
+
+	if cfg.first && len(merged.Code) > 1 {
+		merged.Code = merged.Code[:1]
+	}
+	merged.Usable = len(merged.Code)
+
+	if cfg.visit != nil {
+		for _, code := range merged.Code {
+			if !cfg.visit(code) {
+				break
+			}
+		}
+		merged.Code = nil
+	}
+	if cfg.visitFailure != nil {
+		for _, failure := range merged.Failures {
+			if !cfg.visitFailure(failure) {
+				break
+			}
+		}
+		merged.Failures = nil
+	}
+	merged.Messages = Assemble(merged.Code)
+
+	return merged
+}
+
+// addCode appends a qrcode revealed by the detection source unless another
+// detection revealed it too, with the same payload at nearly the same place,
+// in which case the one with the largest area is kept since the other may be
+// sampled at a lower scale
+func addCode(codes []QRcode, sources []int, code QRcode, source int) ([]QRcode, []int) {
+	for i, other := range codes {
+		if sources[i] != source && bytes.Equal(code.Payload, other.Payload) && sameQuad(code.Corners, other.Corners) {
+			if code.Area() > other.Area() {
+				codes[i] = code
+			}
+			return codes, sources
+		}
+	}
+	return append(codes, code), append(sources, source)
+}
+
+// sameQuad tells whether two quadrilaterals mostly cover each other, their
+// intersection being most of their union
+func sameQuad(a [4]Position, b [4]Position) bool {
+	shared := intersectionArea(a, b)
+	union := quad(a).Area() + quad(b).Area() - shared
+	return union > 0 && shared >= duplicateOverlap*union
+}
+
+// covered tells whether most of the quadrilateral of a failure lies within
+// the one of a decoded qrcode, a failure being possibly cut by a tile edge
+func covered(failure [4]Position, code [4]Position) bool {
+	area := quad(failure).Area()
+	return area > 0 && intersectionArea(failure, code) >= duplicateOverlap*area
+}
+
+// quad wraps corners in a qrcode to reuse its geometry methods
+func quad(corners [4]Position) QRcode {
+	return QRcode{Corners: corners}
+}
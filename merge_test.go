@@ -0,0 +1,75 @@
+package goquirc
+
+import "testing"
+
+// diamond returns the corners of a square rotated by 45 degrees, centered on
+// x, y with a half diagonal of r
+func diamond(x int, y int, r int) [4]Position {
+	return [4]Position{{x, y - r}, {x + r, y}, {x, y + r}, {x - r, y}}
+}
+
+func TestMergeKeepsAdjacentCodes(t *testing.T) {
+	// The bounding boxes of the two rotated qrcodes overlap while their
+	// quadrilaterals only touch
+	a := QRcode{Corners: diamond(100, 100, 50), Payload: []byte("A")}
+	b := QRcode{Corners: diamond(180, 100, 50), Payload: []byte("B")}
+	if !a.Bounds().Overlaps(b.Bounds()) {
+		t.Fatal("bounding boxes should overlap")
+	}
+
+	merged := merge([]Result{{Found: 2, Code: []QRcode{a, b}}}, &config{})
+	if len(merged.Code) != 2 {
+		t.Fatalf("merged %d qrcodes of a single detection, want 2", len(merged.Code))
+	}
+
+	// Another detection revealing both again, slightly larger, does not add
+	// any
+	a2 := QRcode{Corners: diamond(100, 100, 52), Payload: []byte("A")}
+	b2 := QRcode{Corners: diamond(180, 100, 52), Payload: []byte("B")}
+	merged = merge([]Result{{Code: []QRcode{a, b}}, {Code: []QRcode{b2, a2}}}, &config{})
+	if len(merged.Code) != 2 {
+		t.Fatalf("merged %d qrcodes of two detections, want 2", len(merged.Code))
+	}
+	if merged.Code[0].Corners != a2.Corners || merged.Code[1].Corners != b2.Corners {
+		t.Errorf("merge kept %v, want the largest copies", merged.Code)
+	}
+
+	// A different payload at the same place is another qrcode
+	c := QRcode{Corners: a.Corners, Payload: []byte("C")}
+	merged = merge([]Result{{Code: []QRcode{a}}, {Code: []QRcode{c}}}, &config{})
+	if len(merged.Code) != 2 {
+		t.Errorf("merged %d qrcodes of different payloads, want 2", len(merged.Code))
+	}
+}
+
+func TestMergeFailures(t *testing.T) {
+	a := QRcode{Corners: diamond(100, 100, 50), Payload: []byte("A")}
+	inside := DecodeFailure{Corners: diamond(100, 100, 40)}
+	beside := DecodeFailure{Corners: diamond(180, 100, 50)}
+	other := DecodeFailure{Corners: diamond(185, 100, 50)}
+
+	merged := merge([]Result{{Code: []QRcode{a}}, {Failures: []DecodeFailure{inside, beside}}, {Failures: []DecodeFailure{other}}}, &config{})
+	if len(merged.Failures) != 2 || merged.Failures[0].Corners != beside.Corners || merged.Failures[1].Corners != other.Corners {
+		t.Errorf("merge kept failures %v, want the two beside the decoded qrcode", merged.Failures)
+	}
+}
+
+func TestIntersectionArea(t *testing.T) {
+	square := [4]Position{{0, 0}, {10, 0}, {10, 10}, {0, 10}}
+	tests := []struct {
+		name  string
+		other [4]Position
+		want  float64
+	}{
+		{"same", square, 100},
+		{"reversed", [4]Position{{0, 0}, {0, 10}, {10, 10}, {10, 0}}, 100},
+		{"half", [4]Position{{5, 0}, {15, 0}, {15, 10}, {5, 10}}, 50},
+		{"apart", [4]Position{{20, 0}, {30, 0}, {30, 10}, {20, 10}}, 0},
+		{"diamond", diamond(5, 5, 5), 50},
+	}
+	for _, tt := range tests {
+		if got := intersectionArea(square, tt.other); got < tt.want-1e-9 || got > tt.want+1e-9 {
+			t.Errorf("%s: intersectionArea = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
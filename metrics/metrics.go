@@ -0,0 +1,188 @@
+// Package metrics exposes Prometheus collectors for reveal processes: frames
+// processed, qrcodes found and decoded, decode latency, decode failures by
+// error and the saturation of a decoder pool
+//
+// Reveals are instrumented through Pool, which wraps a goquirc.Pool and can
+// be given to the servers of httpserver and grpcserver:
+//
+//	pool, err := goquirc.NewPool(0)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	m := metrics.New(metrics.Config{Pool: pool})
+//	prometheus.MustRegister(m)
+//	http.Handle("/decode", &httpserver.Handler{Pool: m.Pool(pool)})
+package metrics
+
+import (
+	"context"
+	"errors"
+	"image"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/quaresc/goquirc"
+)
+
+// DefaultNamespace prefixes the metric names when Config leaves Namespace
+// unset
+const DefaultNamespace = "goquirc"
+
+// DefaultBuckets spread decode latencies from a millisecond to 16 seconds
+var DefaultBuckets = prometheus.ExponentialBuckets(0.001, 2, 15)
+
+// Config tunes the collectors, a zero Namespace falls back to
+// DefaultNamespace and nil Buckets to DefaultBuckets. When Pool is set its
+// size and busy decoders are exported as well
+type Config struct {
+	Namespace   string
+	ConstLabels prometheus.Labels
+	Buckets     []float64
+	Pool        *goquirc.Pool
+}
+
+// failureLabels maps decode errors to the value of the error label of
+// failure counters, others are counted as "other"
+var failureLabels = map[error]string{
+	goquirc.ErrInvalidGridSize: "invalid_grid_size",
+	goquirc.ErrInvalidVersion:  "invalid_version",
+	goquirc.ErrFormatECC:       "format_ecc",
+	goquirc.ErrDataECC:         "data_ecc",
+	goquirc.ErrUnknownDataType: "unknown_data_type",
+	goquirc.ErrDataOverflow:    "data_overflow",
+	goquirc.ErrDataUnderflow:   "data_underflow",
+}
+
+// Metrics holds the collectors of reveal processes, it is itself a
+// prometheus.Collector to be registered
+type Metrics struct {
+	frames   prometheus.Counter
+	found    prometheus.Counter
+	decoded  prometheus.Counter
+	errors   prometheus.Counter
+	failures *prometheus.CounterVec
+	latency  prometheus.Histogram
+
+	collectors []prometheus.Collector
+}
+
+var _ prometheus.Collector = (*Metrics)(nil)
+
+var _ goquirc.Revealer = (*Pool)(nil)
+
+// New creates the collectors of reveal processes
+func New(cfg Config) *Metrics {
+	namespace := cfg.Namespace
+	if namespace == "" {
+		namespace = DefaultNamespace
+	}
+	buckets := cfg.Buckets
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	counter := func(name string, help string) prometheus.Counter {
+		return prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace, Name: name, Help: help, ConstLabels: cfg.ConstLabels,
+		})
+	}
+
+	m := &Metrics{
+		frames:  counter("frames_total", "Frames processed by reveal processes."),
+		found:   counter("codes_found_total", "Qrcodes found, decoded or not."),
+		decoded: counter("codes_decoded_total", "Qrcodes successfully decoded."),
+		errors:  counter("reveal_errors_total", "Reveal processes which returned an error."),
+		failures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Name: "decode_failures_total", Help: "Qrcodes found which could not be decoded, by error.", ConstLabels: cfg.ConstLabels,
+		}, []string{"error"}),
+		latency: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace, Name: "decode_duration_seconds", Help: "Duration of reveal processes.", ConstLabels: cfg.ConstLabels, Buckets: buckets,
+		}),
+	}
+	m.collectors = []prometheus.Collector{m.frames, m.found, m.decoded, m.errors, m.failures, m.latency}
+
+	if pool := cfg.Pool; pool != nil {
+		m.collectors = append(m.collectors,
+			prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+				Namespace: namespace, Name: "pool_decoders", Help: "Decoders held by the pool.", ConstLabels: cfg.ConstLabels,
+			}, func() float64 {
+				return float64(pool.Size())
+			}),
+			prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+				Namespace: namespace, Name: "pool_busy_decoders", Help: "Decoders of the pool busy with a reveal.", ConstLabels: cfg.ConstLabels,
+			}, func() float64 {
+				return float64(pool.Size() - pool.Idle())
+			}),
+		)
+	}
+	return m
+}
+
+// Describe sends the descriptors of the collectors
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	for _, c := range m.collectors {
+		c.Describe(ch)
+	}
+}
+
+// Collect sends the current values of the collectors
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	for _, c := range m.collectors {
+		c.Collect(ch)
+	}
+}
+
+// Observe accounts for the outcome of a reveal process which took elapsed
+func (m *Metrics) Observe(result goquirc.Result, err error, elapsed time.Duration) {
+	m.frames.Inc()
+	m.latency.Observe(elapsed.Seconds())
+	m.found.Add(float64(result.Found))
+	m.decoded.Add(float64(result.Usable))
+	for _, failure := range result.Failures {
+		m.failures.WithLabelValues(failureLabel(failure.Err)).Inc()
+	}
+	if err != nil {
+		m.errors.Inc()
+	}
+}
+
+// failureLabel returns the error label of a decode failure
+func failureLabel(err error) string {
+	for sentinel, label := range failureLabels {
+		if errors.Is(err, sentinel) {
+			return label
+		}
+	}
+	return "other"
+}
+
+// Pool returns a view of a pool observing every reveal process it runs
+func (m *Metrics) Pool(pool *goquirc.Pool) *Pool {
+	return &Pool{Pool: pool, metrics: m}
+}
+
+// Pool is a goquirc.Pool whose reveal processes are observed
+type Pool struct {
+	*goquirc.Pool
+
+	metrics *Metrics
+}
+
+// Reveal borrows a decoder for the duration of an observed reveal process
+func (p *Pool) Reveal(image *[]byte, w int, h int, opts ...goquirc.Option) (goquirc.Result, error) {
+	return p.RevealContext(context.Background(), image, w, h, opts...)
+}
+
+// RevealContext works like Reveal but stops as soon as ctx is done
+func (p *Pool) RevealContext(ctx context.Context, image *[]byte, w int, h int, opts ...goquirc.Option) (goquirc.Result, error) {
+	start := time.Now()
+	result, err := p.Pool.RevealContext(ctx, image, w, h, opts...)
+	p.metrics.Observe(result, err, time.Since(start))
+	return result, err
+}
+
+// RevealImage works like Reveal but accepts any image.Image as source
+func (p *Pool) RevealImage(img image.Image, opts ...goquirc.Option) (goquirc.Result, error) {
+	gray, w, h := goquirc.Luminance(img)
+	return p.Reveal(&gray, w, h, opts...)
+}
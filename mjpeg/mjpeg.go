@@ -0,0 +1,188 @@
+// Package mjpeg reveals the qrcodes of Motion JPEG streams served over HTTP,
+// the multipart/x-mixed-replace responses of cheap IP cameras, ESP32-CAM
+// boards and mjpg-streamer
+//
+//	err := mjpeg.Run(ctx, mjpeg.Config{URL: "http://esp32cam.local:81/stream"}, func(frame mjpeg.Frame) bool {
+//		for _, code := range frame.Result.Code {
+//			fmt.Println(code.Text())
+//		}
+//		return true
+//	})
+package mjpeg
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/quaresc/goquirc"
+	"github.com/quaresc/goquirc/internal/imagedecode"
+)
+
+// DefaultMaxFrameBytes bounds the size of a frame when Config leaves it unset
+const DefaultMaxFrameBytes = 8 << 20
+
+// DefaultMaxPixels bounds the dimensions of a frame when Config leaves it
+// unset
+const DefaultMaxPixels = imagedecode.DefaultMaxPixels
+
+// Config describes the stream to read, a nil Client falls back to
+// http.DefaultClient, a zero MaxFrameBytes to DefaultMaxFrameBytes and a zero
+// MaxPixels to DefaultMaxPixels
+type Config struct {
+	URL    string
+	Client *http.Client
+
+	// MaxFrameBytes bounds the size of a frame, larger ones are reported as
+	// failed instead of being read into memory
+	MaxFrameBytes int64
+
+	// MaxPixels bounds the dimensions of a frame, larger ones are reported
+	// as failed before they are decoded
+	MaxPixels int
+
+	// RetryDelay is waited before connecting again once the stream drops or
+	// fails, a zero RetryDelay making Run return instead
+	RetryDelay time.Duration
+
+	// OnError is called with the error of every dropped connection that is
+	// retried
+	OnError func(error)
+}
+
+// Frame is the outcome of revealing a frame of the stream
+type Frame struct {
+	// Sequence counts the frames received since Run was called
+	Sequence int
+
+	// Time is when the frame was received
+	Time time.Time
+
+	Result goquirc.Result
+	Err    error
+}
+
+// Run reads the stream and calls fn with the outcome of every frame, until
+// fn returns false, which gives a nil error, or ctx is done, which gives its
+// error. The stream ending or failing ends Run too unless RetryDelay is set.
+// A frame which cannot be decoded is reported through its Err without
+// closing the stream
+func Run(ctx context.Context, cfg Config, fn func(Frame) bool, opts ...goquirc.Option) error {
+	if cfg.URL == "" {
+		return errors.New("Missing stream URL")
+	}
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+	if cfg.MaxFrameBytes <= 0 {
+		cfg.MaxFrameBytes = DefaultMaxFrameBytes
+	}
+	if cfg.MaxPixels <= 0 {
+		cfg.MaxPixels = DefaultMaxPixels
+	}
+
+	d, err := goquirc.NewDecoder()
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	s := stream{cfg: cfg, d: d, opts: opts, fn: fn}
+	for {
+		err := s.read(ctx)
+		switch {
+		case s.stopped:
+			return nil
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case cfg.RetryDelay <= 0:
+			return err
+		}
+
+		if err == nil {
+			err = errors.New("Stream ended")
+		}
+		if cfg.OnError != nil {
+			cfg.OnError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(cfg.RetryDelay):
+		}
+	}
+}
+
+// stream holds the state of Run across connections
+type stream struct {
+	cfg  Config
+	d    *goquirc.Decoder
+	opts []goquirc.Option
+	fn   func(Frame) bool
+
+	sequence int
+	stopped  bool
+}
+
+// read connects once and reveals frames until the response ends
+func (s *stream) read(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.cfg.URL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.cfg.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Unexpected status %s", resp.Status)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") || params["boundary"] == "" {
+		return errors.New("Not a multipart MJPEG stream")
+	}
+
+	parts := multipart.NewReader(bufio.NewReader(resp.Body), params["boundary"])
+	for {
+		part, err := parts.NextPart()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if kind := part.Header.Get("Content-Type"); kind != "" && kind != "image/jpeg" {
+			continue
+		}
+
+		frame := Frame{Sequence: s.sequence, Time: time.Now()}
+		s.sequence++
+		frame.Result, frame.Err = s.reveal(part)
+		if !s.fn(frame) {
+			s.stopped = true
+			return nil
+		}
+	}
+}
+
+// reveal decodes a JPEG part and reveals its qrcodes
+func (s *stream) reveal(part *multipart.Part) (goquirc.Result, error) {
+	limited := &io.LimitedReader{R: part, N: s.cfg.MaxFrameBytes + 1}
+	gray, w, h, err := imagedecode.Luminance(limited, s.cfg.MaxPixels)
+	if limited.N == 0 {
+		return goquirc.Result{}, fmt.Errorf("Frame exceeds %d bytes", s.cfg.MaxFrameBytes)
+	}
+	if err != nil {
+		return goquirc.Result{}, err
+	}
+	return s.d.Reveal(&gray, w, h, s.opts...)
+}
@@ -0,0 +1,69 @@
+package goquirc
+
+// Erode spreads dark pixels over the square window of radius pixels around
+// them, which reconnects the dots of dot-matrix printed modules
+func Erode(radius int) Filter {
+	return func(pix []byte, w int, h int) []byte {
+		return morph(pix, w, h, radius, false)
+	}
+}
+
+// Dilate spreads light pixels over the square window of radius pixels
+// around them, which removes dark specks such as dust
+func Dilate(radius int) Filter {
+	return func(pix []byte, w int, h int) []byte {
+		return morph(pix, w, h, radius, true)
+	}
+}
+
+// Open erodes then dilates images, which removes light defects smaller than
+// the window, such as scratches through dark modules or the gaps between the
+// dots of dot-matrix printing, while keeping the size of modules
+func Open(radius int) Filter {
+	return func(pix []byte, w int, h int) []byte {
+		return morph(morph(pix, w, h, radius, false), w, h, radius, true)
+	}
+}
+
+// Close dilates then erodes images, which removes dark defects smaller than
+// the window, such as dust, while keeping the size of modules
+func Close(radius int) Filter {
+	return func(pix []byte, w int, h int) []byte {
+		return morph(morph(pix, w, h, radius, true), w, h, radius, false)
+	}
+}
+
+// morph replaces each pixel with the maximum, or the minimum, of the square
+// window of radius pixels around it, computed as two separable passes
+func morph(pix []byte, w int, h int, radius int, max bool) []byte {
+	pick := func(a byte, b byte) byte {
+		if (b > a) == max {
+			return b
+		}
+		return a
+	}
+
+	horizontal := make([]byte, len(pix))
+	for y := 0; y < h; y++ {
+		row := pix[y*w : (y+1)*w]
+		for x := range row {
+			v := row[x]
+			for dx := maxInt(x-radius, 0); dx <= minInt(x+radius, w-1); dx++ {
+				v = pick(v, row[dx])
+			}
+			horizontal[y*w+x] = v
+		}
+	}
+
+	out := make([]byte, len(pix))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := horizontal[y*w+x]
+			for dy := maxInt(y-radius, 0); dy <= minInt(y+radius, h-1); dy++ {
+				v = pick(v, horizontal[dy*w+x])
+			}
+			out[y*w+x] = v
+		}
+	}
+	return out
+}
@@ -0,0 +1,98 @@
+// Package mqttquirc publishes decoded qrcodes to an MQTT broker, so that IoT
+// deployments consume scans by subscribing to a topic rather than through
+// glue code around the decoder
+//
+// Every decoded qrcode becomes a JSON message holding its payload, corners
+// and metadata along with the source it was seen by and when:
+//
+//	{"source":"dock-3","time":"2024-05-02T09:14:07.5Z","payload":"PALLET-0042","encoding":"text",...}
+//
+// The Publisher works over a connected client of the Eclipse Paho library:
+//
+//	client := mqtt.NewClient(mqtt.NewClientOptions().AddBroker("tcp://broker.local:1883"))
+//	if token := client.Connect(); token.Wait() && token.Error() != nil {
+//		log.Fatal(token.Error())
+//	}
+//	publisher := mqttquirc.New(client, mqttquirc.Config{Topic: "scans/{source}", QoS: 1})
+//
+//	result, err := d.Reveal(&frame, w, h)
+//	if err == nil {
+//		err = publisher.Publish(ctx, "dock-3", time.Now(), result)
+//	}
+package mqttquirc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+
+	"github.com/quaresc/goquirc"
+	"github.com/quaresc/goquirc/internal/jsonresult"
+)
+
+// SourcePlaceholder is replaced by the source of the scans in Config.Topic
+const SourcePlaceholder = "{source}"
+
+// Config tunes the messages published
+type Config struct {
+	// Topic receives the messages, SourcePlaceholder standing for the
+	// source of each scan, such as "scans/{source}"
+	Topic string
+
+	// QoS is the MQTT quality of service, 0, 1 or 2
+	QoS byte
+
+	// Retained asks the broker to keep the last message of the topic for
+	// the clients subscribing later
+	Retained bool
+}
+
+// Publisher publishes decoded qrcodes with an MQTT client
+type Publisher struct {
+	client mqtt.Client
+	cfg    Config
+}
+
+// New returns a publisher sending messages through client, which must be
+// connected and stays owned by the caller
+func New(client mqtt.Client, cfg Config) *Publisher {
+	return &Publisher{client: client, cfg: cfg}
+}
+
+// Publish sends a message for every qrcode result decoded, seen by source
+// at a time, and waits until the broker acknowledged it as required by the
+// quality of service or ctx is done. Failures are not published
+func (p *Publisher) Publish(ctx context.Context, source string, at time.Time, result goquirc.Result) error {
+	if p.cfg.Topic == "" {
+		return errors.New("Missing topic")
+	}
+	if p.cfg.QoS > 2 {
+		return errors.New("Invalid quality of service, 0, 1 or 2 expected")
+	}
+	topic := strings.ReplaceAll(p.cfg.Topic, SourcePlaceholder, source)
+
+	tokens := make([]mqtt.Token, 0, len(result.Code))
+	for _, code := range result.Code {
+		message, err := json.Marshal(jsonresult.Scan{Source: source, Time: at, Code: jsonresult.NewCode(code)})
+		if err != nil {
+			return err
+		}
+		tokens = append(tokens, p.client.Publish(topic, p.cfg.QoS, p.cfg.Retained, message))
+	}
+
+	for _, token := range tokens {
+		select {
+		case <-token.Done():
+			if err := token.Error(); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
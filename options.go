@@ -0,0 +1,252 @@
+package goquirc
+
+import (
+	"image"
+	"runtime"
+	"time"
+)
+
+// Option configures a reveal process
+type Option func(*config)
+
+// config gathers all settings applied by options
+type config struct {
+	inverted bool
+	mirrored bool
+	first    bool
+	maxCodes int
+	minSize  int
+	roi      image.Rectangle
+	budget   time.Duration
+	deadline time.Time
+
+	maxDimension int
+	tileSize     int
+	tileOverlap  int
+	tileWorkers  int
+	pyramid      []float64
+	filters      []Filter
+	rotations    bool
+	rectify      bool
+	binarized    bool
+	profiles     []Profile
+
+	decodeWorkers int
+
+	visit        func(QRcode) bool
+	visitFailure func(DecodeFailure) bool
+	transform    func(Position) Position
+	observe      func(PhaseSpan)
+}
+
+// newConfig applies options over default settings
+func newConfig(opts []Option) *config {
+	cfg := new(config)
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// mapped returns a copy of the settings for a detection working on a
+// transformed image, fn translating its coordinates to the outer image
+func (cfg *config) mapped(fn func(Position) Position) *config {
+	sub := *cfg
+	if outer := cfg.transform; outer != nil {
+		sub.transform = func(p Position) Position {
+			return outer(fn(p))
+		}
+	} else {
+		sub.transform = fn
+	}
+	return &sub
+}
+
+// start records the beginning of a reveal process
+func (cfg *config) start() {
+	if cfg.budget > 0 && cfg.deadline.IsZero() {
+		cfg.deadline = time.Now().Add(cfg.budget)
+	}
+}
+
+// reloads tells whether the source image is needed again after a first
+// identification, or must be transformed before it
+func (cfg *config) reloads() bool {
+	return cfg.inverted || !cfg.roi.Empty() || cfg.maxDimension > 0 || cfg.tileSize > 0 ||
+		len(cfg.pyramid) > 0 || len(cfg.filters) > 0 ||
+		cfg.rotations || cfg.rectify || len(cfg.profiles) > 0
+}
+
+// decodesAtOnce tells whether every processing may be decoded before any of
+// them is looked at, nothing stopping the decoding or skipping processings
+// on the way
+func (cfg *config) decodesAtOnce() bool {
+	return !cfg.first && cfg.visit == nil && cfg.visitFailure == nil && cfg.minSize == 0
+}
+
+// expired tells whether the time budget of the reveal process is exceeded
+func (cfg *config) expired() bool {
+	return !cfg.deadline.IsZero() && time.Now().After(cfg.deadline)
+}
+
+// place translates corners found by a detection to source image coordinates
+func (cfg *config) place(corners [4]Position) [4]Position {
+	if cfg.transform != nil {
+		for i, c := range corners {
+			corners[i] = cfg.transform(c)
+		}
+	}
+	return corners
+}
+
+// WithInvertedSearch retries detection on the negative of the source image
+// when no usable qrcode was found, which reveals light on dark qrcodes
+func WithInvertedSearch() Option {
+	return func(cfg *config) {
+		cfg.inverted = true
+	}
+}
+
+// WithMirroredSearch retries decoding on the mirrored form of qrcodes which
+// failed to decode, such as those captured by front cameras or through a
+// mirror, successful ones are flagged as Mirrored
+func WithMirroredSearch() Option {
+	return func(cfg *config) {
+		cfg.mirrored = true
+	}
+}
+
+// WithMaxCodes limits to n the number of processings extracted and decoded,
+// Found still reports all processings identified in the image
+func WithMaxCodes(n int) Option {
+	return func(cfg *config) {
+		cfg.maxCodes = n
+	}
+}
+
+// WithMinSize skips without decoding the processings whose shortest side is
+// below pixels of the source image, which saves the decoding of tiny false
+// positives in noisy images, skipped processings are neither in Code nor in
+// Failures
+func WithMinSize(pixels int) Option {
+	return func(cfg *config) {
+		cfg.minSize = pixels
+	}
+}
+
+// WithROI restricts identification to a region of interest of the source
+// image, corners are still reported in full image coordinates
+func WithROI(rect image.Rectangle) Option {
+	return func(cfg *config) {
+		cfg.roi = rect
+	}
+}
+
+// WithDeadline bounds the time spent by a reveal process, once exceeded no
+// more processing is decoded and the result is returned as Partial
+func WithDeadline(d time.Duration) Option {
+	return func(cfg *config) {
+		cfg.budget = d
+	}
+}
+
+// WithMaxDimension downsamples source images whose long edge exceeds n
+// pixels before identification, corners are still reported in source image
+// coordinates
+func WithMaxDimension(n int) Option {
+	return func(cfg *config) {
+		cfg.maxDimension = n
+	}
+}
+
+// WithTiles splits source images larger than size pixels into square tiles
+// overlapping by overlap pixels, each one identified on its own by workers
+// goroutines. qrcodes revealed by several tiles are merged, corners are
+// reported in source image coordinates and Found sums the identifications of
+// all tiles. overlap should exceed the size of the largest qrcode expected
+func WithTiles(size int, overlap int, workers int) Option {
+	return func(cfg *config) {
+		cfg.tileSize = size
+		cfg.tileOverlap = overlap
+		cfg.tileWorkers = workers
+	}
+}
+
+// WithPyramid also runs identification on the source image resampled at
+// each of scales, 0.5, 0.25 and 2 when none is given, so that qrcodes too
+// large or too small for quirc at native resolution are revealed as well.
+// qrcodes revealed at several scales are merged and Found sums the
+// identifications of all scales
+func WithPyramid(scales ...float64) Option {
+	return func(cfg *config) {
+		if len(scales) == 0 {
+			scales = defaultPyramid
+		}
+		cfg.pyramid = scales
+	}
+}
+
+// WithPreprocessing applies filters in order to the source image before
+// identification, after its region of interest is cropped and its
+// dimensions are reduced. Several calls append their filters
+func WithPreprocessing(filters ...Filter) Option {
+	return func(cfg *config) {
+		cfg.filters = append(cfg.filters, filters...)
+	}
+}
+
+// WithGamma applies a gamma correction of g to the source image before
+// identification, see Gamma
+func WithGamma(g float64) Option {
+	return WithPreprocessing(Gamma(g))
+}
+
+// WithRotationRetries retries identification on the source image rotated by
+// quarter turns when no usable qrcode was found, corners are still reported
+// in source image coordinates
+func WithRotationRetries() Option {
+	return func(cfg *config) {
+		cfg.rotations = true
+	}
+}
+
+// WithRectification retries decoding the processings which failed on a
+// fronto-parallel view of their quadrilateral, resampled from the image,
+// which rescues qrcodes captured at steep angles
+func WithRectification() Option {
+	return func(cfg *config) {
+		cfg.rectify = true
+	}
+}
+
+// WithBinarized tells that source images are already binarized by the
+// caller, dark pixels being below 128 and light ones above, so that quirc
+// thresholding is skipped and identification only groups finder patterns.
+// It suits the output of Otsu, AdaptiveMean or Sauvola filters as well
+func WithBinarized() Option {
+	return func(cfg *config) {
+		cfg.binarized = true
+	}
+}
+
+// WithProfiles runs the reveal process with the options of each profile in
+// turn, on top of the other options, until one of them reveals a usable
+// qrcode, and reports its name in the result. DefaultProfiles suits most
+// difficult captures
+func WithProfiles(profiles ...Profile) Option {
+	return func(cfg *config) {
+		cfg.profiles = profiles
+	}
+}
+
+// WithParallelDecode extracts and decodes the processings identified in an
+// image with workers goroutines, which speeds up images holding many
+// qrcodes. A workers count lower than one falls back to GOMAXPROCS
+func WithParallelDecode(workers int) Option {
+	return func(cfg *config) {
+		if workers < 1 {
+			workers = runtime.GOMAXPROCS(0)
+		}
+		cfg.decodeWorkers = workers
+	}
+}
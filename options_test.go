@@ -0,0 +1,42 @@
+package goquirc
+
+import "testing"
+
+func TestDecodesAtOnce(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config
+		want bool
+	}{
+		{"default", config{}, true},
+		{"max codes", config{maxCodes: 2}, true},
+		{"first", config{first: true}, false},
+		{"visit", config{visit: func(QRcode) bool { return true }}, false},
+		{"visit failure", config{visitFailure: func(DecodeFailure) bool { return true }}, false},
+		{"min size", config{minSize: 10}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.cfg.decodesAtOnce(); got != tt.want {
+			t.Errorf("%s: decodesAtOnce() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReloads(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config
+		want bool
+	}{
+		{"default", config{}, false},
+		{"first", config{first: true}, false},
+		{"inverted", config{inverted: true}, true},
+		{"rotations", config{rotations: true}, true},
+		{"rectify", config{rectify: true}, true},
+	}
+	for _, tt := range tests {
+		if got := tt.cfg.reloads(); got != tt.want {
+			t.Errorf("%s: reloads() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+// Package otelquirc traces reveal processes with OpenTelemetry: every reveal
+// gets a span with the dimensions of its image and the qrcodes it found,
+// holding a child span for each identification, extraction and decoding
+//
+// Revealer wraps any goquirc.Revealer, so it can be given to the servers of
+// httpserver and grpcserver, whose request contexts carry the parent span:
+//
+//	pool, err := goquirc.NewPool(0)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	http.Handle("/decode", otelhttp.NewHandler(&httpserver.Handler{Pool: otelquirc.New(pool, nil)}, "decode"))
+package otelquirc
+
+import (
+	"context"
+	"image"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/quaresc/goquirc"
+)
+
+// ScopeName is the instrumentation scope of the tracer
+const ScopeName = "github.com/quaresc/goquirc/otelquirc"
+
+// Attribute keys set on the spans
+const (
+	WidthKey     = attribute.Key("goquirc.image.width")
+	HeightKey    = attribute.Key("goquirc.image.height")
+	RegionsKey   = attribute.Key("goquirc.regions")
+	CapstonesKey = attribute.Key("goquirc.capstones")
+	GridsKey     = attribute.Key("goquirc.grids")
+	IndexKey     = attribute.Key("goquirc.index")
+	FoundKey     = attribute.Key("goquirc.codes.found")
+	DecodedKey   = attribute.Key("goquirc.codes.decoded")
+	PartialKey   = attribute.Key("goquirc.partial")
+)
+
+// Revealer traces the reveal processes of another Revealer
+type Revealer struct {
+	revealer goquirc.Revealer
+	tracer   trace.Tracer
+}
+
+var _ goquirc.Revealer = (*Revealer)(nil)
+
+// New wraps a Revealer, creating spans with a tracer of provider, or of the
+// global provider when nil
+func New(revealer goquirc.Revealer, provider trace.TracerProvider) *Revealer {
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+	return &Revealer{revealer: revealer, tracer: provider.Tracer(ScopeName)}
+}
+
+// RevealContext runs a traced reveal process, child of the span of ctx
+func (r *Revealer) RevealContext(ctx context.Context, image *[]byte, w int, h int, opts ...goquirc.Option) (goquirc.Result, error) {
+	ctx, span := r.tracer.Start(ctx, "goquirc.Reveal", trace.WithAttributes(WidthKey.Int(w), HeightKey.Int(h)))
+	defer span.End()
+
+	// Phases are reported once over, their spans are created afterwards
+	// with the timestamps they were given
+	observe := goquirc.WithPhaseObserver(func(p goquirc.PhaseSpan) {
+		_, child := r.tracer.Start(ctx, "goquirc."+p.Phase.String(), trace.WithTimestamp(p.Start), trace.WithAttributes(phaseAttributes(p)...))
+		if p.Err != nil {
+			child.RecordError(p.Err)
+			child.SetStatus(codes.Error, p.Err.Error())
+		}
+		child.End(trace.WithTimestamp(p.End))
+	})
+	result, err := r.revealer.RevealContext(ctx, image, w, h, append(opts[:len(opts):len(opts)], observe)...)
+
+	span.SetAttributes(FoundKey.Int(result.Found), DecodedKey.Int(result.Usable), PartialKey.Bool(result.Partial))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return result, err
+}
+
+// Reveal works like RevealContext without a parent span
+func (r *Revealer) Reveal(image *[]byte, w int, h int, opts ...goquirc.Option) (goquirc.Result, error) {
+	return r.RevealContext(context.Background(), image, w, h, opts...)
+}
+
+// RevealImage works like Reveal but accepts any image.Image as source
+func (r *Revealer) RevealImage(img image.Image, opts ...goquirc.Option) (goquirc.Result, error) {
+	gray, w, h := goquirc.Luminance(img)
+	return r.Reveal(&gray, w, h, opts...)
+}
+
+// phaseAttributes returns the attributes of the span of a phase
+func phaseAttributes(p goquirc.PhaseSpan) []attribute.KeyValue {
+	if p.Phase == goquirc.PhaseIdentify {
+		return []attribute.KeyValue{
+			WidthKey.Int(p.Width),
+			HeightKey.Int(p.Height),
+			RegionsKey.Int(p.Regions),
+			CapstonesKey.Int(p.Capstones),
+			GridsKey.Int(p.Grids),
+		}
+	}
+	return []attribute.KeyValue{IndexKey.Int(p.Index)}
+}
@@ -0,0 +1,194 @@
+// Package payloads parses the structured payloads qrcodes commonly carry,
+// starting with the Wi-Fi network configurations printed on routers and
+// displayed by phones sharing a network:
+//
+//	network, err := payloads.ParseWiFi(code.Text())
+//	if err == nil {
+//		fmt.Println(network.SSID, network.Auth, network.Password)
+//	}
+package payloads
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Auth is the authentication of a Wi-Fi network
+type Auth int
+
+// Authentications named by the T field of Wi-Fi payloads
+const (
+	AuthNone    Auth = iota // nopass, or no T field
+	AuthWEP                 // WEP
+	AuthWPA                 // WPA, also standing for WPA2 and WPA3 transition
+	AuthSAE                 // SAE, WPA3 only networks
+	AuthWPA2EAP             // WPA2-EAP, enterprise networks
+)
+
+// String returns the T field value of the authentication
+func (a Auth) String() string {
+	switch a {
+	case AuthNone:
+		return "nopass"
+	case AuthWEP:
+		return "WEP"
+	case AuthWPA:
+		return "WPA"
+	case AuthSAE:
+		return "SAE"
+	case AuthWPA2EAP:
+		return "WPA2-EAP"
+	}
+	return "Auth(" + strconv.Itoa(int(a)) + ")"
+}
+
+// WiFi is a Wi-Fi network configuration, the EAP fields being only set for
+// WPA2-EAP networks
+type WiFi struct {
+	SSID     string
+	Auth     Auth
+	Password string
+	Hidden   bool
+
+	EAP               string
+	Identity          string
+	AnonymousIdentity string
+	Phase2            string
+}
+
+// ParseWiFi parses a Wi-Fi payload such as "WIFI:T:WPA;S:ssid;P:pass;;",
+// whose fields may come in any order and escape \ ; , : and " with a
+// backslash. Unknown fields are ignored
+func ParseWiFi(payload string) (WiFi, error) {
+	var network WiFi
+	if len(payload) < 5 || !strings.EqualFold(payload[:5], "WIFI:") {
+		return WiFi{}, errors.New("Not a Wi-Fi payload, WIFI: prefix expected")
+	}
+
+	fields, err := splitFields(payload[5:])
+	if err != nil {
+		return WiFi{}, err
+	}
+	auth, ssid := "", false
+	for _, f := range fields {
+		switch strings.ToUpper(f.key) {
+		case "S":
+			network.SSID, ssid = f.value, true
+		case "T":
+			auth = f.value
+		case "P":
+			network.Password = f.value
+		case "H":
+			hidden, err := strconv.ParseBool(f.value)
+			if err != nil {
+				return WiFi{}, errors.New("Invalid hidden field, true or false expected")
+			}
+			network.Hidden = hidden
+		case "E":
+			network.EAP = f.value
+		case "I":
+			network.Identity = f.value
+		case "A":
+			network.AnonymousIdentity = f.value
+		case "PH2":
+			network.Phase2 = f.value
+		}
+	}
+	if !ssid {
+		return WiFi{}, errors.New("Missing SSID")
+	}
+
+	switch strings.ToUpper(auth) {
+	case "", "NOPASS":
+		network.Auth = AuthNone
+	case "WEP":
+		network.Auth = AuthWEP
+	case "WPA", "WPA2":
+		network.Auth = AuthWPA
+	case "SAE", "WPA3":
+		network.Auth = AuthSAE
+	case "WPA2-EAP":
+		network.Auth = AuthWPA2EAP
+	default:
+		return WiFi{}, errors.New("Unknown authentication " + strconv.Quote(auth))
+	}
+	return network, nil
+}
+
+// field is a key and its unescaped value
+type field struct {
+	key   string
+	value string
+}
+
+// splitFields splits the fields following the prefix of a payload, up to the
+// empty field ending it or the end of the payload
+func splitFields(s string) ([]field, error) {
+	var fields []field
+	for len(s) > 0 {
+		raw, rest, err := cutField(s)
+		if err != nil {
+			return nil, err
+		}
+		s = rest
+		if raw == "" {
+			break
+		}
+
+		key, value, ok := strings.Cut(raw, ":")
+		if !ok {
+			return nil, errors.New("Malformed field " + strconv.Quote(raw) + ", KEY:value expected")
+		}
+		fields = append(fields, field{key: key, value: unescape(value)})
+	}
+	return fields, nil
+}
+
+// cutField returns the raw field before the first unescaped semicolon and
+// what follows it, a missing final semicolon being tolerated
+func cutField(s string) (string, string, error) {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if i+1 == len(s) {
+				return "", "", errors.New("Dangling escape")
+			}
+			i++
+		case ';':
+			return s[:i], s[i+1:], nil
+		}
+	}
+	return s, "", nil
+}
+
+// unescape removes the backslashes of a raw value, and the double quotes
+// some generators wrap SSIDs and passwords looking like hexadecimal in
+func unescape(raw string) string {
+	if len(raw) >= 2 && raw[0] == '"' && raw[len(raw)-1] == '"' && !escaped(raw, len(raw)-1) {
+		raw = raw[1 : len(raw)-1]
+	}
+	if !strings.Contains(raw, `\`) {
+		return raw
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(raw); i++ {
+		if raw[i] == '\\' && i+1 < len(raw) {
+			i++
+		}
+		b.WriteByte(raw[i])
+	}
+	return b.String()
+}
+
+// escaped tells whether the byte at i is preceded by an odd count of
+// backslashes
+func escaped(raw string, i int) bool {
+	n := 0
+	for i > 0 && raw[i-1] == '\\' {
+		n++
+		i--
+	}
+	return n%2 == 1
+}
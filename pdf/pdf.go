@@ -0,0 +1,104 @@
+// Package pdf reveals the qrcodes of the pages of PDF documents, rasterized
+// at a configurable resolution
+package pdf
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+
+	// Register the output format of Pdftoppm
+	_ "image/png"
+
+	"github.com/quaresc/goquirc"
+)
+
+// DefaultDPI is the resolution used when Config leaves it unset
+const DefaultDPI = 150
+
+// Rasterizer renders every page of a PDF document at dpi and calls page with
+// each of them in order, stopping at the first error it returns
+type Rasterizer func(ctx context.Context, document io.Reader, dpi int, page func(image.Image) error) error
+
+// Config tunes the rasterization of documents, a zero DPI falls back to
+// DefaultDPI and a nil Rasterizer to Pdftoppm
+type Config struct {
+	DPI        int
+	Rasterizer Rasterizer
+}
+
+// Scan rasterizes the pages of a PDF document and reveals their qrcodes
+func Scan(ctx context.Context, document io.Reader, cfg Config, opts ...goquirc.Option) ([]goquirc.Page, error) {
+	if cfg.DPI == 0 {
+		cfg.DPI = DefaultDPI
+	}
+	if cfg.Rasterizer == nil {
+		cfg.Rasterizer = Pdftoppm
+	}
+
+	d, err := goquirc.NewDecoder()
+	if err != nil {
+		return nil, err
+	}
+	defer d.Close()
+
+	var pages []goquirc.Page
+	err = cfg.Rasterizer(ctx, document, cfg.DPI, func(img image.Image) error {
+		page := goquirc.Page{Number: len(pages) + 1}
+		page.Result, page.Err = d.RevealImage(img, opts...)
+		pages = append(pages, page)
+		return ctx.Err()
+	})
+	return pages, err
+}
+
+// Pdftoppm rasterizes documents with the pdftoppm tool of poppler, which
+// must be found in PATH, into grayscale pages
+func Pdftoppm(ctx context.Context, document io.Reader, dpi int, page func(image.Image) error) error {
+	dir, err := os.MkdirTemp("", "goquirc-pdf")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	cmd := exec.CommandContext(ctx, "pdftoppm", "-r", fmt.Sprint(dpi), "-gray", "-png", "-", filepath.Join(dir, "page"))
+	cmd.Stdin = document
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("pdftoppm: %v: %s", err, output)
+	}
+
+	// Page numbers are zero padded to the same width, so names sort in order
+	files, err := filepath.Glob(filepath.Join(dir, "page-*.png"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		img, err := decodeFile(file)
+		if err != nil {
+			return err
+		}
+		if err := page(img); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeFile decodes the image stored in a file
+func decodeFile(name string) (image.Image, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	return img, err
+}
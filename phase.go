@@ -0,0 +1,76 @@
+package goquirc
+
+import "time"
+
+// Phase is a step of the reveal process of a processing
+type Phase int
+
+// Steps of the reveal process
+const (
+	// PhaseIdentify loads a source image and locates its processings
+	PhaseIdentify Phase = iota
+	// PhaseExtract samples the grid of a processing
+	PhaseExtract
+	// PhaseDecode corrects and decodes the grid of a processing
+	PhaseDecode
+)
+
+// String returns the name of the phase
+func (p Phase) String() string {
+	switch p {
+	case PhaseIdentify:
+		return "identify"
+	case PhaseExtract:
+		return "extract"
+	case PhaseDecode:
+		return "decode"
+	}
+	return "unknown"
+}
+
+// PhaseSpan describes a step of a reveal process once it is over
+type PhaseSpan struct {
+	Phase Phase
+	Start time.Time
+	End   time.Time
+
+	// Width and Height are the dimensions of the image identified
+	Width  int
+	Height int
+
+	// Regions, Capstones and Grids are the counts tracked by identification,
+	// Grids being the processings found
+	Regions   int
+	Capstones int
+	Grids     int
+
+	// Index is the processing extracted or decoded, and Err the decode error
+	Index int
+	Err   error
+}
+
+// WithPhaseObserver hands every step of reveal processes to fn once it is
+// over, to feed a tracing or profiling system. Identification, extraction
+// and decoding then run as separate cgo calls so that each one can be timed.
+// fn must be safe for concurrent use when reveals split images or decode in
+// parallel
+func WithPhaseObserver(fn func(PhaseSpan)) Option {
+	return func(cfg *config) {
+		cfg.observe = fn
+	}
+}
+
+// observeIdentify reports an identification which started at start
+func (qr *Processing) observeIdentify(cfg *config, start time.Time) {
+	stats := qr.stats()
+	cfg.observe(PhaseSpan{
+		Phase:     PhaseIdentify,
+		Start:     start,
+		End:       time.Now(),
+		Width:     qr.width,
+		Height:    qr.height,
+		Regions:   stats.Regions,
+		Capstones: stats.Capstones,
+		Grids:     stats.Grids,
+	})
+}
@@ -0,0 +1,228 @@
+// Package pnm decodes PGM and PPM images, in both their binary and plain
+// variants. Importing it registers both formats with image.Decode
+package pnm
+
+import (
+	"bufio"
+	"errors"
+	"image"
+	"image/color"
+	"io"
+)
+
+func init() {
+	for _, magic := range []string{"P2", "P3", "P5", "P6"} {
+		image.RegisterFormat("pnm", magic, Decode, DecodeConfig)
+	}
+}
+
+// header holds the fields preceding the samples
+type header struct {
+	magic  byte
+	width  int
+	height int
+	maxval int
+}
+
+// Decode reads a PGM or PPM image, 8 bits samples give an *image.Gray or an
+// *image.RGBA and deeper ones an *image.Gray16 or an *image.RGBA64. Pixels
+// are stored row by row as their samples are read, so that a truncated file
+// fails before the memory announced by its header is allocated
+func Decode(r io.Reader) (image.Image, error) {
+	br := bufio.NewReader(r)
+	h, err := readHeader(br)
+	if err != nil {
+		return nil, err
+	}
+
+	channels, depth := 1, 1
+	if h.magic == '3' || h.magic == '6' {
+		channels = 3
+	}
+	if h.maxval > 255 {
+		depth = 2
+	}
+	// Color images are stored with an opaque alpha channel
+	stride := h.width * depth
+	if channels == 3 {
+		stride *= 4
+	}
+
+	samples := make([]int, h.width*channels)
+	var raw []byte
+	if h.magic == '5' || h.magic == '6' {
+		raw = make([]byte, len(samples)*depth)
+	}
+	var pix []byte
+	for y := 0; y < h.height; y++ {
+		if err := readSamples(br, h, samples, raw); err != nil {
+			return nil, err
+		}
+		pix = appendRow(pix, h, channels, samples)
+	}
+
+	rect := image.Rect(0, 0, h.width, h.height)
+	switch {
+	case channels == 1 && depth == 1:
+		return &image.Gray{Pix: pix, Stride: stride, Rect: rect}, nil
+	case channels == 1:
+		return &image.Gray16{Pix: pix, Stride: stride, Rect: rect}, nil
+	case depth == 1:
+		return &image.RGBA{Pix: pix, Stride: stride, Rect: rect}, nil
+	}
+	return &image.RGBA64{Pix: pix, Stride: stride, Rect: rect}, nil
+}
+
+// appendRow appends the pixels of a row of samples scaled to 8 or 16 bits,
+// big endian in the latter case as the image package stores them
+func appendRow(pix []byte, h header, channels int, samples []int) []byte {
+	scale := func(v int) int {
+		if h.maxval == 255 || h.maxval == 65535 {
+			return v
+		}
+		if h.maxval < 256 {
+			return v * 255 / h.maxval
+		}
+		return v * 65535 / h.maxval
+	}
+
+	for i := 0; i < len(samples); i += channels {
+		for _, v := range samples[i : i+channels] {
+			if v = scale(v); h.maxval < 256 {
+				pix = append(pix, byte(v))
+			} else {
+				pix = append(pix, byte(v>>8), byte(v))
+			}
+		}
+		if channels == 3 && h.maxval < 256 {
+			pix = append(pix, 0xff)
+		} else if channels == 3 {
+			pix = append(pix, 0xff, 0xff)
+		}
+	}
+	return pix
+}
+
+// DecodeConfig returns the color model and dimensions of a PGM or PPM image
+// without decoding its samples
+func DecodeConfig(r io.Reader) (image.Config, error) {
+	h, err := readHeader(bufio.NewReader(r))
+	if err != nil {
+		return image.Config{}, err
+	}
+
+	config := image.Config{Width: h.width, Height: h.height}
+	switch {
+	case (h.magic == '2' || h.magic == '5') && h.maxval < 256:
+		config.ColorModel = color.GrayModel
+	case h.magic == '2' || h.magic == '5':
+		config.ColorModel = color.Gray16Model
+	case h.maxval < 256:
+		config.ColorModel = color.RGBAModel
+	default:
+		config.ColorModel = color.RGBA64Model
+	}
+	return config, nil
+}
+
+// readHeader parses the magic number, dimensions and maximum value, and
+// consumes the single whitespace preceding binary samples
+func readHeader(br *bufio.Reader) (header, error) {
+	var h header
+	magic := make([]byte, 2)
+	if _, err := io.ReadFull(br, magic); err != nil {
+		return h, err
+	}
+	if magic[0] != 'P' || (magic[1] != '2' && magic[1] != '3' && magic[1] != '5' && magic[1] != '6') {
+		return h, errors.New("Invalid PNM magic number")
+	}
+	h.magic = magic[1]
+
+	var err error
+	for _, field := range []*int{&h.width, &h.height, &h.maxval} {
+		if *field, err = readNumber(br); err != nil {
+			return h, err
+		}
+	}
+	if h.width < 1 || h.height < 1 || h.maxval < 1 || h.maxval > 65535 {
+		return h, errors.New("Invalid PNM header")
+	}
+	if int64(h.width)*int64(h.height) > 1<<28 {
+		return h, errors.New("PNM image is too large")
+	}
+	if _, err := br.ReadByte(); err != nil {
+		return h, err
+	}
+	return h, nil
+}
+
+// readSamples reads a row of binary samples through raw, big endian when
+// wider than a byte, or of plain decimal ones
+func readSamples(br *bufio.Reader, h header, samples []int, raw []byte) error {
+	if h.magic == '2' || h.magic == '3' {
+		for i := range samples {
+			v, err := readNumber(br)
+			if err != nil {
+				return err
+			}
+			if v > h.maxval {
+				return errors.New("PNM sample exceeds maximum value")
+			}
+			samples[i] = v
+		}
+		return nil
+	}
+
+	if _, err := io.ReadFull(br, raw); err != nil {
+		return err
+	}
+	for i := range samples {
+		if h.maxval < 256 {
+			samples[i] = int(raw[i])
+		} else {
+			samples[i] = int(raw[2*i])<<8 | int(raw[2*i+1])
+		}
+		if samples[i] > h.maxval {
+			return errors.New("PNM sample exceeds maximum value")
+		}
+	}
+	return nil
+}
+
+// readNumber reads a decimal number, skipping the whitespace and comments
+// before it, and leaves the byte following it unread
+func readNumber(br *bufio.Reader) (int, error) {
+	c, err := br.ReadByte()
+	for err == nil {
+		if c == '#' {
+			for err == nil && c != '\n' {
+				c, err = br.ReadByte()
+			}
+			continue
+		}
+		if c != ' ' && c != '\t' && c != '\n' && c != '\r' && c != '\v' && c != '\f' {
+			break
+		}
+		c, err = br.ReadByte()
+	}
+	if err != nil {
+		return 0, err
+	}
+	if c < '0' || c > '9' {
+		return 0, errors.New("Invalid PNM number")
+	}
+
+	n := 0
+	for err == nil && c >= '0' && c <= '9' {
+		if n = n*10 + int(c-'0'); n > 1<<30 {
+			return 0, errors.New("Invalid PNM number")
+		}
+		c, err = br.ReadByte()
+	}
+	if err == nil {
+		err = br.UnreadByte()
+	} else if err == io.EOF {
+		err = nil
+	}
+	return n, err
+}
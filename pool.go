@@ -0,0 +1,120 @@
+package goquirc
+
+import (
+	"context"
+	"errors"
+	"image"
+	"runtime"
+	"sync"
+)
+
+// Revealer is implemented by the types running reveal processes, such as
+// Processing, Decoder and Pool, so that services can take any of them
+type Revealer interface {
+	RevealContext(ctx context.Context, image *[]byte, w int, h int, opts ...Option) (Result, error)
+}
+
+var (
+	_ Revealer = (*Processing)(nil)
+	_ Revealer = (*Decoder)(nil)
+	_ Revealer = (*Pool)(nil)
+)
+
+// Pool shares a bounded set of pre-created decoders between goroutines, so
+// concurrent reveals never race on the same quirc context
+type Pool struct {
+	size     int
+	decoders chan *Decoder
+	done     chan struct{}
+	once     sync.Once
+}
+
+// NewPool creates size decoders ready for concurrent usage, a size lower than
+// one falls back to GOMAXPROCS
+func NewPool(size int) (*Pool, error) {
+	if size < 1 {
+		size = runtime.GOMAXPROCS(0)
+	}
+
+	p := &Pool{
+		size:     size,
+		decoders: make(chan *Decoder, size),
+		done:     make(chan struct{}),
+	}
+	for i := 0; i < size; i++ {
+		d, err := NewDecoder()
+		if err != nil {
+			close(p.decoders)
+			for d := range p.decoders {
+				d.Close()
+			}
+			return nil, err
+		}
+		p.decoders <- d
+	}
+
+	return p, nil
+}
+
+// Size returns the number of decoders held by the pool
+func (p *Pool) Size() int {
+	return p.size
+}
+
+// Idle returns the number of decoders waiting in the pool, the others being
+// busy with reveals
+func (p *Pool) Idle() int {
+	return len(p.decoders)
+}
+
+// Get waits for an idle decoder, which must be given back with Put
+func (p *Pool) Get(ctx context.Context) (*Decoder, error) {
+	select {
+	case <-p.done:
+		return nil, errors.New("Pool is closed")
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case d := <-p.decoders:
+		return d, nil
+	}
+}
+
+// Put gives back a decoder previously obtained with Get
+func (p *Pool) Put(d *Decoder) {
+	p.decoders <- d
+}
+
+// Reveal borrows a decoder for the duration of a reveal process
+func (p *Pool) Reveal(image *[]byte, w int, h int, opts ...Option) (Result, error) {
+	return p.RevealContext(context.Background(), image, w, h, opts...)
+}
+
+// RevealContext works like Reveal but stops as soon as ctx is done, including
+// while waiting for an idle decoder
+func (p *Pool) RevealContext(ctx context.Context, image *[]byte, w int, h int, opts ...Option) (Result, error) {
+	d, err := p.Get(ctx)
+	if err != nil {
+		return Result{}, err
+	}
+	defer p.Put(d)
+
+	return d.RevealContext(ctx, image, w, h, opts...)
+}
+
+// RevealImage works like Reveal but accepts any image.Image as source
+func (p *Pool) RevealImage(img image.Image, opts ...Option) (Result, error) {
+	gray, w, h := Luminance(img)
+	return p.Reveal(&gray, w, h, opts...)
+}
+
+// Close waits for all decoders to be given back and frees them
+func (p *Pool) Close() error {
+	p.once.Do(func() {
+		close(p.done)
+		for i := 0; i < p.size; i++ {
+			d := <-p.decoders
+			d.Close()
+		}
+	})
+	return nil
+}
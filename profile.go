@@ -0,0 +1,49 @@
+package goquirc
+
+import "context"
+
+// Profile is a named set of options tried as a single pass of a reveal
+// process, see WithProfiles
+type Profile struct {
+	Name    string
+	Options []Option
+}
+
+// DefaultProfiles tries the source image as is, then equalized, inverted
+// and sharpened
+var DefaultProfiles = []Profile{
+	{Name: "raw"},
+	{Name: "equalized", Options: []Option{WithPreprocessing(Equalize())}},
+	{Name: "inverted", Options: []Option{WithInvertedSearch()}},
+	{Name: "sharpened", Options: []Option{WithPreprocessing(Unsharp(1, 1))}},
+}
+
+// profiled runs a reveal process per profile until one reveals a usable
+// qrcode, the result of the last one being returned otherwise
+func (qr *Processing) profiled(ctx context.Context, image *[]byte, w int, h int, cfg *config) (Result, error) {
+	var result Result
+	for _, profile := range cfg.profiles {
+		if cfg.expired() {
+			result.Partial = true
+			break
+		}
+
+		sub := *cfg
+		sub.profiles = nil
+		// Profiles must not append to filters shared with the other ones
+		sub.filters = sub.filters[:len(sub.filters):len(sub.filters)]
+		for _, opt := range profile.Options {
+			opt(&sub)
+		}
+
+		var err error
+		if result, err = qr.reveal(ctx, image, w, h, &sub); err != nil {
+			return result, err
+		}
+		result.Profile = profile.Name
+		if result.Usable > 0 {
+			break
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,128 @@
+//go:build !cgo
+
+package goquirc_test
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"testing"
+
+	"github.com/quaresc/goquirc"
+	"github.com/quaresc/goquirc/encoder"
+)
+
+// eccLevels maps the levels of the encoder to the ones decoded
+var eccLevels = map[encoder.Level]goquirc.ECCLevel{
+	encoder.L: goquirc.ECCLevelL,
+	encoder.M: goquirc.ECCLevelM,
+	encoder.Q: goquirc.ECCLevelQ,
+	encoder.H: goquirc.ECCLevelH,
+}
+
+// roundTrip reveals the image of a symbol and checks it decodes to the
+// payload, version, level and mask it was encoded with
+func roundTrip(t *testing.T, name string, symbol *encoder.Symbol, payload string, img image.Image, opts ...goquirc.Option) goquirc.QRcode {
+	t.Helper()
+	var qr goquirc.Processing
+	result, err := qr.RevealImage(img, opts...)
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	if len(result.Code) != 1 {
+		t.Errorf("%s: decoded %d qrcodes with %d failures, want 1", name, len(result.Code), len(result.Failures))
+		return goquirc.QRcode{}
+	}
+	code := result.Code[0]
+	if code.Text() != payload || code.Version != symbol.Version || code.ECCLevel != eccLevels[symbol.Level] || code.Mask != symbol.Mask {
+		t.Errorf("%s: decoded %q version %d level %v mask %d, want %q version %d level %v mask %d", name,
+			code.Text(), code.Version, code.ECCLevel, code.Mask,
+			payload, symbol.Version, eccLevels[symbol.Level], symbol.Mask)
+	}
+	return code
+}
+
+// rotate turns an image by degrees around its center onto a white canvas
+// wide enough to hold it, sampling the nearest source pixel
+func rotate(img image.Image, degrees float64) *image.Gray {
+	b := img.Bounds()
+	sin, cos := math.Sincos(degrees * math.Pi / 180)
+	side := int(math.Ceil(float64(b.Dx())*(math.Abs(sin)+math.Abs(cos)))) + 2
+	out := image.NewGray(image.Rect(0, 0, side, side))
+	draw.Draw(out, out.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	cx, cy := float64(b.Min.X)+float64(b.Dx())/2, float64(b.Min.Y)+float64(b.Dy())/2
+	for y := 0; y < side; y++ {
+		for x := 0; x < side; x++ {
+			dx, dy := float64(x)+0.5-float64(side)/2, float64(y)+0.5-float64(side)/2
+			p := image.Pt(int(math.Floor(cx+dx*cos+dy*sin)), int(math.Floor(cy-dx*sin+dy*cos)))
+			if p.In(b) {
+				out.Set(x, y, img.At(p.X, p.Y))
+			}
+		}
+	}
+	return out
+}
+
+func TestPureRoundTripVersionsAndLevels(t *testing.T) {
+	levels := []encoder.Level{encoder.L, encoder.M, encoder.Q, encoder.H}
+	for _, version := range []int{1, 2, 4, 7, 10, 14, 20, 27, 33, 40} {
+		for i, level := range levels {
+			// Masks and module sizes rotate along the cases
+			mask := (version + i) % 8
+			size := 2 + (version+i)%3
+			payload := fmt.Sprintf("V%d%c", version, "LMQH"[i])
+			symbol, err := encoder.Encode([]byte(payload), encoder.WithVersions(version, version), encoder.WithLevel(level), encoder.WithMask(mask))
+			if err != nil {
+				t.Fatal(err)
+			}
+			name := fmt.Sprintf("version %d level %d mask %d module %dpx", version, level, mask, size)
+			roundTrip(t, name, symbol, payload, symbol.Image(encoder.WithModuleSize(size)))
+		}
+	}
+}
+
+func TestPureRoundTripMasks(t *testing.T) {
+	for mask := 0; mask < 8; mask++ {
+		for _, size := range []int{2, 3, 6} {
+			payload := fmt.Sprintf("mask %d", mask)
+			symbol, err := encoder.Encode([]byte(payload), encoder.WithVersions(3, 3), encoder.WithLevel(encoder.M), encoder.WithMask(mask))
+			if err != nil {
+				t.Fatal(err)
+			}
+			roundTrip(t, fmt.Sprintf("mask %d module %dpx", mask, size), symbol, payload, symbol.Image(encoder.WithModuleSize(size)))
+		}
+	}
+}
+
+func TestPureRoundTripRotated(t *testing.T) {
+	payload := "rotated"
+	for _, version := range []int{2, 8} {
+		symbol, err := encoder.Encode([]byte(payload), encoder.WithVersions(version, version))
+		if err != nil {
+			t.Fatal(err)
+		}
+		img := symbol.Image(encoder.WithModuleSize(5))
+		for _, degrees := range []float64{90, 180, 270, 17, 45} {
+			roundTrip(t, fmt.Sprintf("version %d rotated %g degrees", version, degrees), symbol, payload, rotate(img, degrees))
+		}
+	}
+}
+
+func TestPureRoundTripInverted(t *testing.T) {
+	payload := "inverted"
+	symbol, err := encoder.Encode([]byte(payload), encoder.WithVersions(4, 4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	img := symbol.Image(encoder.WithModuleSize(4), encoder.WithColors(color.White, color.Black))
+
+	var qr goquirc.Processing
+	if result, err := qr.RevealImage(img); err != nil || result.Usable != 0 {
+		t.Fatalf("light on dark qrcode decoded without inverted search: %d, %v", result.Usable, err)
+	}
+	if code := roundTrip(t, "inverted", symbol, payload, img, goquirc.WithInvertedSearch()); !code.Inverted {
+		t.Error("inverted qrcode is not flagged as Inverted")
+	}
+}
@@ -0,0 +1,43 @@
+package goquirc
+
+import "context"
+
+// defaultPyramid lists the scales of WithPyramid when none is given
+var defaultPyramid = []float64{0.5, 0.25, 2}
+
+// pyramid reveals the qrcodes of an image at its native resolution and at
+// each scale of the pyramid, then merges their results
+func (qr *Processing) pyramid(ctx context.Context, image *[]byte, w int, h int, cfg *config) (Result, error) {
+	// Visits and early stops apply to merged results only
+	sub := *cfg
+	sub.visit, sub.visitFailure, sub.first = nil, nil, false
+
+	native, err := qr.scan(ctx, image, w, h, &sub)
+	if err != nil {
+		return Result{}, err
+	}
+	results := []Result{native}
+	usable := native.Usable
+
+	for _, scale := range cfg.pyramid {
+		if (cfg.first && usable > 0) || cfg.expired() {
+			break
+		}
+		sw, sh := int(float64(w)*scale+0.5), int(float64(h)*scale+0.5)
+		if scale <= 0 || sw < 1 || sh < 1 || (sw == w && sh == h) {
+			continue
+		}
+
+		scaled, sw, sh := resample(*image, w, h, sw, sh)
+		result, err := qr.scan(ctx, &scaled, sw, sh, sub.mapped(func(p Position) Position {
+			return Position{p.X * w / sw, p.Y * h / sh}
+		}))
+		if err != nil {
+			return Result{}, err
+		}
+		results = append(results, result)
+		usable += result.Usable
+	}
+
+	return merge(results, cfg), nil
+}
@@ -0,0 +1,245 @@
+package goquirc
+
+// #cgo CFLAGS: -Iquirc/lib -O3 -fPIC
+// #include <quirc.h>
+// #include "regions.h"
+// #include <stdio.h>
+// #include <string.h>
+import "C"
+import (
+	"errors"
+	"unsafe"
+)
+
+// engine holds the quirc context and the processing last extracted from it
+type engine struct {
+	qrStruct *C.struct_goquirc
+	code     C.struct_quirc_code
+	data     C.struct_quirc_data
+
+	results decodeResults
+}
+
+// Version provides current version of quirc
+func (qr *Processing) Version() string {
+	return C.GoString(C.quirc_version())
+}
+
+// Create allocates memory for library usage
+func (qr *Processing) Create() error {
+	return qr.CreateRegions(RegionsLarge)
+}
+
+// CreateRegions works like Create with quirc built for a region capacity
+func (qr *Processing) CreateRegions(capacity RegionCapacity) error {
+	if capacity < RegionsLarge || capacity > RegionsSmall {
+		return errors.New("Unknown region capacity")
+	}
+	if qr.qrStruct = C.goquirc_new(C.int(capacity)); qr.qrStruct == nil {
+		return errors.New("Failed to allocate memory")
+	}
+	qr.width, qr.height = 0, 0
+	qr.regions = capacity
+	return nil
+}
+
+// Destroy frees memory after library usage
+func (qr *Processing) Destroy() {
+	C.goquirc_destroy(qr.qrStruct)
+	qr.qrStruct = nil
+}
+
+// allocated tells whether the quirc context was created and not destroyed
+func (qr *Processing) allocated() bool {
+	return qr.qrStruct != nil
+}
+
+// share returns a processing reading the identification of qr with its own
+// code and data, for concurrent extraction and decoding
+func (qr *Processing) share() *Processing {
+	return &Processing{engine: engine{qrStruct: qr.qrStruct}}
+}
+
+// Resize allocates memory for source image buffer, nothing is reallocated
+// when dimensions are unchanged since the previous call
+func (qr *Processing) Resize(w int, h int) error {
+	if w == qr.width && h == qr.height {
+		return nil
+	}
+	if err := checkDimensions(w, h); err != nil {
+		return err
+	}
+	if C.goquirc_resize(qr.qrStruct, C.int(w), C.int(h)) == -1 {
+		return errors.New("Failed to allocate video memory")
+	}
+	qr.width, qr.height = w, h
+	return nil
+}
+
+// Count returns the count of all Processings detected
+func (qr *Processing) Count() int {
+	return int(C.goquirc_count(qr.qrStruct))
+}
+
+// Extract allows to work on a specific processing
+func (qr *Processing) Extract(index int) {
+	C.goquirc_extract(qr.qrStruct, C.int(index), &qr.code)
+}
+
+// Decode gives informations from previously extracted processing
+func (qr *Processing) Decode() error {
+	return decodeError(C.quirc_decode(&qr.code, &qr.data))
+}
+
+// Load permits to load a byte array (source image) for further detection work,
+// copied at once into the quirc buffer
+func (qr *Processing) Load(image *[]byte) {
+	var w C.int
+	var h C.int
+
+	data := C.goquirc_begin(qr.qrStruct, &w, &h)
+
+	size := int(w) * int(h)
+	if len(*image) < size {
+		size = len(*image)
+	}
+	if size > 0 {
+		C.memcpy(unsafe.Pointer(data), unsafe.Pointer(&(*image)[0]), C.size_t(size))
+	}
+}
+
+// End announces detection end
+func (qr *Processing) End() {
+	C.goquirc_end(qr.qrStruct)
+}
+
+// qrcode converts the last extracted and decoded processing, whose corners
+// were already read, copying its payload at once out of quirc_data
+func (qr *Processing) qrcode(corners [4]Position) QRcode {
+	length := qr.data.payload_len
+	code := QRcode{
+		Corners:       corners,
+		DataType:      (DataType)(qr.data.data_type),
+		ECCLevel:      (ECCLevel)(qr.data.ecc_level),
+		Mask:          (int)(qr.data.mask),
+		Payload:       C.GoBytes(unsafe.Pointer(&qr.data.payload[0]), length),
+		PayloadLength: (int)(length),
+		ECI:           (int)(qr.data.eci),
+		Size:          (int)(qr.code.size),
+		Version:       (int)(qr.data.version),
+		cells:         qr.cells()}
+
+	// quirc stops at structured append headers, leaving the payload empty
+	if code.PayloadLength == 0 && code.DataType == 0 {
+		if s, err := readStream(code.Size, code.cells); err == nil && s.append != nil {
+			code.Payload = s.payload
+			code.PayloadLength = len(s.payload)
+			code.DataType = s.dataType
+			code.ECI = s.eci
+			code.Append = s.append
+		}
+	}
+
+	return code
+}
+
+// flip transposes the cells of the last extracted processing, which turns a
+// mirrored qrcode back to its normal form like quirc_flip does
+func (qr *Processing) flip() {
+	size := int(qr.code.size)
+	cells := qr.cells()
+	for i := range cells {
+		qr.code.cell_bitmap[i] = 0
+	}
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			i := x*size + y
+			if cells[i>>3]>>uint(i&7)&1 != 0 {
+				j := y*size + x
+				qr.code.cell_bitmap[j>>3] |= 1 << uint(j&7)
+			}
+		}
+	}
+}
+
+// corners returns the corners of the last extracted processing
+func (qr *Processing) corners() [4]Position {
+	return [4]Position{
+		Position{
+			(int)(qr.code.corners[0].x),
+			(int)(qr.code.corners[0].y),
+		},
+		Position{
+			(int)(qr.code.corners[1].x),
+			(int)(qr.code.corners[1].y),
+		},
+		Position{
+			(int)(qr.code.corners[2].x),
+			(int)(qr.code.corners[2].y),
+		},
+		Position{
+			(int)(qr.code.corners[3].x),
+			(int)(qr.code.corners[3].y),
+		}}
+}
+
+// cells returns a copy of the bitmap of the last extracted processing
+func (qr *Processing) cells() []byte {
+	size := int(qr.code.size)
+	return C.GoBytes(unsafe.Pointer(&qr.code.cell_bitmap[0]), C.int((size*size+7)/8))
+}
+
+// buffer starts a detection and returns a slice view over the source image
+// buffer of quirc along with its dimensions
+func (qr *Processing) buffer() ([]byte, int, int) {
+	var w C.int
+	var h C.int
+
+	data := C.goquirc_begin(qr.qrStruct, &w, &h)
+	size := int(w) * int(h)
+	if data == nil || size == 0 {
+		return nil, int(w), int(h)
+	}
+
+	return unsafe.Slice((*byte)(unsafe.Pointer(data)), size), int(w), int(h)
+}
+
+// decodeErrors maps quirc_decode_error_t values to their sentinel
+var decodeErrors = map[C.quirc_decode_error_t]error{
+	C.QUIRC_ERROR_INVALID_GRID_SIZE: ErrInvalidGridSize,
+	C.QUIRC_ERROR_INVALID_VERSION:   ErrInvalidVersion,
+	C.QUIRC_ERROR_FORMAT_ECC:        ErrFormatECC,
+	C.QUIRC_ERROR_DATA_ECC:          ErrDataECC,
+	C.QUIRC_ERROR_UNKNOWN_DATA_TYPE: ErrUnknownDataType,
+	C.QUIRC_ERROR_DATA_OVERFLOW:     ErrDataOverflow,
+	C.QUIRC_ERROR_DATA_UNDERFLOW:    ErrDataUnderflow,
+}
+
+// decodeError converts a quirc_decode_error_t value, unknown values fall back
+// to the message of quirc_strerror
+func decodeError(code C.quirc_decode_error_t) error {
+	if code == C.QUIRC_SUCCESS {
+		return nil
+	}
+	if err, ok := decodeErrors[code]; ok {
+		return err
+	}
+	return errors.New(C.GoString(C.quirc_strerror(code)))
+}
+
+// stats reads the buffer sizes and identification counts of the context
+func (qr *Processing) stats() Stats {
+	var c C.struct_goquirc_stats
+	C.goquirc_stats(qr.qrStruct, &c)
+
+	return Stats{
+		ImageBytes:     int(c.image),
+		PixelBytes:     int(c.pixels),
+		FloodFillBytes: int(c.vars),
+		ContextBytes:   int(c.context),
+		Regions:        int(c.regions),
+		MaxRegions:     int(c.max_regions),
+		Capstones:      int(c.capstones),
+		Grids:          int(c.grids),
+	}
+}
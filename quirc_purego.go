@@ -0,0 +1,226 @@
+//go:build !cgo
+
+package goquirc
+
+import (
+	"context"
+	"errors"
+	"unsafe"
+
+	"github.com/quaresc/goquirc/internal/qrspec"
+)
+
+// engine holds the pure Go identifier used when cgo is disabled, such as on
+// js/wasm and wasip1, and the processing last extracted from it
+type engine struct {
+	q *identifier
+
+	// corners, size and cells of the processing last extracted
+	code struct {
+		corners [4]Position
+		size    int
+		cells   []byte
+	}
+	data stream
+}
+
+// Version names the pure Go engine, which follows the identification and
+// decoding of quirc without matching a release of the C library, its
+// threshold being global rather than computed along rows
+func (qr *Processing) Version() string {
+	return "pure Go"
+}
+
+// Create allocates memory for library usage
+func (qr *Processing) Create() error {
+	return qr.CreateRegions(RegionsLarge)
+}
+
+// CreateRegions works like Create with identification bounded by a region
+// capacity, the pure Go engine always labelling regions in a 16 bits pixel
+// map
+func (qr *Processing) CreateRegions(capacity RegionCapacity) error {
+	if capacity < RegionsLarge || capacity > RegionsSmall {
+		return errors.New("Unknown region capacity")
+	}
+	qr.q = newIdentifier(capacity)
+	qr.width, qr.height = 0, 0
+	qr.regions = capacity
+	return nil
+}
+
+// Destroy frees memory after library usage
+func (qr *Processing) Destroy() {
+	qr.q = nil
+}
+
+// allocated tells whether the context was created and not destroyed
+func (qr *Processing) allocated() bool {
+	return qr.q != nil
+}
+
+// share returns a processing reading the identification of qr with its own
+// code and data, for concurrent extraction and decoding
+func (qr *Processing) share() *Processing {
+	return &Processing{engine: engine{q: qr.q}}
+}
+
+// Resize allocates memory for source image buffer, nothing is reallocated
+// when dimensions are unchanged since the previous call
+func (qr *Processing) Resize(w int, h int) error {
+	if w == qr.width && h == qr.height {
+		return nil
+	}
+	if err := checkDimensions(w, h); err != nil {
+		return err
+	}
+	qr.q.resize(w, h)
+	qr.width, qr.height = w, h
+	return nil
+}
+
+// Count returns the count of all Processings detected
+func (qr *Processing) Count() int {
+	return len(qr.q.grids)
+}
+
+// Extract allows to work on a specific processing
+func (qr *Processing) Extract(index int) {
+	if index < 0 || index >= len(qr.q.grids) {
+		return
+	}
+
+	corners, size, cells := qr.q.extract(index)
+	for i, p := range corners {
+		qr.code.corners[i] = Position{p.x, p.y}
+	}
+	qr.code.size, qr.code.cells = size, cells
+}
+
+// Decode gives informations from previously extracted processing
+func (qr *Processing) Decode() error {
+	size := qr.code.size
+	if size < 17 || (size-17)%4 != 0 {
+		return ErrInvalidGridSize
+	}
+	if version := (size - 17) / 4; version < qrspec.MinVersion || version > qrspec.MaxVersion {
+		return ErrInvalidVersion
+	}
+
+	var err error
+	qr.data, err = readStream(size, qr.code.cells)
+	return err
+}
+
+// Load permits to load a byte array (source image) for further detection work,
+// copied at once into the image buffer
+func (qr *Processing) Load(image *[]byte) {
+	copy(qr.q.image, *image)
+}
+
+// End announces detection end
+func (qr *Processing) End() {
+	qr.q.end(false)
+}
+
+// EndBinarized works like End on a source image already binarized by the
+// caller, pixels below 128 being dark, and only groups the finder patterns
+func (qr *Processing) EndBinarized() {
+	qr.q.end(true)
+}
+
+// decodeAll works like Load, End and the extraction and decoding of all
+// processings, which the pure Go engine runs in turn. It returns the count
+// of processings found and the outcomes of those within the limit
+func (qr *Processing) decodeAll(image []byte, cfg *config, inverted bool) (int, []outcome) {
+	qr.Load(&image)
+	if inverted {
+		qr.invert()
+	}
+	qr.End()
+
+	found := qr.Count()
+	limit := found
+	if cfg.maxCodes > 0 && cfg.maxCodes < limit {
+		limit = cfg.maxCodes
+	}
+	outcomes := make([]outcome, limit)
+	for i := range outcomes {
+		outcomes[i] = qr.process(context.Background(), i, cfg, inverted)
+	}
+	return found, outcomes
+}
+
+// qrcode converts the last extracted and decoded processing, whose corners
+// were already read
+func (qr *Processing) qrcode(corners [4]Position) QRcode {
+	return QRcode{
+		Corners:       corners,
+		DataType:      qr.data.dataType,
+		ECCLevel:      qr.data.level,
+		Mask:          qr.data.mask,
+		Payload:       qr.data.payload,
+		PayloadLength: len(qr.data.payload),
+		ECI:           qr.data.eci,
+		Append:        qr.data.append,
+		Size:          qr.code.size,
+		Version:       qr.data.version,
+		cells:         qr.cells()}
+}
+
+// flip transposes the cells of the last extracted processing, which turns a
+// mirrored qrcode back to its normal form
+func (qr *Processing) flip() {
+	size := qr.code.size
+	cells := make([]byte, len(qr.code.cells))
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			i := x*size + y
+			if qr.code.cells[i>>3]>>uint(i&7)&1 != 0 {
+				j := y*size + x
+				cells[j>>3] |= 1 << uint(j&7)
+			}
+		}
+	}
+	qr.code.cells = cells
+}
+
+// corners returns the corners of the last extracted processing
+func (qr *Processing) corners() [4]Position {
+	return qr.code.corners
+}
+
+// cells returns a copy of the bitmap of the last extracted processing
+func (qr *Processing) cells() []byte {
+	return append([]byte(nil), qr.code.cells...)
+}
+
+// buffer starts a detection and returns the source image buffer along with
+// its dimensions
+func (qr *Processing) buffer() ([]byte, int, int) {
+	if len(qr.q.image) == 0 {
+		return nil, qr.q.w, qr.q.h
+	}
+	return qr.q.image, qr.q.w, qr.q.h
+}
+
+// stats reads the buffer sizes and identification counts of the context
+func (qr *Processing) stats() Stats {
+	q := qr.q
+	size := q.w * q.h
+	tables := int(unsafe.Sizeof(*q)) +
+		cap(q.regions)*int(unsafe.Sizeof(region{})) +
+		cap(q.capstones)*int(unsafe.Sizeof(capstone{})) +
+		cap(q.grids)*int(unsafe.Sizeof(qrGrid{}))
+
+	return Stats{
+		ImageBytes:     size,
+		PixelBytes:     size * int(unsafe.Sizeof(q.pixels[0])),
+		FloodFillBytes: cap(q.stack) * int(unsafe.Sizeof(point{})),
+		ContextBytes:   tables,
+		Regions:        len(q.regions) - pixelRegion,
+		MaxRegions:     q.labels - pixelRegion,
+		Capstones:      len(q.capstones),
+		Grids:          len(q.grids),
+	}
+}
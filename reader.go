@@ -0,0 +1,41 @@
+package goquirc
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"io"
+
+	// Register supported formats for image.Decode
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	_ "github.com/quaresc/goquirc/pnm"
+	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/tiff"
+	_ "golang.org/x/image/webp"
+)
+
+// DecodeReader sniffs the format of an encoded image (PNG, JPEG, GIF, WebP,
+// TIFF, BMP, PGM or PPM), decodes it and reveals all qrcodes it contains
+func DecodeReader(r io.Reader, opts ...Option) (Result, error) {
+	var header bytes.Buffer
+
+	config, _, err := image.DecodeConfig(io.TeeReader(r, &header))
+	if err != nil {
+		return Result{}, err
+	}
+	if config.Width == 0 || config.Height == 0 {
+		return Result{}, errors.New("Empty source image")
+	}
+
+	// Replay the sniffed header before the rest of the stream
+	img, _, err := image.Decode(io.MultiReader(&header, r))
+	if err != nil {
+		return Result{}, err
+	}
+
+	var qr Processing
+	return qr.RevealImage(img, opts...)
+}
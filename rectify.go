@@ -0,0 +1,87 @@
+package goquirc
+
+import (
+	"context"
+	"math"
+)
+
+// rectified reveals the qrcodes of an image, then retries the processings
+// which failed to decode on a fronto-parallel view of their quadrilateral
+func (qr *Processing) rectified(ctx context.Context, image *[]byte, w int, h int, cfg *config) (Result, error) {
+	// Visits and early stops apply to merged results only
+	sub := *cfg
+	sub.visit, sub.visitFailure, sub.first, sub.rectify = nil, nil, false, false
+
+	result, err := qr.detect(ctx, image, w, h, &sub)
+	if err != nil {
+		return Result{}, err
+	}
+
+	var rescued Result
+	for _, failure := range result.Failures {
+		if (cfg.first && result.Usable+rescued.Usable > 0) || cfg.expired() {
+			break
+		}
+		warped, side, fn := warp(*image, w, h, failure.frame)
+		retry, err := qr.detect(ctx, &warped, side, side, sub.mapped(fn))
+		if err != nil {
+			return Result{}, err
+		}
+		rescued.Usable += retry.Usable
+		rescued.Code = append(rescued.Code, retry.Code...)
+	}
+
+	return merge([]Result{result, rescued}, cfg), nil
+}
+
+// warp resamples the quadrilateral of corners with a quiet zone around it
+// into a square image, and returns it with its side and the function mapping
+// its coordinates back to the source image
+func warp(pix []byte, w int, h int, corners [4]Position) ([]byte, int, func(Position) Position) {
+	longest := 0.0
+	for i, c := range corners {
+		next := corners[(i+1)%4]
+		longest = math.Max(longest, math.Hypot(float64(next.X-c.X), float64(next.Y-c.Y)))
+	}
+	size := int(math.Min(math.Max(longest, 84), 1024))
+	margin := size / 8
+	side := size + 2*margin
+
+	m := QRcode{Corners: corners, Size: 1}.Homography()
+	project := func(x float64, y float64) (float64, float64) {
+		return m.Map((x-float64(margin))/float64(size), (y-float64(margin))/float64(size))
+	}
+
+	out := make([]byte, side*side)
+	for y := 0; y < side; y++ {
+		for x := 0; x < side; x++ {
+			sx, sy := project(float64(x)+0.5, float64(y)+0.5)
+			out[y*side+x] = bilinear(pix, w, h, sx-0.5, sy-0.5)
+		}
+	}
+
+	return out, side, func(p Position) Position {
+		x, y := project(float64(p.X), float64(p.Y))
+		return Position{int(math.Floor(x + 0.5)), int(math.Floor(y + 0.5))}
+	}
+}
+
+// bilinear samples an image between pixel centers, outside of which it is
+// considered white
+func bilinear(pix []byte, w int, h int, x float64, y float64) byte {
+	if math.IsNaN(x) || math.IsNaN(y) || x < -1 || y < -1 || x > float64(w) || y > float64(h) {
+		return 255
+	}
+	x0, y0 := int(math.Floor(x)), int(math.Floor(y))
+	fx, fy := x-float64(x0), y-float64(y0)
+	at := func(x int, y int) float64 {
+		if x < 0 || y < 0 || x >= w || y >= h {
+			return 255
+		}
+		return float64(pix[y*w+x])
+	}
+
+	top := (1-fx)*at(x0, y0) + fx*at(x0+1, y0)
+	bottom := (1-fx)*at(x0, y0+1) + fx*at(x0+1, y0+1)
+	return byte((1-fy)*top + fy*bottom + 0.5)
+}
@@ -0,0 +1,77 @@
+#include <stdlib.h>
+#include "regions.h"
+
+// goquirc_new allocates a quirc context with a region capacity, or returns
+// NULL when memory is exhausted or the capacity is unknown
+struct goquirc *goquirc_new(int capacity)
+{
+	const struct goquirc_variant *variant;
+
+	switch (capacity) {
+	case GOQUIRC_REGIONS_LARGE:
+		variant = &goquirc_large;
+		break;
+	case GOQUIRC_REGIONS_MEDIUM:
+		variant = &goquirc_medium;
+		break;
+	case GOQUIRC_REGIONS_SMALL:
+		variant = &goquirc_small;
+		break;
+	default:
+		return NULL;
+	}
+
+	struct goquirc *g = malloc(sizeof(*g));
+	if (!g)
+		return NULL;
+	g->variant = variant;
+	g->q = variant->create();
+	if (!g->q) {
+		free(g);
+		return NULL;
+	}
+	return g;
+}
+
+void goquirc_destroy(struct goquirc *g)
+{
+	g->variant->destroy(g->q);
+	free(g);
+}
+
+int goquirc_resize(struct goquirc *g, int w, int h)
+{
+	return g->variant->resize(g->q, w, h);
+}
+
+uint8_t *goquirc_begin(struct goquirc *g, int *w, int *h)
+{
+	return g->variant->begin(g->q, w, h);
+}
+
+void goquirc_end(struct goquirc *g)
+{
+	g->variant->end(g->q);
+}
+
+void goquirc_end_binarized(struct goquirc *g)
+{
+	g->variant->end_binarized(g->q);
+}
+
+int goquirc_count(const struct goquirc *g)
+{
+	return g->variant->count(g->q);
+}
+
+void goquirc_extract(const struct goquirc *g, int index,
+		     struct quirc_code *code)
+{
+	g->variant->extract(g->q, index, code);
+}
+
+void goquirc_stats(const struct goquirc *g, struct goquirc_stats *stats)
+{
+	g->variant->stats(g->q, stats);
+	stats->context += sizeof(*g);
+}
@@ -0,0 +1,30 @@
+package goquirc
+
+// RegionCapacity selects the build of quirc a context is allocated with. It
+// bounds the count of regions, dark or light connected areas, labelled per
+// frame and the memory held by the context: busy frames running out of
+// regions miss their qrcodes
+type RegionCapacity int
+
+// Region capacities, every build is linked in and selected at allocation
+const (
+	// RegionsLarge labels up to 65532 regions in a 16 bits pixel map, it is
+	// the capacity of NewDecoder
+	RegionsLarge RegionCapacity = iota
+	// RegionsMedium labels up to 4092 regions in a 16 bits pixel map, with a
+	// region table of 64KB instead of 1MB
+	RegionsMedium
+	// RegionsSmall labels up to 252 regions in the source image buffer
+	// itself, which saves both the table and the pixel map
+	RegionsSmall
+)
+
+// NewDecoderRegions works like NewDecoder with quirc built for a region
+// capacity, smaller ones suiting embedded devices decoding simple frames
+func NewDecoderRegions(capacity RegionCapacity) (*Decoder, error) {
+	d := new(Decoder)
+	if err := d.qr.CreateRegions(capacity); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
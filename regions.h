@@ -0,0 +1,65 @@
+#ifndef GOQUIRC_REGIONS_H_
+#define GOQUIRC_REGIONS_H_
+
+#include <stddef.h>
+#include <stdint.h>
+#include <quirc.h>
+
+// goquirc_stats describes the memory held by a quirc context and the counts
+// tracked by its last identification
+struct goquirc_stats {
+	size_t	image;
+	size_t	pixels;
+	size_t	vars;
+	size_t	context;
+	int	regions;
+	int	max_regions;
+	int	capstones;
+	int	grids;
+};
+
+// goquirc_variant holds the entry points of quirc built with a region
+// capacity, as struct quirc is laid out after QUIRC_MAX_REGIONS
+struct goquirc_variant {
+	struct quirc	*(*create)(void);
+	void		(*destroy)(struct quirc *q);
+	int		(*resize)(struct quirc *q, int w, int h);
+	uint8_t		*(*begin)(struct quirc *q, int *w, int *h);
+	void		(*end)(struct quirc *q);
+	void		(*end_binarized)(struct quirc *q);
+	int		(*count)(const struct quirc *q);
+	void		(*extract)(const struct quirc *q, int index,
+				   struct quirc_code *code);
+	void		(*stats)(const struct quirc *q,
+				 struct goquirc_stats *stats);
+};
+
+// Region capacities, in the order of RegionCapacity
+enum {
+	GOQUIRC_REGIONS_LARGE,
+	GOQUIRC_REGIONS_MEDIUM,
+	GOQUIRC_REGIONS_SMALL,
+};
+
+extern const struct goquirc_variant goquirc_large;
+extern const struct goquirc_variant goquirc_medium;
+extern const struct goquirc_variant goquirc_small;
+
+// goquirc is a quirc context bound to the variant which allocated it
+struct goquirc {
+	const struct goquirc_variant	*variant;
+	struct quirc			*q;
+};
+
+struct goquirc *goquirc_new(int capacity);
+void goquirc_destroy(struct goquirc *g);
+int goquirc_resize(struct goquirc *g, int w, int h);
+uint8_t *goquirc_begin(struct goquirc *g, int *w, int *h);
+void goquirc_end(struct goquirc *g);
+void goquirc_end_binarized(struct goquirc *g);
+int goquirc_count(const struct goquirc *g);
+void goquirc_extract(const struct goquirc *g, int index,
+		     struct quirc_code *code);
+void goquirc_stats(const struct goquirc *g, struct goquirc_stats *stats);
+
+#endif
@@ -0,0 +1,4 @@
+// quirc built for 65534 regions, labelled in a 16 bits pixel map
+#define QUIRC_MAX_REGIONS	65534
+#define GOQUIRC_VARIANT		goquirc_large
+#include "regions_variant.h"
@@ -0,0 +1,4 @@
+// quirc built for 4094 regions, with a region table 16 times smaller
+#define QUIRC_MAX_REGIONS	4094
+#define GOQUIRC_VARIANT		goquirc_medium
+#include "regions_variant.h"
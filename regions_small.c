@@ -0,0 +1,4 @@
+// quirc built for 254 regions, labelled in the source image buffer itself
+#define QUIRC_MAX_REGIONS	254
+#define GOQUIRC_VARIANT		goquirc_small
+#include "regions_variant.h"
@@ -0,0 +1,65 @@
+// regions_variant.h builds quirc for the QUIRC_MAX_REGIONS defined by the
+// including file, with its public functions renamed after GOQUIRC_VARIANT so
+// that several variants and libquirc link together. It defines the
+// goquirc_variant named GOQUIRC_VARIANT itself
+
+#include "regions.h"
+
+#define GOQUIRC_NAME(variant, name)	variant##_##name
+#define GOQUIRC_RENAME(variant, name)	GOQUIRC_NAME(variant, name)
+#define GOQUIRC_LOCAL(name)		GOQUIRC_RENAME(GOQUIRC_VARIANT, name)
+
+#define quirc_version	GOQUIRC_LOCAL(version)
+#define quirc_strerror	GOQUIRC_LOCAL(strerror)
+#define quirc_new	GOQUIRC_LOCAL(new)
+#define quirc_destroy	GOQUIRC_LOCAL(destroy)
+#define quirc_resize	GOQUIRC_LOCAL(resize)
+#define quirc_count	GOQUIRC_LOCAL(count)
+#define quirc_begin	GOQUIRC_LOCAL(begin)
+#define quirc_end	GOQUIRC_LOCAL(end)
+#define quirc_extract	GOQUIRC_LOCAL(extract)
+
+#include <quirc.c>
+#include <identify.c>
+
+// end_binarized works like quirc_end on an image already binarized, dark
+// pixels being below 128, skipping quirc thresholding
+static void end_binarized(struct quirc *q)
+{
+	int i;
+
+	pixels_setup(q, 128);
+	for (i = 0; i < q->h; i++)
+		finder_scan(q, i);
+	for (i = 0; i < q->num_capstones; i++)
+		test_grouping(q, i);
+}
+
+// variant_stats reads the buffer sizes and identification counts of q
+static void variant_stats(const struct quirc *q, struct goquirc_stats *stats)
+{
+	size_t size = (size_t)q->w * q->h;
+
+	stats->image = size;
+	stats->pixels = QUIRC_PIXEL_ALIAS_IMAGE ? 0 : size * sizeof(quirc_pixel_t);
+	stats->vars = q->num_flood_fill_vars * sizeof(struct quirc_flood_fill_vars);
+	stats->context = sizeof(*q);
+	stats->regions = q->num_regions - QUIRC_PIXEL_REGION;
+	if (stats->regions < 0)
+		stats->regions = 0;
+	stats->max_regions = QUIRC_MAX_REGIONS - QUIRC_PIXEL_REGION;
+	stats->capstones = q->num_capstones;
+	stats->grids = q->num_grids;
+}
+
+const struct goquirc_variant GOQUIRC_VARIANT = {
+	.create		= quirc_new,
+	.destroy	= quirc_destroy,
+	.resize		= quirc_resize,
+	.begin		= quirc_begin,
+	.end		= quirc_end,
+	.end_binarized	= end_binarized,
+	.count		= quirc_count,
+	.extract	= quirc_extract,
+	.stats		= variant_stats,
+};
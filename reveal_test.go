@@ -0,0 +1,130 @@
+package goquirc_test
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/quaresc/goquirc"
+	"github.com/quaresc/goquirc/encoder"
+)
+
+// moduleSize is the side in pixels of the modules drawn by row
+const moduleSize = 4
+
+// row draws qrcodes of payloads side by side, separated by their quiet zones
+// only, and returns the luminance of the image
+func row(t *testing.T, payloads ...string) ([]byte, int, int) {
+	t.Helper()
+	var symbols []image.Image
+	w, h := 0, 0
+	for _, payload := range payloads {
+		symbol, err := encoder.Encode([]byte(payload), encoder.WithVersions(2, 2))
+		if err != nil {
+			t.Fatal(err)
+		}
+		img := symbol.Image(encoder.WithModuleSize(moduleSize))
+		symbols = append(symbols, img)
+		w += img.Bounds().Dx()
+		if img.Bounds().Dy() > h {
+			h = img.Bounds().Dy()
+		}
+	}
+
+	canvas := image.NewGray(image.Rect(0, 0, w, h))
+	draw.Draw(canvas, canvas.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	x := 0
+	for _, img := range symbols {
+		draw.Draw(canvas, img.Bounds().Add(image.Pt(x, 0)), img, image.Point{}, draw.Src)
+		x += img.Bounds().Dx()
+	}
+	return canvas.Pix, w, h
+}
+
+// decodes returns an option counting the processings decoded
+func decodes(count *int) goquirc.Option {
+	return goquirc.WithPhaseObserver(func(span goquirc.PhaseSpan) {
+		if span.Phase == goquirc.PhaseDecode {
+			*count++
+		}
+	})
+}
+
+func TestRevealFirstDecodesOneRegion(t *testing.T) {
+	gray, w, h := row(t, "first", "second")
+	var all goquirc.Processing
+	result, err := all.Reveal(&gray, w, h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Usable != 2 {
+		t.Fatalf("Reveal decoded %d qrcodes, want 2", result.Usable)
+	}
+
+	for _, opts := range [][]goquirc.Option{nil, {goquirc.WithMaxCodes(2)}} {
+		count := 0
+		var qr goquirc.Processing
+		if _, err := qr.RevealFirst(&gray, w, h, append(opts, decodes(&count))...); err != nil {
+			t.Fatal(err)
+		}
+		if count != 1 {
+			t.Errorf("RevealFirst decoded %d regions, want 1", count)
+		}
+	}
+}
+
+func TestRevealFuncStopsDecoding(t *testing.T) {
+	gray, w, h := row(t, "first", "second")
+	count, visited := 0, 0
+	var qr goquirc.Processing
+	err := qr.RevealFunc(&gray, w, h, func(goquirc.QRcode) bool {
+		visited++
+		return false
+	}, decodes(&count))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if visited != 1 || count != 1 {
+		t.Errorf("RevealFunc visited %d qrcodes and decoded %d regions, want 1 and 1", visited, count)
+	}
+}
+
+func TestMinSizeSkipsDecoding(t *testing.T) {
+	gray, w, h := row(t, "first", "second")
+
+	// Version 2 symbols are 25 modules wide
+	count := 0
+	var qr goquirc.Processing
+	result, err := qr.Reveal(&gray, w, h, goquirc.WithMinSize(26*moduleSize), decodes(&count))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 || result.Usable != 0 || len(result.Failures) != 0 {
+		t.Errorf("WithMinSize decoded %d regions into %d qrcodes and %d failures, want none", count, result.Usable, len(result.Failures))
+	}
+	if result.Found != 2 {
+		t.Errorf("Found = %d, want 2", result.Found)
+	}
+
+	count = 0
+	result, err = qr.Reveal(&gray, w, h, goquirc.WithMinSize(20*moduleSize), decodes(&count))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 || result.Usable != 2 {
+		t.Errorf("WithMinSize below the qrcodes decoded %d regions into %d qrcodes, want 2 and 2", count, result.Usable)
+	}
+}
+
+func TestPyramidKeepsAdjacentCodes(t *testing.T) {
+	gray, w, h := row(t, "first", "second")
+	var qr goquirc.Processing
+	result, err := qr.Reveal(&gray, w, h, goquirc.WithPyramid(1, 0.75))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Usable != 2 {
+		t.Errorf("Reveal over a pyramid decoded %d qrcodes, want 2", result.Usable)
+	}
+}
@@ -0,0 +1,22 @@
+package goquirc
+
+import (
+	"errors"
+	"image"
+)
+
+// cropROI copies the part of a source image within a region of interest and
+// returns it with the region clipped to the image
+func cropROI(pix []byte, w int, h int, rect image.Rectangle) ([]byte, image.Rectangle, error) {
+	roi := rect.Intersect(image.Rect(0, 0, w, h))
+	if roi.Empty() {
+		return nil, roi, errors.New("Region of interest outside of source image")
+	}
+
+	crop := make([]byte, roi.Dx()*roi.Dy())
+	for y := 0; y < roi.Dy(); y++ {
+		offset := (roi.Min.Y+y)*w + roi.Min.X
+		copy(crop[y*roi.Dx():(y+1)*roi.Dx()], pix[offset:offset+roi.Dx()])
+	}
+	return crop, roi, nil
+}
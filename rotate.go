@@ -0,0 +1,57 @@
+package goquirc
+
+import "context"
+
+// rotated reveals the qrcodes of an image and, when none is usable, retries
+// on its copies rotated by a quarter turn, a half turn and three quarters
+func (qr *Processing) rotated(ctx context.Context, image *[]byte, w int, h int, cfg *config) (Result, error) {
+	result, err := qr.search(ctx, image, w, h, cfg)
+	if err != nil || result.Usable > 0 {
+		return result, err
+	}
+
+	for quarter := 1; quarter < 4 && !cfg.expired(); quarter++ {
+		turned, tw, th := rotate(*image, w, h, quarter)
+		retry, err := qr.search(ctx, &turned, tw, th, cfg.mapped(func(p Position) Position {
+			return unrotate(p, w, h, quarter)
+		}))
+		if err != nil {
+			return result, err
+		}
+		if retry.Usable > 0 {
+			return retry, nil
+		}
+	}
+	return result, nil
+}
+
+// rotate returns a copy of an image turned clockwise by quarter quarters,
+// with its dimensions
+func rotate(pix []byte, w int, h int, quarter int) ([]byte, int, int) {
+	tw, th := w, h
+	if quarter%2 == 1 {
+		tw, th = h, w
+	}
+	out := make([]byte, len(pix))
+	for y := 0; y < th; y++ {
+		for x := 0; x < tw; x++ {
+			p := unrotate(Position{x, y}, w, h, quarter)
+			out[y*tw+x] = pix[p.Y*w+p.X]
+		}
+	}
+	return out, tw, th
+}
+
+// unrotate maps a position of an image turned clockwise by quarter quarters
+// back to the original image of w by h pixels
+func unrotate(p Position, w int, h int, quarter int) Position {
+	switch quarter {
+	case 1:
+		return Position{p.Y, h - 1 - p.X}
+	case 2:
+		return Position{w - 1 - p.X, h - 1 - p.Y}
+	case 3:
+		return Position{w - 1 - p.Y, p.X}
+	}
+	return p
+}
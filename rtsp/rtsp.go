@@ -0,0 +1,209 @@
+// Package rtsp reveals the qrcodes seen by IP cameras streaming over RTSP,
+// for access control gates or logistics docks watched around the clock
+//
+// Streams are received and their H.264 or H.265 frames decoded by ffmpeg,
+// which is only needed by this package and must be found in PATH unless
+// Config.FFmpeg points to it. Every frame is scanned as soon as it is
+// decoded and reported along with the camera it comes from:
+//
+//	err := rtsp.Run(ctx, rtsp.Config{
+//		URL:        "rtsp://gate-1.local/stream1",
+//		Camera:     "gate-1",
+//		RetryDelay: 5 * time.Second,
+//	}, func(event rtsp.Event) bool {
+//		for _, code := range event.Result.Code {
+//			log.Printf("%s %s %s", event.Camera, event.Time.Format(time.RFC3339), code.Text())
+//		}
+//		return true
+//	})
+//
+// Stalled cameras are timed out through the socket timeout of the RTSP
+// demuxer, named -timeout since ffmpeg 5 and -stimeout before, the version
+// being asked to ffmpeg once per Run
+package rtsp
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/url"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/quaresc/goquirc"
+)
+
+// DefaultFPS is the count of frames scanned per second when Config leaves it
+// unset, most cameras streaming many more than a qrcode needs
+const DefaultFPS = 5
+
+// DefaultTimeout is how long a silent camera is waited for when Config leaves
+// Timeout unset
+const DefaultTimeout = 10 * time.Second
+
+// Config describes the camera to connect to, a zero FPS falls back to
+// DefaultFPS, a zero Timeout to DefaultTimeout and an empty Transport to
+// "tcp"
+type Config struct {
+	// URL locates the stream, credentials included
+	URL string
+
+	// Camera identifies the camera in the events, errors only report the
+	// URL stripped of its credentials
+	Camera string
+
+	FPS float64
+
+	// Width and Height scale frames before decoding when both are set
+	Width  int
+	Height int
+
+	// Transport carries RTP packets, "tcp" going through most firewalls and
+	// "udp" having less latency
+	Transport string
+
+	// Timeout bounds the wait for data from the camera, past which the
+	// connection is dropped, so that a camera stalling without closing its
+	// connection is retried
+	Timeout time.Duration
+
+	// RetryDelay is waited before connecting again once the stream drops or
+	// fails, a zero RetryDelay making Run return instead
+	RetryDelay time.Duration
+
+	// OnError is called with the error of every dropped connection that is
+	// retried
+	OnError func(error)
+
+	// FFmpeg is the path of the ffmpeg binary
+	FFmpeg string
+}
+
+// Event is the outcome of revealing a frame of a camera
+type Event struct {
+	Camera string
+
+	// Time is when the frame was revealed
+	Time time.Time
+
+	Result goquirc.Result
+	Err    error
+}
+
+// Run connects to a camera and calls fn with the event of every frame
+// scanned, until fn returns false, which gives a nil error, or ctx is done,
+// which gives its error. The stream ending or failing ends Run too unless
+// RetryDelay is set
+func Run(ctx context.Context, cfg Config, fn func(Event) bool, opts ...goquirc.Option) error {
+	if cfg.URL == "" {
+		return errors.New("Missing stream URL")
+	}
+	if cfg.FPS == 0 {
+		cfg.FPS = DefaultFPS
+	}
+	if cfg.Transport == "" {
+		cfg.Transport = "tcp"
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	video := goquirc.VideoConfig{
+		FPS:    cfg.FPS,
+		Width:  cfg.Width,
+		Height: cfg.Height,
+		FFmpeg: cfg.FFmpeg,
+		InputArgs: []string{
+			"-rtsp_transport", cfg.Transport,
+			// Socket timeout of the RTSP demuxer, in microseconds
+			timeoutFlag(ctx, cfg.FFmpeg), strconv.FormatInt(cfg.Timeout.Microseconds(), 10),
+		},
+	}
+
+	for {
+		stopped := false
+		err := goquirc.ScanVideo(ctx, cfg.URL, video, func(frame goquirc.VideoFrame) bool {
+			event := Event{Camera: cfg.Camera, Time: time.Now(), Result: frame.Result, Err: frame.Err}
+			stopped = !fn(event)
+			return !stopped
+		}, opts...)
+		err = redact(err, cfg.URL)
+		switch {
+		case stopped:
+			return nil
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case cfg.RetryDelay <= 0:
+			return err
+		}
+
+		if err == nil {
+			err = errors.New("Stream ended")
+		}
+		if cfg.OnError != nil {
+			cfg.OnError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(cfg.RetryDelay):
+		}
+	}
+}
+
+// redact removes the credentials of a stream URL from an error, which may
+// quote the output of ffmpeg
+func redact(err error, rawURL string) error {
+	u, perr := url.Parse(rawURL)
+	if err == nil || perr != nil || u.User == nil {
+		return err
+	}
+
+	msg := err.Error()
+	stripped := *u
+	stripped.User = nil
+	redacted := strings.ReplaceAll(msg, rawURL, stripped.String())
+	redacted = strings.ReplaceAll(redacted, u.User.String()+"@", "")
+	if password, ok := u.User.Password(); ok && password != "" {
+		redacted = strings.ReplaceAll(redacted, password, "xxxxx")
+	}
+	if redacted == msg {
+		return err
+	}
+	return errors.New(redacted)
+}
+
+// timeoutFlag returns the socket timeout option of the RTSP demuxer of
+// ffmpeg. Before ffmpeg 5 it was -stimeout, -timeout then being a listen
+// timeout in seconds which makes ffmpeg wait for the camera to connect
+func timeoutFlag(ctx context.Context, ffmpeg string) string {
+	if ffmpeg == "" {
+		ffmpeg = "ffmpeg"
+	}
+	out, err := exec.CommandContext(ctx, ffmpeg, "-version").Output()
+	if err == nil && majorVersion(string(out)) < 5 {
+		return "-stimeout"
+	}
+	return "-timeout"
+}
+
+// majorVersion parses the major version of the output of ffmpeg -version,
+// such as "ffmpeg version 4.4.2-0ubuntu0.22.04.1" or "ffmpeg version n6.1".
+// Builds from git, versioned "N-113000-g...", are as recent as it gets
+func majorVersion(out string) int {
+	const prefix = "ffmpeg version "
+	if !strings.HasPrefix(out, prefix) {
+		return math.MaxInt
+	}
+	version := strings.TrimPrefix(out[len(prefix):], "n")
+	end := strings.IndexFunc(version, func(r rune) bool { return r < '0' || r > '9' })
+	if end < 0 {
+		end = len(version)
+	}
+	major, err := strconv.Atoi(version[:end])
+	if err != nil {
+		return math.MaxInt
+	}
+	return major
+}
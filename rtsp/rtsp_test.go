@@ -0,0 +1,24 @@
+package rtsp
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMajorVersion(t *testing.T) {
+	tests := []struct {
+		out  string
+		want int
+	}{
+		{"ffmpeg version 4.4.2-0ubuntu0.22.04.1 Copyright (c) 2000-2021", 4},
+		{"ffmpeg version n6.1 Copyright (c) 2000-2023", 6},
+		{"ffmpeg version 5.1.4-0+deb12u1 Copyright (c) 2000-2023", 5},
+		{"ffmpeg version N-113000-g0123456789 Copyright (c) 2000-2024", math.MaxInt},
+		{"not ffmpeg", math.MaxInt},
+	}
+	for _, tt := range tests {
+		if got := majorVersion(tt.out); got != tt.want {
+			t.Errorf("majorVersion(%q) = %d, want %d", tt.out, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package goquirc
+
+// downscale resamples a grayscale image so that its long edge is maxDim
+// pixels
+func downscale(pix []byte, w int, h int, maxDim int) ([]byte, int, int) {
+	sw, sh := maxDim, maxDim
+	if w > h {
+		sh = (h*maxDim + w/2) / w
+	} else {
+		sw = (w*maxDim + h/2) / h
+	}
+	return resample(pix, w, h, sw, sh)
+}
+
+// resample scales a grayscale image to sw by sh pixels, at least one, each
+// output pixel averaging the source area it covers when shrinking or
+// repeating the nearest source pixel when enlarging
+func resample(pix []byte, w int, h int, sw int, sh int) ([]byte, int, int) {
+	if sw < 1 {
+		sw = 1
+	}
+	if sh < 1 {
+		sh = 1
+	}
+	if sw > w || sh > h {
+		out := make([]byte, sw*sh)
+		for y := 0; y < sh; y++ {
+			row := pix[y*h/sh*w:]
+			for x := 0; x < sw; x++ {
+				out[y*sw+x] = row[x*w/sw]
+			}
+		}
+		return out, sw, sh
+	}
+
+	// Source columns covered by each output column, shared by all rows
+	left := make([]int, sw+1)
+	for x := range left {
+		left[x] = x * w / sw
+	}
+
+	out := make([]byte, sw*sh)
+	sums := make([]uint32, sw)
+	for y := 0; y < sh; y++ {
+		top, bottom := y*h/sh, (y+1)*h/sh
+		for i := range sums {
+			sums[i] = 0
+		}
+		for sy := top; sy < bottom; sy++ {
+			row := pix[sy*w : (sy+1)*w]
+			for x := range sums {
+				for _, p := range row[left[x]:left[x+1]] {
+					sums[x] += uint32(p)
+				}
+			}
+		}
+		for x, sum := range sums {
+			area := uint32((left[x+1] - left[x]) * (bottom - top))
+			out[y*sw+x] = byte((sum + area/2) / area)
+		}
+	}
+	return out, sw, sh
+}
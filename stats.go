@@ -0,0 +1,42 @@
+package goquirc
+
+// Stats describes the memory held by a decoder and what its last
+// identification tracked
+type Stats struct {
+	// ImageBytes is the size of the source image buffer
+	ImageBytes int
+	// PixelBytes is the size of the region map, zero when quirc labels
+	// regions in the source image buffer itself
+	PixelBytes int
+	// FloodFillBytes is the size of the stack used to label regions
+	FloodFillBytes int
+	// ContextBytes is the size of the quirc context, whose region, capstone
+	// and grid tables have a fixed capacity
+	ContextBytes int
+
+	// Regions, Capstones and Grids are the counts tracked by the last
+	// identification, out of MaxRegions regions at most
+	Regions    int
+	MaxRegions int
+	Capstones  int
+	Grids      int
+
+	// Frames is the count of frames revealed since the decoder was created
+	Frames uint64
+}
+
+// Memory returns the total count of bytes allocated by quirc
+func (s Stats) Memory() int {
+	return s.ImageBytes + s.PixelBytes + s.FloodFillBytes + s.ContextBytes
+}
+
+// Stats reports the memory usage and activity of the decoder
+func (d *Decoder) Stats() Stats {
+	if !d.qr.allocated() {
+		return Stats{Frames: d.frames}
+	}
+
+	stats := d.qr.stats()
+	stats.Frames = d.frames
+	return stats
+}
@@ -0,0 +1,116 @@
+package goquirc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"golang.org/x/image/tiff"
+)
+
+// Page is the outcome of the reveal process of a page of a document, Number
+// starting from one
+type Page struct {
+	Number int
+	Result Result
+	Err    error
+}
+
+// DecodeTIFF reveals the qrcodes of every page of a multi-page TIFF. A page
+// which cannot be decoded is reported through its Err without stopping the
+// scan of the following ones
+func DecodeTIFF(r io.Reader, opts ...Option) ([]Page, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	offsets, err := tiffPages(data)
+	if err != nil {
+		return nil, err
+	}
+
+	d, err := NewDecoder()
+	if err != nil {
+		return nil, err
+	}
+	defer d.Close()
+
+	pages := make([]Page, len(offsets))
+	for i, offset := range offsets {
+		pages[i].Number = i + 1
+		img, err := tiff.Decode(&tiffPage{data: data, offset: offset})
+		if err != nil {
+			pages[i].Err = err
+			continue
+		}
+		pages[i].Result, pages[i].Err = d.RevealImage(img, opts...)
+	}
+	return pages, nil
+}
+
+// tiffPages walks the chain of image file directories and returns the
+// offset of each one
+func tiffPages(data []byte) ([]uint32, error) {
+	if len(data) < 8 {
+		return nil, errors.New("TIFF header is too short")
+	}
+	var order binary.ByteOrder
+	switch string(data[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return nil, errors.New("Invalid TIFF byte order")
+	}
+
+	var offsets []uint32
+	seen := make(map[uint32]bool)
+	for offset := order.Uint32(data[4:]); offset != 0; {
+		if seen[offset] || uint64(offset)+2 > uint64(len(data)) {
+			return nil, errors.New("Invalid TIFF directory offset")
+		}
+		seen[offset] = true
+		offsets = append(offsets, offset)
+
+		next := uint64(offset) + 2 + 12*uint64(order.Uint16(data[offset:]))
+		if next+4 > uint64(len(data)) {
+			return nil, errors.New("Invalid TIFF directory offset")
+		}
+		offset = order.Uint32(data[next:])
+	}
+	return offsets, nil
+}
+
+// tiffPage reads a TIFF file as if its first directory was the one at offset,
+// since x/image/tiff only decodes the first page
+type tiffPage struct {
+	data   []byte
+	offset uint32
+	pos    int64
+}
+
+// ReadAt patches the first directory offset of the header on the fly
+func (p *tiffPage) ReadAt(b []byte, off int64) (int, error) {
+	n, err := bytes.NewReader(p.data).ReadAt(b, off)
+	for i := 0; i < n; i++ {
+		if at := off + int64(i); at >= 4 && at < 8 {
+			var header [4]byte
+			if p.data[0] == 'I' {
+				binary.LittleEndian.PutUint32(header[:], p.offset)
+			} else {
+				binary.BigEndian.PutUint32(header[:], p.offset)
+			}
+			b[i] = header[at-4]
+		}
+	}
+	return n, err
+}
+
+// Read reads sequentially through ReadAt
+func (p *tiffPage) Read(b []byte) (int, error) {
+	n, err := p.ReadAt(b, p.pos)
+	p.pos += int64(n)
+	return n, err
+}
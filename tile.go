@@ -0,0 +1,103 @@
+package goquirc
+
+import (
+	"context"
+	"errors"
+	"image"
+	"sync"
+)
+
+// tileGrid splits an image into tiles of size pixels overlapping by overlap
+// pixels, the last tiles of each axis being aligned on the image edges
+func tileGrid(w int, h int, size int, overlap int) []image.Rectangle {
+	starts := func(length int) []int {
+		if length <= size {
+			return []int{0}
+		}
+		var positions []int
+		for p := 0; ; p += size - overlap {
+			if p+size >= length {
+				return append(positions, length-size)
+			}
+			positions = append(positions, p)
+		}
+	}
+
+	var tiles []image.Rectangle
+	for _, y := range starts(h) {
+		for _, x := range starts(w) {
+			tiles = append(tiles, image.Rect(x, y, x+size, y+size).Intersect(image.Rect(0, 0, w, h)))
+		}
+	}
+	return tiles
+}
+
+// tiled reveals the qrcodes of each tile of an image with its own detection,
+// then merges their results in image coordinates
+func (qr *Processing) tiled(ctx context.Context, image *[]byte, w int, h int, cfg *config) (Result, error) {
+	if cfg.tileOverlap >= cfg.tileSize {
+		return Result{}, errors.New("Tile overlap must be smaller than tile size")
+	}
+
+	// Visits and early stops apply to merged results only
+	sub := *cfg
+	sub.visit, sub.visitFailure, sub.first = nil, nil, false
+
+	tiles := tileGrid(w, h, cfg.tileSize, cfg.tileOverlap)
+	results := make([]Result, len(tiles))
+	errs := make([]error, len(tiles))
+	scan := func(p *Processing, i int) {
+		crop, roi, err := cropROI(*image, w, h, tiles[i])
+		if err != nil {
+			errs[i] = err
+			return
+		}
+		results[i], errs[i] = p.detect(ctx, &crop, roi.Dx(), roi.Dy(), sub.mapped(func(p Position) Position {
+			return Position{p.X + roi.Min.X, p.Y + roi.Min.Y}
+		}))
+	}
+
+	workers := cfg.tileWorkers
+	if workers > len(tiles) {
+		workers = len(tiles)
+	}
+	if workers <= 1 {
+		for i := range tiles {
+			scan(qr, i)
+			if errs[i] != nil || (cfg.first && results[i].Usable > 0) {
+				break
+			}
+		}
+	} else {
+		next := make(chan int)
+		var wg sync.WaitGroup
+		for n := 0; n < workers; n++ {
+			var p Processing
+			if err := p.CreateRegions(qr.regions); err != nil {
+				close(next)
+				wg.Wait()
+				return Result{}, err
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer p.Destroy()
+				for i := range next {
+					scan(&p, i)
+				}
+			}()
+		}
+		for i := range tiles {
+			next <- i
+		}
+		close(next)
+		wg.Wait()
+	}
+
+	for _, err := range errs {
+		if err != nil {
+			return Result{}, err
+		}
+	}
+	return merge(results, cfg), nil
+}
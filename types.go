@@ -0,0 +1,55 @@
+package goquirc
+
+import "strconv"
+
+// DataType describes how the payload of a qrcode is encoded
+type DataType int
+
+// Data types as reported by quirc
+const (
+	DataTypeNumeric      DataType = 1
+	DataTypeAlphanumeric DataType = 2
+	DataTypeByte         DataType = 4
+	DataTypeKanji        DataType = 8
+)
+
+// String returns the name of the data type
+func (t DataType) String() string {
+	switch t {
+	case DataTypeNumeric:
+		return "Numeric"
+	case DataTypeAlphanumeric:
+		return "Alphanumeric"
+	case DataTypeByte:
+		return "Byte"
+	case DataTypeKanji:
+		return "Kanji"
+	}
+	return "DataType(" + strconv.Itoa(int(t)) + ")"
+}
+
+// ECCLevel describes the error correction level of a qrcode
+type ECCLevel int
+
+// Error correction levels as reported by quirc
+const (
+	ECCLevelM ECCLevel = 0
+	ECCLevelL ECCLevel = 1
+	ECCLevelH ECCLevel = 2
+	ECCLevelQ ECCLevel = 3
+)
+
+// String returns the letter naming the error correction level
+func (l ECCLevel) String() string {
+	switch l {
+	case ECCLevelM:
+		return "M"
+	case ECCLevelL:
+		return "L"
+	case ECCLevelH:
+		return "H"
+	case ECCLevelQ:
+		return "Q"
+	}
+	return "ECCLevel(" + strconv.Itoa(int(l)) + ")"
+}
@@ -0,0 +1,206 @@
+package goquirc
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"time"
+)
+
+// DefaultVideoFPS is the sampling rate used when VideoConfig leaves it unset
+const DefaultVideoFPS = 2
+
+// VideoConfig tunes the extraction of video frames, a zero FPS falls back to
+// DefaultVideoFPS and an empty FFmpeg to the ffmpeg binary found in PATH
+type VideoConfig struct {
+	// FPS is the count of frames extracted per second of footage, below one
+	// for long recordings
+	FPS float64
+
+	// Width and Height scale frames before decoding when both are set,
+	// frames keep the dimensions of the video otherwise
+	Width  int
+	Height int
+
+	// FFmpeg is the path of the ffmpeg binary and InputArgs the options
+	// placed before its input, such as "-rtsp_transport", "tcp"
+	FFmpeg    string
+	InputArgs []string
+}
+
+// VideoFrame is the outcome of the reveal process of a frame extracted from
+// a video, Timestamp being its position from the start of the footage
+type VideoFrame struct {
+	Timestamp time.Duration
+	Result    Result
+	Err       error
+}
+
+// DecodeVideo extracts grayscale frames of a video file with ffmpeg at a
+// steady rate and reveals their qrcodes, in timestamp order
+func DecodeVideo(path string, cfg VideoConfig, opts ...Option) ([]VideoFrame, error) {
+	return DecodeVideoContext(context.Background(), path, cfg, opts...)
+}
+
+// DecodeVideoContext works like DecodeVideo but stops ffmpeg as soon as ctx
+// is done, returning the frames revealed so far along with the error of ctx
+func DecodeVideoContext(ctx context.Context, path string, cfg VideoConfig, opts ...Option) ([]VideoFrame, error) {
+	var frames []VideoFrame
+	err := ScanVideo(ctx, path, cfg, func(frame VideoFrame) bool {
+		frames = append(frames, frame)
+		return true
+	}, opts...)
+	return frames, err
+}
+
+// ScanVideo works like DecodeVideoContext but hands every frame to fn as soon
+// as it is revealed, until the video ends or fn returns false, which suits
+// live streams ffmpeg can open such as RTSP cameras
+func ScanVideo(ctx context.Context, path string, cfg VideoConfig, fn func(VideoFrame) bool, opts ...Option) error {
+	if cfg.FPS < 0 {
+		return errors.New("Negative frame rate")
+	}
+	if cfg.FPS == 0 {
+		cfg.FPS = DefaultVideoFPS
+	}
+	if cfg.FFmpeg == "" {
+		cfg.FFmpeg = "ffmpeg"
+	}
+	filter := "fps=" + strconv.FormatFloat(cfg.FPS, 'g', -1, 64)
+	if cfg.Width > 0 && cfg.Height > 0 {
+		filter += fmt.Sprintf(",scale=%d:%d", cfg.Width, cfg.Height)
+	}
+
+	d, err := NewDecoder()
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	// Frames come out as a stream of binary PGM images, whose headers carry
+	// the dimensions of the video
+	args := append([]string{"-hide_banner", "-loglevel", "error", "-nostdin"}, cfg.InputArgs...)
+	args = append(args, "-i", path, "-an", "-vf", filter,
+		"-pix_fmt", "gray", "-c:v", "pgm", "-f", "image2pipe", "-")
+	run, stop := context.WithCancel(ctx)
+	defer stop()
+	cmd := exec.CommandContext(run, cfg.FFmpeg, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	r := bufio.NewReader(stdout)
+	stopped := false
+	for i := 0; ; i++ {
+		if err = readPGMFrame(r, d); err != nil {
+			break
+		}
+		frame := VideoFrame{Timestamp: time.Duration(float64(i) / cfg.FPS * float64(time.Second))}
+		frame.Result, frame.Err = d.Scan(opts...)
+		if !fn(frame) {
+			stopped = true
+			break
+		}
+	}
+	// ffmpeg is stopped rather than drained unless it already ended its
+	// output, as a live stream never ends
+	killed := stopped || (err != io.EOF && err != errTruncatedFrame)
+	if killed {
+		stop()
+	}
+	io.Copy(io.Discard, r)
+
+	werr := cmd.Wait()
+	switch {
+	case ctx.Err() != nil:
+		return ctx.Err()
+	case stopped:
+		return nil
+	case werr != nil && !killed:
+		return fmt.Errorf("ffmpeg: %v: %s", werr, bytes.TrimSpace(stderr.Bytes()))
+	case err != io.EOF:
+		return err
+	}
+	return nil
+}
+
+// errTruncatedFrame is returned when the output of ffmpeg ends within a frame
+var errTruncatedFrame = errors.New("Truncated PGM frame")
+
+// readPGMFrame reads the next binary PGM image of a stream straight into the
+// image buffer of d, resized to its dimensions. It returns io.EOF when the
+// stream ends between images
+func readPGMFrame(r *bufio.Reader, d *Decoder) error {
+	var magic [2]byte
+	if _, err := io.ReadFull(r, magic[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return errTruncatedFrame
+		}
+		return err
+	}
+	if magic != [2]byte{'P', '5'} {
+		return errors.New("Unexpected frame format, binary PGM expected")
+	}
+
+	var fields [3]int
+	for i := range fields {
+		n, err := readPGMField(r)
+		if err != nil {
+			return err
+		}
+		fields[i] = n
+	}
+	w, h, maxval := fields[0], fields[1], fields[2]
+	if maxval == 0 || maxval > 255 {
+		return errors.New("Unsupported PGM depth, 8 bits expected")
+	}
+	if err := d.Resize(w, h); err != nil {
+		return err
+	}
+
+	if _, err := io.ReadFull(r, d.Buffer()); err != nil {
+		return errTruncatedFrame
+	}
+	return nil
+}
+
+// readPGMField reads a decimal header field, skipping the whitespace and
+// comments before it and consuming the single whitespace after it
+func readPGMField(r *bufio.Reader) (int, error) {
+	c, err := r.ReadByte()
+	for err == nil && (pgmSpace(c) || c == '#') {
+		if c == '#' {
+			_, err = r.ReadString('\n')
+		}
+		if err == nil {
+			c, err = r.ReadByte()
+		}
+	}
+
+	n, digits := 0, 0
+	for err == nil && c >= '0' && c <= '9' && n <= 1<<20 {
+		n = n*10 + int(c-'0')
+		digits++
+		c, err = r.ReadByte()
+	}
+	if err != nil || digits == 0 || n > 1<<20 || !pgmSpace(c) {
+		return 0, errors.New("Malformed PGM header")
+	}
+	return n, nil
+}
+
+// pgmSpace tells whether c separates the fields of a PGM header
+func pgmSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}